@@ -1,20 +1,26 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"reflect"
 	"strconv"
 	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/sunny0826/kubecm/pkg/bundle"
 	"golang.org/x/exp/slices"
 	"k8s.io/apimachinery/pkg/util/rand"
 	"k8s.io/client-go/tools/clientcmd"
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 )
 
+// passphraseEnv holds the passphrase of encrypted bundles.
+const passphraseEnv = "KUBECM_PASSPHRASE"
+
 // AddCommand add command struct
 type AddCommand struct {
 	BaseCommand
@@ -25,6 +31,11 @@ type KubeConfigOption struct {
 	config                *clientcmdapi.Config
 	fileName              string
 	insecureSkipTLSVerify bool
+	// skipExisting skips contexts whose name is taken instead of asking
+	// for a new one, recording them in skipped.
+	skipExisting bool
+	added        []string
+	skipped      []string
 }
 
 // Init AddCommand
@@ -47,6 +58,7 @@ func (ac *AddCommand) Init() {
 	ac.command.Flags().StringSlice("context-template", []string{"context"}, "define the attributes used for composing the context name, available values: filename, user, cluster, context, namespace")
 	ac.command.Flags().Bool("select-context", false, "select the context to be added in interactive mode")
 	ac.command.Flags().Bool("insecure-skip-tls-verify", false, "if true, the server's certificate will not be checked for validity")
+	ac.command.Flags().String("identity", "", "age identity file decrypting encrypted bundles (default ~/.kubecm/identity.txt)")
 	_ = ac.command.MarkFlagRequired("file")
 	ac.AddCommands(&DocsCommand{})
 }
@@ -60,6 +72,7 @@ func (ac *AddCommand) runAdd(cmd *cobra.Command, args []string) error {
 	contextTemplate, _ := ac.command.Flags().GetStringSlice("context-template")
 	selectContext, _ := ac.command.Flags().GetBool("select-context")
 	insecureSkipTLSVerify, _ := ac.command.Flags().GetBool("insecure-skip-tls-verify")
+	identity, _ := ac.command.Flags().GetString("identity")
 
 	var newConfig *clientcmdapi.Config
 
@@ -78,6 +91,12 @@ func (ac *AddCommand) runAdd(cmd *cobra.Command, args []string) error {
 		if err != nil {
 			return err
 		}
+		if bundle.IsEncrypted(contents) {
+			contents, err = decryptBundle(contents, identity)
+			if err != nil {
+				return err
+			}
+		}
 		newConfig, err = clientcmd.Load(contents)
 		if err != nil {
 			return err
@@ -88,7 +107,7 @@ func (ac *AddCommand) runAdd(cmd *cobra.Command, args []string) error {
 		if err != nil {
 			return err
 		}
-		newConfig, err = clientcmd.LoadFromFile(file)
+		newConfig, err = loadAddFile(file, identity)
 		if err != nil {
 			return err
 		}
@@ -101,6 +120,62 @@ func (ac *AddCommand) runAdd(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// loadAddFile loads the kubeconfig to add, decrypting it first when it is
+// a bundle written by export --encrypt.
+func loadAddFile(file, identity string) (*clientcmdapi.Config, error) {
+	contents, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	if !bundle.IsEncrypted(contents) {
+		return clientcmd.LoadFromFile(file)
+	}
+	contents, err = decryptBundle(contents, identity)
+	if err != nil {
+		return nil, err
+	}
+	return clientcmd.Load(contents)
+}
+
+// decryptBundle decrypts a bundle with the keys of the identity file, the
+// default one being optional, or with a passphrase.
+func decryptBundle(contents []byte, identityFile string) ([]byte, error) {
+	explicit := identityFile != ""
+	if !explicit {
+		var err error
+		identityFile, err = bundle.DefaultIdentityFile()
+		if err != nil {
+			return nil, err
+		}
+	}
+	identities, err := bundle.LoadIdentities(identityFile)
+	if err != nil && (explicit || !errors.Is(err, fs.ErrNotExist)) {
+		return nil, err
+	}
+	return bundle.Decrypt(contents, identities, bundlePassphrase)
+}
+
+// bundlePassphrase returns $KUBECM_PASSPHRASE or asks for the passphrase.
+func bundlePassphrase() (string, error) {
+	if passphrase := os.Getenv(passphraseEnv); passphrase != "" {
+		return passphrase, nil
+	}
+	if err := passphrasePromptable(); err != nil {
+		return "", err
+	}
+	return PassphraseUI("Passphrase")
+}
+
+// passphrasePromptable reports why a bundle passphrase cannot be prompted
+// for: in non-interactive mode, or when stdin is not a terminal, as when the
+// bundle itself is piped in with -f -.
+func passphrasePromptable() error {
+	if !isInteractive() {
+		return fmt.Errorf("cannot prompt for the bundle passphrase, set $%s", passphraseEnv)
+	}
+	return nil
+}
+
 // AddToLocal add kubeConfig to local
 func AddToLocal(newConfig *clientcmdapi.Config, path, contextPrefix string, cover bool, selectContext bool, contextTemplate []string, context []string, insecureSkipTLSVerify bool) error {
 	kubeconfig, err := SelectKubeconfigFile("Select The kubeconfig file to add to")
@@ -176,6 +251,14 @@ func (kc *KubeConfigOption) handleContexts(oldConfig *clientcmdapi.Config, conte
 
 		var quitNewName bool
 		for checkContextName(newName, oldConfig) {
+			if kc.skipExisting {
+				kc.skipped = append(kc.skipped, newName)
+				quitNewName = true
+				break
+			}
+			if nonInteractive {
+				return nil, fmt.Errorf("context %q already exists", newName)
+			}
 			nameConfirm := BoolUI(fmt.Sprintf("「%s」 Name already exists, do you want to rename it? (If you select `False`, this context will not be merged)", newName))
 			if nameConfirm == "True" {
 				newName = PromptUI("Rename", newName)
@@ -190,6 +273,7 @@ func (kc *KubeConfigOption) handleContexts(oldConfig *clientcmdapi.Config, conte
 		}
 		itemConfig := kc.handleContext(oldConfig, newName, ctx)
 		newConfig = appendConfig(newConfig, itemConfig)
+		kc.added = append(kc.added, newName)
 		fmt.Printf("Add Context: %s \n", newName)
 	}
 	outConfig := appendConfig(oldConfig, newConfig)
@@ -350,5 +434,9 @@ kubecm add -f test.yaml --context context1,context2
 cat /etc/kubernetes/admin.conf | kubecm add -f -
 # Merge test.yaml with $HOME/.kube/config and skip TLS certificate verification
 kubecm add -f test.yaml --insecure-skip-tls-verify
+# Add an encrypted bundle from kubecm export --encrypt, with the key in ~/.kubecm/identity.txt (age-keygen -o ~/.kubecm/identity.txt)
+kubecm add -f dev.age
+# Add an encrypted bundle with another age identity file
+kubecm add -f dev.age --identity ~/keys/age.txt
 `
 }
@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
@@ -8,6 +10,7 @@ import (
 
 	"github.com/manifoldco/promptui"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/sunny0826/kubecm/pkg/cloud"
 )
 
@@ -16,48 +19,6 @@ type CloudCommand struct {
 	BaseCommand
 }
 
-// CloudInfo Public cloud info
-type CloudInfo struct {
-	Name     string
-	Alias    []string
-	HomePage string
-	Service  string
-}
-
-// Clouds date of clouds
-var Clouds = []CloudInfo{
-	{
-		Name:     "AlibabaCloud",
-		Alias:    []string{"alibabacloud", "alicloud", "aliyun", "ack"},
-		HomePage: "https://cs.console.aliyun.com",
-		Service:  "ACK",
-	},
-	{
-		Name:     "TencentCloud",
-		Alias:    []string{"tencentcloud", "tencent", "tke"},
-		HomePage: "https://console.cloud.tencent.com/tke",
-		Service:  "TKE",
-	},
-	{
-		Name:     "Rancher",
-		Alias:    []string{"rancher"},
-		HomePage: "https://rancher.com",
-		Service:  "Rancher",
-	},
-	{
-		Name:     "AWS",
-		Alias:    []string{"aws", "eks"},
-		HomePage: "https://console.aws.amazon.com/eks/home",
-		Service:  "EKS",
-	},
-	{
-		Name:     "Azure",
-		Alias:    []string{"azure", "aks"},
-		HomePage: "https://portal.azure.com",
-		Service:  "AKS",
-	},
-}
-
 // Init CloudCommand
 func (cc *CloudCommand) Init() {
 	cc.command = &cobra.Command{
@@ -69,223 +30,234 @@ func (cc *CloudCommand) Init() {
 	cc.command.PersistentFlags().String("cluster_id", "", "kubernetes cluster id")
 	cc.command.PersistentFlags().String("region_id", "", "cloud region id")
 	cc.command.PersistentFlags().String("aws_profile", "", "AWS profile name (from ~/.aws/config)")
+	cc.command.PersistentFlags().Bool("all-regions", false, "search clusters in all regions (AWS)")
+	cc.command.PersistentFlags().StringSlice("profiles", nil, "search clusters with each of these profiles, e.g. p1,p2 (AWS)")
+	cc.command.PersistentFlags().StringSlice("filter", []string{}, "only clusters matching all of these key=value filters; keys status and endpoint (public/private) match cluster fields, others match tags")
+	cc.command.PersistentFlags().BoolVar(&nonInteractive, "non-interactive", false, "fail with an error instead of prompting for missing input")
 	cc.AddCommands(&CloudAddCommand{})
 	cc.AddCommands(&CloudListCommand{})
 	cc.AddCommands(&DocsCommand{})
 }
 
-func getClusters(provider, regionID, awsProfile string, num int) ([]cloud.ClusterInfo, error) {
-	var clusters []cloud.ClusterInfo
-	var err error
-	switch num {
-	case -1:
-		var allAlias []string
-		for _, cloudInfo := range Clouds {
-			allAlias = append(allAlias, cloudInfo.Alias...)
-		}
-		return nil, fmt.Errorf("'%s' is not supported, supported cloud alias are %v", provider, allAlias)
-	case 0:
-		accessKeyID, accessKeySecret := checkEnvForSecret(0)
-		ali := cloud.AliCloud{
-			AccessKeyID:     accessKeyID,
-			AccessKeySecret: accessKeySecret,
+// cloudProvider resolves the --provider flag, prompting for a provider when
+// it is empty.
+func cloudProvider(provider string) (cloud.ProviderSpec, error) {
+	if provider == "" {
+		if nonInteractive {
+			return cloud.ProviderSpec{}, requiredInputError("--provider")
 		}
-		clusters, err = ali.ListCluster()
-		if err != nil {
-			return nil, err
-		}
-	case 1:
-		secretID, secretKey := checkEnvForSecret(1)
-		ten := cloud.TencentCloud{
-			SecretID:  secretID,
-			SecretKey: secretKey,
+		specs := cloud.Providers()
+		return specs[selectCloud(specs, "Select Cloud")], nil
+	}
+	spec, ok := cloud.LookupProvider(provider)
+	if !ok {
+		return cloud.ProviderSpec{}, fmt.Errorf("'%s' is not supported, supported cloud alias are %v", provider, cloud.ProviderAliases())
+	}
+	return spec, nil
+}
+
+// requiredInputError reports input that would have been prompted for.
+func requiredInputError(input string) error {
+	return fmt.Errorf("%s is required in non-interactive mode", input)
+}
+
+// cloudScope holds the flags selecting where clusters are searched.
+type cloudScope struct {
+	region     string
+	awsProfile string
+	allRegions bool
+	profiles   []string
+}
+
+func cloudScopeFlags(flags *pflag.FlagSet) cloudScope {
+	scope := cloudScope{}
+	scope.region, _ = flags.GetString("region_id")
+	scope.awsProfile, _ = flags.GetString("aws_profile")
+	scope.allRegions, _ = flags.GetBool("all-regions")
+	scope.profiles, _ = flags.GetStringSlice("profiles")
+	return scope
+}
+
+// values maps provider specific flags to credential values.
+func (s cloudScope) values() map[string]string {
+	values := map[string]string{}
+	if s.awsProfile != "" {
+		values["AWS_PROFILE"] = s.awsProfile
+	} else if len(s.profiles) > 0 {
+		values["AWS_PROFILE"] = s.profiles[0]
+	}
+	return values
+}
+
+// resolveProviderConfig collects the credentials and region of a provider.
+// Flag values take precedence over the environment; whatever is still
+// missing is prompted for, unless running non-interactively.
+func resolveProviderConfig(ctx context.Context, spec cloud.ProviderSpec, scope cloudScope) (cloud.ProviderConfig, error) {
+	cfg := cloud.ProviderConfig{
+		Credentials: make(map[string]string),
+		Region:      scope.region,
+		Profiles:    scope.profiles,
+	}
+	if scope.allRegions && !spec.MultiRegion {
+		return cfg, fmt.Errorf("--all-regions is not supported by %s", spec.Name)
+	}
+	if len(scope.profiles) > 0 && !spec.MultiProfile {
+		return cfg, fmt.Errorf("--profiles is not supported by %s", spec.Name)
+	}
+	values := scope.values()
+	lookup := func(env string) string {
+		if v := values[env]; v != "" {
+			return v
 		}
-		if regionID == "" {
-			regionList, err := ten.GetRegionID()
-			if err != nil {
-				return nil, err
+		return os.Getenv(env)
+	}
+
+	if len(spec.AuthModes) > 0 {
+		cfg.AuthMode = chooseAuthMode(spec.AuthModes, values, lookup)
+		for _, source := range spec.AuthModes[cfg.AuthMode].Credentials {
+			v := lookup(source.Env)
+			if v == "" && source.Prompt != "" && !nonInteractive {
+				v = PromptUI(source.Prompt, "")
+			}
+			if v == "" && !source.Optional {
+				if nonInteractive {
+					return cfg, fmt.Errorf("%s must be set in non-interactive mode", source.Env)
+				}
+				return cfg, fmt.Errorf("%s is required", source.Env)
 			}
-			regionNum := selectRegion(regionList, "Select Region Name")
-			ten.RegionID = regionList[regionNum]
-		} else {
-			ten.RegionID = regionID
+			cfg.Credentials[source.Env] = v
 		}
-		clusters, err = ten.ListCluster()
+	}
+
+	if scope.allRegions {
+		regionList, err := spec.Regions(ctx, cfg)
 		if err != nil {
-			return nil, err
+			return cfg, err
 		}
-	case 2:
-		fmt.Println("⛅  Selected: Rancher")
-		serverURL, apiKey := checkEnvForSecret(2)
-		rancher := cloud.Rancher{
-			ServerURL: serverURL,
-			APIKey:    apiKey,
-		}
-		clusters, err = rancher.ListCluster()
-		if err != nil {
-			return nil, err
+		cfg.Regions = regionList
+		return cfg, nil
+	}
+	if cfg.Region == "" {
+		for _, env := range spec.RegionEnv {
+			if cfg.Region = os.Getenv(env); cfg.Region != "" {
+				break
+			}
 		}
-	case 3:
-		fmt.Println("⛅  Selected: AWS")
-		awsProvider, err := buildAWSProvider(awsProfile, regionID)
-		if err != nil {
-			return nil, err
+	}
+	if spec.NeedsRegion && cfg.Region == "" {
+		if nonInteractive {
+			return cfg, requiredInputError("--region_id")
 		}
-		clusters, err = awsProvider.ListCluster()
+		regionList, err := spec.Regions(ctx, cfg)
 		if err != nil {
-			return nil, err
+			return cfg, err
 		}
-	case 4:
-		fmt.Println("⛅  Selected: Azure")
-		authModes := []string{"Default (SDK Auth)", "Service Principal"}
-		authMode := selectOption(nil, authModes, "Select Auth Type")
-		azure := cloud.Azure{
-			AuthMode:       cloud.AzureAuth(authMode),
-			TenantID:       os.Getenv("AZURE_TENANT_ID"),
-			SubscriptionID: os.Getenv("AZURE_SUBSCRIPTION_ID"),
-		}
-
-		if azure.TenantID == "" {
-			azure.TenantID = PromptUI("Azure Tenant ID", "")
-		}
-
-		if azure.AuthMode == cloud.AuthModeServicePrincipal {
-			azure.ClientID, azure.ClientSecret = checkEnvForSecret(4)
-			azure.ObjectID = os.Getenv("AZURE_OBJECT_ID")
-
-			if azure.ObjectID == "" {
-				azure.ObjectID = PromptUI("Azure Object ID", "")
-			}
+		if len(regionList) == 0 {
+			return cfg, errors.New("no regions found")
 		}
+		cfg.Region = regionList[selectRegion(regionList, "Select Region ID")]
+	}
+	return cfg, nil
+}
 
-		subscriptionList, err := azure.ListSubscriptions()
-		if err != nil {
+// listCloudClusters lists the clusters of a provider. When only part of the
+// search failed, e.g. a region that is not enabled, a warning is printed and
+// the clusters found are returned.
+func listCloudClusters(ctx context.Context, prov cloud.Provider) ([]cloud.ClusterInfo, error) {
+	clusters, err := prov.ListClusters(ctx)
+	if err != nil {
+		if len(clusters) == 0 {
 			return nil, err
 		}
-
-		for _, subscription := range subscriptionList {
-			if azure.SubscriptionID != "" && azure.SubscriptionID != subscription.ID {
-				continue
-			}
-
-			subscriptionClusters, err := azure.ListCluster(subscription)
-			if err != nil {
-				return nil, err
-			}
-			clusters = append(clusters, subscriptionClusters...)
-		}
+		printWarning(os.Stderr, fmt.Sprintf("Warning: %v\n", err))
 	}
+	return clusters, nil
+}
 
-	return clusters, err
+// allClustersFlag reports whether --all, or its --all-clusters alias, is set.
+func allClustersFlag(flags *pflag.FlagSet) bool {
+	all, _ := flags.GetBool("all")
+	allClusters, _ := flags.GetBool("all-clusters")
+	return all || allClusters
 }
 
-// buildAWSProvider creates an AWS provider with the appropriate auth mode.
-// If awsProfile is set, it uses the default credential chain with that profile.
-// Otherwise, it prompts the user to select an auth type.
-func buildAWSProvider(awsProfile, regionID string) (cloud.AWS, error) {
-	a := cloud.AWS{}
+// clusterFilters parses the key=value --filter flags.
+func clusterFilters(flags *pflag.FlagSet) (map[string]string, error) {
+	values, _ := flags.GetStringSlice("filter")
+	return parseClusterFilters(values)
+}
 
-	if awsProfile != "" {
-		// Profile flag provided: use default credential chain with profile
-		a.AuthMode = cloud.AWSAuthDefault
-		a.Profile = awsProfile
-	} else {
-		// Prompt for auth type (like Azure does)
-		authModes := []string{"Default (Credential Chain)", "Static Credentials"}
-		authMode := selectOption(nil, authModes, "Select Auth Type")
-		switch authMode {
-		case 0:
-			a.AuthMode = cloud.AWSAuthDefault
-			// Check for AWS_PROFILE env var
-			if envProfile := os.Getenv("AWS_PROFILE"); envProfile != "" {
-				a.Profile = envProfile
-			}
-		case 1:
-			a.AuthMode = cloud.AWSAuthStaticCredentials
-			a.AccessKeyID, a.AccessKeySecret = checkEnvForSecret(3)
-		}
+func parseClusterFilters(values []string) (map[string]string, error) {
+	if len(values) == 0 {
+		return nil, nil
 	}
-
-	// Resolve region
-	if regionID != "" {
-		a.RegionID = regionID
-	} else {
-		// Check environment variables
-		if envRegion := os.Getenv("AWS_REGION"); envRegion != "" {
-			a.RegionID = envRegion
-		} else if envRegion := os.Getenv("AWS_DEFAULT_REGION"); envRegion != "" {
-			a.RegionID = envRegion
-		} else {
-			regionList, err := cloud.GetRegionID()
-			if err != nil {
-				return cloud.AWS{}, err
-			}
-			regionNum := selectRegion(regionList, "Select Region ID")
-			a.RegionID = regionList[regionNum]
+	filters := make(map[string]string, len(values))
+	for _, value := range values {
+		key, v, ok := strings.Cut(value, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid --filter %q, expected key=value", value)
 		}
+		filters[key] = v
 	}
-
-	return a, nil
+	return filters, nil
 }
 
-func checkEnvForSecret(num int) (string, string) {
-	switch num {
-	case 0:
-		accessKeyID, id := os.LookupEnv("ACCESS_KEY_ID")
-		accessKeySecret, sec := os.LookupEnv("ACCESS_KEY_SECRET")
-		if !id || !sec {
-			accessKeyID = PromptUI("AlibabaCloud Access Key ID", "")
-			accessKeySecret = PromptUI("AlibabaCloud Access Key Secret", "")
-		}
-		return accessKeyID, accessKeySecret
-	case 1:
-		secretID, id := os.LookupEnv("TENCENTCLOUD_SECRET_ID")
-		secretKey, key := os.LookupEnv("TENCENTCLOUD_SECRET_KEY")
-		if !id || !key {
-			secretID = PromptUI("TencentCloud API secretId", "")
-			secretKey = PromptUI("TencentCloud API secretKey", "")
-		}
-		return secretID, secretKey
-	case 2:
-		serverURL, su := os.LookupEnv("RANCHER_SERVER_URL")
-		apiKey, key := os.LookupEnv("RANCHER_API_KEY")
-		if !su || !key {
-			serverURL = PromptUI("Rancher API serverURL", "")
-			apiKey = PromptUI("Rancher API key", "")
-		}
-		return serverURL, apiKey
-	case 3:
-		accessKeyID, id := os.LookupEnv("AWS_ACCESS_KEY_ID")
-		accessKeySecret, key := os.LookupEnv("AWS_SECRET_ACCESS_KEY")
-		if !id || !key {
-			accessKeyID = PromptUI("AWS Access Key ID", "")
-			accessKeySecret = PromptUI("AWS Access Key Secret", "")
+// chooseAuthMode picks the auth mode to use. A mode is implied by an
+// explicit value for one of its credentials. Otherwise the user is asked, or
+// in non-interactive mode the mode with the most required credentials, all
+// of them set, is used.
+func chooseAuthMode(modes []cloud.AuthMode, values map[string]string, lookup func(string) string) int {
+	for i, mode := range modes {
+		for _, source := range mode.Credentials {
+			if values[source.Env] != "" {
+				return i
+			}
 		}
-		return accessKeyID, accessKeySecret
-	case 4:
-		accessKeyID, id := os.LookupEnv("AZURE_CLIENT_ID")
-		accessKeySecret, key := os.LookupEnv("AZURE_CLIENT_SECRET")
-		if !id || !key {
-			accessKeyID = PromptUI("Azure Client ID", "")
-			accessKeySecret = PromptUI("Azure Client Secret", "")
+	}
+	if len(modes) == 1 {
+		return 0
+	}
+	if !nonInteractive {
+		names := make([]string, len(modes))
+		for i, mode := range modes {
+			names[i] = mode.Name
 		}
-		return accessKeyID, accessKeySecret
+		return selectOption(nil, names, "Select Auth Type")
 	}
-	return "", ""
-}
 
-func checkFlags(provider string) int {
-	for i, cloud := range Clouds {
-		for _, alias := range cloud.Alias {
-			if alias == provider {
-				return i
+	fallback, best, bestRequired := -1, -1, 0
+	for i, mode := range modes {
+		required := 0
+		set := 0
+		for _, source := range mode.Credentials {
+			if source.Optional {
+				continue
+			}
+			required++
+			if lookup(source.Env) != "" {
+				set++
 			}
 		}
+		if required == 0 {
+			if fallback == -1 {
+				fallback = i
+			}
+			continue
+		}
+		if set == required && required > bestRequired {
+			best, bestRequired = i, required
+		}
+	}
+	if best != -1 {
+		return best
+	}
+	if fallback == -1 {
+		fallback = 0
 	}
-	return -1
+	return fallback
 }
 
-func selectCloud(clouds []CloudInfo, label string) int {
+func selectCloud(clouds []cloud.ProviderSpec, label string) int {
 	templates := &promptui.SelectTemplates{
 		Label:    "{{ . }}",
 		Active:   "\U0001F680 {{ .Name | red }}",
@@ -1,16 +1,24 @@
 package cmd
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
-	"os"
+	"path"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"text/template"
+	"time"
 
 	"github.com/mgutz/ansi"
 
 	"github.com/spf13/cobra"
 	"github.com/sunny0826/kubecm/pkg/cloud"
 	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 )
 
 // CloudAddCommand add command struct
@@ -29,307 +37,405 @@ func (ca *CloudAddCommand) Init() {
 		},
 		Example: cloudAddExample(),
 	}
+	ca.command.Flags().Bool("all", false, "add all clusters, or all clusters matching --cluster-name and --filter")
+	ca.command.Flags().Bool("all-clusters", false, "alias of --all")
+	_ = ca.command.Flags().MarkHidden("all-clusters")
+	ca.command.Flags().String("cluster-name", "", "add the clusters whose name matches this glob pattern, e.g. 'prod-*'")
+	ca.command.Flags().Bool("admin", false, "add admin rather than user credentials (Azure)")
+	ca.command.Flags().String("azure-login-mode", "", "convert Entra ID credentials to a kubelogin exec config with this login mode: "+strings.Join(cloud.AzureLoginModes, ", "))
+	ca.command.Flags().String("role-arn", "", "role the token command assumes (AWS)")
+	ca.command.Flags().String("token-command", "", "command generating tokens, aws or aws-iam-authenticator (AWS)")
+	ca.command.Flags().StringToString("exec-env", map[string]string{}, "extra environment of the token command, e.g. AWS_STS_REGIONAL_ENDPOINTS=regional (AWS)")
+	ca.command.Flags().Bool("private-endpoint", false, "use the private API server address, for access from within the VPC (AlibabaCloud, TencentCloud)")
+	ca.command.Flags().Duration("temporary-duration", 0, "fetch credentials expiring after this duration, e.g. 2h; Rancher creates a token scoped to each cluster (AlibabaCloud, Rancher)")
+	ca.command.Flags().String("certificate-authority", "", "CA bundle file verifying the cloud API server (Rancher)")
+	ca.command.Flags().Bool("insecure-skip-tls-verify", false, "do not verify the certificates of the cloud API server (Rancher) and of the added clusters")
+	ca.command.Flags().String("rancher-endpoint", "", "contexts to add from generated kubeconfigs: "+strings.Join(cloud.RancherEndpoints, ", ")+"; ace is the authorized cluster endpoint (Rancher)")
+	ca.command.Flags().String("context-name-template", "", "Go template for context names, fields: .Provider .Name .ID .Region .Account .Context, e.g. '{{.Provider}}-{{.Name}}'")
+}
+
+// cloudContextData is the data available to --context-name-template.
+type cloudContextData struct {
+	Provider string
+	Name     string
+	ID       string
+	Region   string
+	Account  string
+	Context  string
+}
+
+// cloudAddOptions holds how cloud add picks, names and merges clusters.
+type cloudAddOptions struct {
+	clusterID             string
+	clusterName           string
+	filters               map[string]string
+	all                   bool
+	nameTemplate          *template.Template
+	cover                 bool
+	selectContext         bool
+	contextTemplate       []string
+	context               []string
+	insecureSkipTLSVerify bool
 }
 
 func (ca *CloudAddCommand) runCloudAdd(cmd *cobra.Command, args []string) error {
 	provider, _ := ca.command.Flags().GetString("provider")
-	clusterID, _ := ca.command.Flags().GetString("cluster_id")
-	regionID, _ := ca.command.Flags().GetString("region_id")
-	cover, _ := ca.command.Flags().GetBool("cover")
-	context, _ := ca.command.Flags().GetStringSlice("context")
-	selectContext, _ := ca.command.Flags().GetBool("select-context")
-	contextTemplate, _ := ca.command.Flags().GetStringSlice("context-template")
-	insecureSkipTLSVerify, _ := ca.command.Flags().GetBool("insecure-skip-tls-verify")
-	var num int
-	if provider == "" {
-		num = selectCloud(Clouds, "Select Cloud")
-	} else {
-		num = checkFlags(provider)
+	nameTemplate, _ := ca.command.Flags().GetString("context-name-template")
+	opts := cloudAddOptions{}
+	opts.clusterID, _ = ca.command.Flags().GetString("cluster_id")
+	opts.cover, _ = ca.command.Flags().GetBool("cover")
+	opts.context, _ = ca.command.Flags().GetStringSlice("context")
+	opts.selectContext, _ = ca.command.Flags().GetBool("select-context")
+	opts.contextTemplate, _ = ca.command.Flags().GetStringSlice("context-template")
+	opts.insecureSkipTLSVerify, _ = ca.command.Flags().GetBool("insecure-skip-tls-verify")
+	opts.all = allClustersFlag(cmd.Flags())
+	opts.clusterName, _ = ca.command.Flags().GetString("cluster-name")
+
+	if opts.clusterName != "" {
+		if _, err := path.Match(opts.clusterName, ""); err != nil {
+			return fmt.Errorf("invalid --cluster-name pattern %q: %w", opts.clusterName, err)
+		}
 	}
-	switch num {
-	case -1:
-		var allAlias []string
-		for _, cloud := range Clouds {
-			allAlias = append(allAlias, cloud.Alias...)
+	filters, err := clusterFilters(cmd.Flags())
+	if err != nil {
+		return err
+	}
+	opts.filters = filters
+	if nameTemplate != "" {
+		opts.nameTemplate, err = template.New("context-name").Option("missingkey=error").Parse(nameTemplate)
+		if err != nil {
+			return fmt.Errorf("invalid --context-name-template: %w", err)
 		}
-		fmt.Printf("'%s' is not supported, supported cloud alias are %v \n", provider, allAlias)
-		return nil
-	case 0:
-		fmt.Println("⛅  Selected: AlibabaCloud")
-		accessKeyID, accessKeySecret := checkEnvForSecret(0)
-		ali := cloud.AliCloud{
-			AccessKeyID:     accessKeyID,
-			AccessKeySecret: accessKeySecret,
+	}
+
+	spec, err := cloudProvider(provider)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("⛅  Selected: %s\n", spec.Name)
+	creds := cloudCredentialOptions{}
+	creds.admin, _ = ca.command.Flags().GetBool("admin")
+	creds.loginMode, _ = ca.command.Flags().GetString("azure-login-mode")
+	creds.roleARN, _ = ca.command.Flags().GetString("role-arn")
+	creds.tokenCommand, _ = ca.command.Flags().GetString("token-command")
+	creds.execEnv, _ = ca.command.Flags().GetStringToString("exec-env")
+	creds.privateEndpoint, _ = ca.command.Flags().GetBool("private-endpoint")
+	creds.temporaryDuration, _ = ca.command.Flags().GetDuration("temporary-duration")
+	creds.caFile, _ = ca.command.Flags().GetString("certificate-authority")
+	creds.insecureSkipTLSVerify = opts.insecureSkipTLSVerify
+	creds.clusterEndpoint, _ = ca.command.Flags().GetString("rancher-endpoint")
+	if err := creds.check(spec); err != nil {
+		return err
+	}
+
+	ctx := cmd.Context()
+	cfg, err := resolveProviderConfig(ctx, spec, cloudScopeFlags(cmd.Flags()))
+	if err != nil {
+		return err
+	}
+	creds.apply(&cfg)
+	if spec.SupportsAdmin && !nonInteractive && !ca.command.Flags().Changed("admin") && creds.loginMode == "" {
+		cfg.Admin = selectOption(nil, []string{"User Config", "Admin Config"}, "Select Config Type") == 1
+	}
+	prov, err := spec.New(cfg)
+	if err != nil {
+		return err
+	}
+
+	if err := addCloudClusters(ctx, spec, cfg, prov, opts); err != nil {
+		return err
+	}
+	if spec.Note != "" {
+		fmt.Printf("%s: %s\n",
+			ansi.Color("Note", "blue"),
+			ansi.Color(" "+spec.Note, "white+h"))
+	}
+	return nil
+}
+
+// cloudCredentialOptions are the flags shaping the credentials of the added
+// kubeconfigs.
+type cloudCredentialOptions struct {
+	admin        bool
+	loginMode    string
+	roleARN      string
+	tokenCommand string
+	execEnv      map[string]string
+
+	privateEndpoint   bool
+	temporaryDuration time.Duration
+
+	caFile                string
+	insecureSkipTLSVerify bool
+	clusterEndpoint       string
+}
+
+// check checks the options against what the provider supports.
+func (o cloudCredentialOptions) check(spec cloud.ProviderSpec) error {
+	if o.admin && !spec.SupportsAdmin {
+		return fmt.Errorf("--admin is not supported by %s", spec.Name)
+	}
+	if o.loginMode != "" {
+		if len(spec.LoginModes) == 0 {
+			return fmt.Errorf("--azure-login-mode is not supported by %s", spec.Name)
 		}
-		if clusterID == "" {
-			clusters, err := ali.ListCluster()
-			if err != nil {
-				return err
-			}
-			if len(clusters) == 0 {
-				return errors.New("no clusters found")
-			}
-			clusterNum := selectCluster(clusters, "Select Cluster")
-			kubeconfig, err := ali.GetKubeConfig(clusters[clusterNum].ID)
-			if err != nil {
-				return err
-			}
-			newConfig, err := clientcmd.Load([]byte(kubeconfig))
-			if err != nil {
-				return err
-			}
-			err = AddToLocal(newConfig, clusters[clusterNum].Name, "", cover, selectContext, contextTemplate, context, insecureSkipTLSVerify)
-			if err != nil {
-				return err
-			}
-		} else {
-			kubeconfig, err := ali.GetKubeConfig(clusterID)
-			if err != nil {
-				return err
-			}
-			newConfig, err := clientcmd.Load([]byte(kubeconfig))
-			if err != nil {
-				return err
-			}
-			err = AddToLocal(newConfig, fmt.Sprintf("alicloud-%s", clusterID), "", cover, selectContext, contextTemplate, context, insecureSkipTLSVerify)
-			if err != nil {
-				return err
-			}
+		if !slices.Contains(spec.LoginModes, o.loginMode) {
+			return fmt.Errorf("invalid --azure-login-mode %q, expected one of: %s", o.loginMode, strings.Join(spec.LoginModes, ", "))
 		}
-	case 1:
-		fmt.Println("⛅  Selected: TencentCloud")
-		secretID, secretKey := checkEnvForSecret(1)
-		ten := cloud.TencentCloud{
-			SecretID:  secretID,
-			SecretKey: secretKey,
+		if o.admin {
+			return errors.New("--admin and --azure-login-mode cannot be used together, admin credentials do not use Entra ID")
 		}
-		if regionID == "" {
-			regionList, err := ten.GetRegionID()
-			if err != nil {
-				return err
-			}
-			regionNum := selectRegion(regionList, "Select Region Name")
-			ten.RegionID = regionList[regionNum]
-		} else {
-			ten.RegionID = regionID
+	}
+	if len(spec.TokenCommands) == 0 {
+		switch {
+		case o.roleARN != "":
+			return fmt.Errorf("--role-arn is not supported by %s", spec.Name)
+		case o.tokenCommand != "":
+			return fmt.Errorf("--token-command is not supported by %s", spec.Name)
+		case len(o.execEnv) > 0:
+			return fmt.Errorf("--exec-env is not supported by %s", spec.Name)
 		}
-
-		if clusterID == "" {
-			clusters, err := ten.ListCluster()
-			if err != nil {
-				return err
-			}
-			if len(clusters) == 0 {
-				return errors.New("no clusters found")
-			}
-			clusterNum := selectCluster(clusters, "Select Cluster")
-			kubeconfig, err := ten.GetKubeConfig(clusters[clusterNum].ID)
-			if err != nil {
-				return err
-			}
-			newConfig, err := clientcmd.Load([]byte(kubeconfig))
-			if err != nil {
-				return err
-			}
-			err = AddToLocal(newConfig, clusters[clusterNum].Name, "", cover, selectContext, contextTemplate, context, insecureSkipTLSVerify)
-			if err != nil {
-				return err
-			}
-		} else {
-			kubeconfig, err := ten.GetKubeConfig(clusterID)
-			if err != nil {
-				return err
-			}
-			newConfig, err := clientcmd.Load([]byte(kubeconfig))
-			if err != nil {
-				return err
-			}
-			err = AddToLocal(newConfig, fmt.Sprintf("tencent-%s", clusterID), "", cover, selectContext, contextTemplate, context, insecureSkipTLSVerify)
-			if err != nil {
-				return err
-			}
+	}
+	if o.tokenCommand != "" && !slices.Contains(spec.TokenCommands, o.tokenCommand) {
+		return fmt.Errorf("invalid --token-command %q, expected one of: %s", o.tokenCommand, strings.Join(spec.TokenCommands, ", "))
+	}
+	if o.privateEndpoint && !spec.SupportsPrivateEndpoint {
+		return fmt.Errorf("--private-endpoint is not supported by %s", spec.Name)
+	}
+	if o.temporaryDuration != 0 {
+		if !spec.SupportsTemporaryCredentials {
+			return fmt.Errorf("--temporary-duration is not supported by %s", spec.Name)
 		}
-	case 2:
-		fmt.Println("⛅  Selected: Rancher")
-		serverURL, apiKey := checkEnvForSecret(2)
-		rancher := cloud.Rancher{
-			ServerURL: serverURL,
-			APIKey:    apiKey,
+		if spec.MaxTemporaryDuration > 0 && (o.temporaryDuration < spec.MinTemporaryDuration || o.temporaryDuration > spec.MaxTemporaryDuration) {
+			return fmt.Errorf("--temporary-duration must be between %s and %s for %s, got %s", spec.MinTemporaryDuration, spec.MaxTemporaryDuration, spec.Name, o.temporaryDuration)
 		}
-		if clusterID == "" {
-			clusters, err := rancher.ListCluster()
-			if err != nil {
-				return err
-			}
-			if len(clusters) == 0 {
-				return errors.New("no clusters found")
-			}
-			clusterNum := selectCluster(clusters, "Select Cluster")
-			kubeconfig, err := rancher.GetKubeConfig(clusters[clusterNum].ID)
-			if err != nil {
-				return err
-			}
-			newConfig, err := clientcmd.Load([]byte(kubeconfig))
-			if err != nil {
-				return err
-			}
-			err = AddToLocal(newConfig, clusters[clusterNum].Name, "", cover, selectContext, contextTemplate, context, insecureSkipTLSVerify)
-			if err != nil {
-				return err
-			}
-		} else {
-			kubeconfig, err := rancher.GetKubeConfig(clusterID)
-			if err != nil {
-				return err
-			}
-			newConfig, err := clientcmd.Load([]byte(kubeconfig))
-			if err != nil {
-				return err
-			}
-			err = AddToLocal(newConfig, fmt.Sprintf("rancher-%s", clusterID), "", cover, selectContext, contextTemplate, context, insecureSkipTLSVerify)
-			if err != nil {
-				return err
-			}
+		if o.temporaryDuration < time.Minute {
+			return fmt.Errorf("--temporary-duration must be at least 1m, got %s", o.temporaryDuration)
 		}
-	case 3:
-		fmt.Println("⛅  Selected: AWS")
-		awsProfile, _ := ca.command.Flags().GetString("aws_profile")
-		awsProvider, err := buildAWSProvider(awsProfile, regionID)
-		if err != nil {
-			return err
+	}
+	if o.caFile != "" {
+		if !spec.SupportsTLSOptions {
+			return fmt.Errorf("--certificate-authority is not supported by %s", spec.Name)
 		}
-		if clusterID == "" {
-			clusters, err := awsProvider.ListCluster()
-			if err != nil {
-				return err
-			}
-			if len(clusters) == 0 {
-				return errors.New("no clusters found")
-			}
-			clusterNum := selectCluster(clusters, "Select Cluster")
-			clusterID = clusters[clusterNum].ID
+		if o.insecureSkipTLSVerify {
+			return errors.New("--certificate-authority and --insecure-skip-tls-verify cannot be used together")
+		}
+	}
+	if o.clusterEndpoint != "" {
+		if len(spec.ClusterEndpoints) == 0 {
+			return fmt.Errorf("--rancher-endpoint is not supported by %s", spec.Name)
+		}
+		if !slices.Contains(spec.ClusterEndpoints, o.clusterEndpoint) {
+			return fmt.Errorf("invalid --rancher-endpoint %q, expected one of: %s", o.clusterEndpoint, strings.Join(spec.ClusterEndpoints, ", "))
 		}
-		newConfig, err := awsProvider.GetKubeConfigObj(clusterID)
+	}
+	return nil
+}
+
+func (o cloudCredentialOptions) apply(cfg *cloud.ProviderConfig) {
+	cfg.Admin = o.admin
+	cfg.LoginMode = o.loginMode
+	cfg.RoleARN = o.roleARN
+	cfg.TokenCommand = o.tokenCommand
+	if len(o.execEnv) > 0 {
+		cfg.ExecEnv = o.execEnv
+	}
+	cfg.PrivateEndpoint = o.privateEndpoint
+	cfg.TemporaryDuration = o.temporaryDuration
+	cfg.CAFile = o.caFile
+	cfg.InsecureSkipTLSVerify = o.insecureSkipTLSVerify
+	cfg.ClusterEndpoint = o.clusterEndpoint
+}
+
+// addCloudClusters fetches the selected clusters of a provider and adds
+// their kubeconfigs to the local one.
+func addCloudClusters(ctx context.Context, spec cloud.ProviderSpec, cfg cloud.ProviderConfig, prov cloud.Provider, opts cloudAddOptions) error {
+	var clusters []cloud.ClusterInfo
+	if opts.clusterID != "" {
+		clusters = []cloud.ClusterInfo{{
+			ID:       opts.clusterID,
+			Name:     fmt.Sprintf("%s-%s", spec.Key, opts.clusterID),
+			RegionID: cfg.Region,
+		}}
+	} else {
+		all, err := listCloudClusters(ctx, prov)
 		if err != nil {
 			return err
 		}
-		err = AddToLocal(newConfig, fmt.Sprintf("aws-%s", clusterID), "", cover, selectContext, contextTemplate, context, insecureSkipTLSVerify)
+		clusters, err = chooseClusters(all, opts.clusterName, opts.filters, opts.all)
 		if err != nil {
 			return err
 		}
-		fmt.Printf("%s: %s\n",
-			ansi.Color("Note", "blue"),
-			ansi.Color(" please install the AWS CLI before normal use.", "white+h"))
-	case 4:
-		fmt.Println("⛅  Selected: Azure")
-		authModes := []string{"Default (SDK Auth)", "Service Principal"}
-		authMode := selectOption(nil, authModes, "Select Auth Type")
-		azure := cloud.Azure{
-			AuthMode:       cloud.AzureAuth(authMode),
-			TenantID:       os.Getenv("AZURE_TENANT_ID"),
-			SubscriptionID: os.Getenv("AZURE_SUBSCRIPTION_ID"),
-		}
+	}
 
-		if azure.TenantID == "" {
-			azure.TenantID = PromptUI("Azure Tenant ID", "")
-		}
+	// --all never prompts, even when it finds a single cluster
+	if len(clusters) > 1 || opts.all {
+		return bulkAddCloudClusters(ctx, spec, prov, clusters, opts)
+	}
+	newConfig, err := fetchCloudKubeconfig(ctx, spec, prov, clusters[0], opts)
+	if err != nil {
+		return err
+	}
+	return AddToLocal(newConfig, clusters[0].Name, "", opts.cover || nonInteractive, opts.selectContext, opts.contextTemplate, opts.context, opts.insecureSkipTLSVerify)
+}
 
-		if azure.AuthMode == cloud.AuthModeServicePrincipal {
-			azure.ClientID, azure.ClientSecret = checkEnvForSecret(4)
-			azure.ObjectID = os.Getenv("AZURE_OBJECT_ID")
+// fetchCloudKubeconfig fetches the kubeconfig of a cluster and renames its
+// contexts with --context-name-template.
+func fetchCloudKubeconfig(ctx context.Context, spec cloud.ProviderSpec, prov cloud.Provider, cluster cloud.ClusterInfo, opts cloudAddOptions) (*clientcmdapi.Config, error) {
+	newConfig, err := prov.GetKubeconfig(ctx, cluster)
+	if err != nil {
+		return nil, err
+	}
+	if opts.nameTemplate == nil {
+		return newConfig, nil
+	}
+	return renameCloudContexts(newConfig, opts.nameTemplate, cloudContextData{
+		Provider: spec.Key,
+		Name:     cluster.Name,
+		ID:       cluster.ID,
+		Region:   cluster.RegionID,
+		Account:  cluster.Account,
+	})
+}
 
-			if azure.ObjectID == "" {
-				azure.ObjectID = PromptUI("Azure Object ID", "")
-			}
-		}
+// cloudFetchConcurrency bounds the kubeconfigs fetched at the same time.
+const cloudFetchConcurrency = 8
+
+// bulkAddCloudClusters fetches the kubeconfigs of several clusters
+// concurrently and merges them into the local kubeconfig in one write.
+// Contexts whose name is already taken are skipped rather than prompted for.
+func bulkAddCloudClusters(ctx context.Context, spec cloud.ProviderSpec, prov cloud.Provider, clusters []cloud.ClusterInfo, opts cloudAddOptions) error {
+	configs := make([]*clientcmdapi.Config, len(clusters))
+	errs := make([]error, len(clusters))
+	sem := make(chan struct{}, cloudFetchConcurrency)
+	var wg sync.WaitGroup
+	for i, cluster := range clusters {
+		wg.Add(1)
+		go func(i int, cluster cloud.ClusterInfo) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			configs[i], errs[i] = fetchCloudKubeconfig(ctx, spec, prov, cluster, opts)
+		}(i, cluster)
+	}
+	wg.Wait()
 
-		if clusterID != "" {
-			clusterIDParts := strings.Split(clusterID, "/")
-			if len(clusterIDParts) != 9 {
-				return fmt.Errorf("invalid id %s", clusterID)
-			}
-			azure.SubscriptionID = clusterIDParts[2]
-			resourceGroup := clusterIDParts[4]
-			clusterName := clusterIDParts[8]
-
-			var (
-				kubeConfig []byte
-				err        error
-			)
-			kubeConfigType := selectOption(nil, []string{"User Config", "Admin Config"}, "Select Config Type")
-			switch kubeConfigType {
-			case 0:
-				kubeConfig, err = azure.GetKubeConfig(clusterName, resourceGroup)
-			case 1:
-				kubeConfig, err = azure.GetAdminKubeConfig(clusterName, resourceGroup)
-			default:
-				return fmt.Errorf("invalid config type %d", kubeConfigType)
-			}
+	kubeconfig, err := SelectKubeconfigFile("Select The kubeconfig file to add to")
+	if err != nil {
+		return err
+	}
+	oldConfig, err := clientcmd.LoadFromFile(kubeconfig)
+	if err != nil {
+		return err
+	}
 
+	outConfig := oldConfig
+	var rows [][]string
+	var added, failed int
+	for i, cluster := range clusters {
+		if errs[i] != nil {
+			failed++
+			rows = append(rows, []string{cluster.Name, "failed", errs[i].Error()})
+			continue
+		}
+		kco := &KubeConfigOption{
+			config:                configs[i],
+			fileName:              getFileName(cluster.Name),
+			insecureSkipTLSVerify: opts.insecureSkipTLSVerify,
+			skipExisting:          true,
+		}
+		outConfig, err = kco.handleContexts(outConfig, "", false, opts.contextTemplate, opts.context)
+		if err != nil {
+			return err
+		}
+		switch {
+		case len(kco.added) > 0:
+			added++
+			rows = append(rows, []string{cluster.Name, "added", strings.Join(kco.added, ", ")})
+		case len(kco.skipped) > 0:
+			rows = append(rows, []string{cluster.Name, "skipped", "context exists: " + strings.Join(kco.skipped, ", ")})
+		default:
+			rows = append(rows, []string{cluster.Name, "skipped", "no context to add"})
+		}
+	}
+	printRegistryTable([]string{"CLUSTER", "RESULT", "DETAIL"}, rows)
+	fmt.Printf("%d added, %d skipped, %d failed\n", added, len(clusters)-added-failed, failed)
+
+	if added > 0 {
+		cover := opts.cover || nonInteractive
+		if !cover {
+			cover, err = strconv.ParseBool(BoolUI(fmt.Sprintf("Does it overwrite File 「%s」?", kubeconfig)))
 			if err != nil {
 				return err
 			}
-			newConfig, err := clientcmd.Load(kubeConfig)
-			if err != nil {
-				return err
-			}
-			return AddToLocal(newConfig, fmt.Sprintf("azure-%s", clusterID), "", cover, selectContext, contextTemplate, context, insecureSkipTLSVerify)
 		}
-
-		subscriptionList, err := azure.ListSubscriptions()
-		if err != nil {
+		if err := WriteConfig(cover, kubeconfig, outConfig); err != nil {
 			return err
 		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d clusters failed", failed, len(clusters))
+	}
+	return nil
+}
 
-		var clusters []cloud.ClusterInfo
-
-		for _, subscription := range subscriptionList {
-			if azure.SubscriptionID != "" && azure.SubscriptionID != subscription.ID {
+// chooseClusters returns the clusters to add: those matching the namePattern
+// glob and the filters, all of them with all, or the one picked from a prompt.
+func chooseClusters(clusters []cloud.ClusterInfo, namePattern string, filters map[string]string, all bool) ([]cloud.ClusterInfo, error) {
+	if len(clusters) == 0 {
+		return nil, errors.New("no clusters found")
+	}
+	if namePattern != "" || len(filters) > 0 {
+		var matched []cloud.ClusterInfo
+		for _, cluster := range clusters {
+			if ok, _ := path.Match(namePattern, cluster.Name); namePattern != "" && !ok {
 				continue
 			}
-
-			subscriptionClusters, err := azure.ListCluster(subscription)
-			if err != nil {
-				return err
+			if cluster.Matches(filters) {
+				matched = append(matched, cluster)
 			}
-			clusters = append(clusters, subscriptionClusters...)
 		}
-
-		if len(clusters) == 0 {
-			return errors.New("no clusters found")
+		if len(matched) == 0 {
+			return nil, errors.New("no clusters match the given --cluster-name and --filter")
 		}
-
-		clusterNum := selectCluster(clusters, "Select Cluster")
-		cluster := clusters[clusterNum]
-
-		if azure.SubscriptionID == "" {
-			azure.SubscriptionID = strings.Split(cluster.ID, "/")[2]
+		return matched, nil
+	}
+	if all {
+		return clusters, nil
+	}
+	if nonInteractive {
+		if len(clusters) == 1 {
+			return clusters, nil
 		}
+		return nil, requiredInputError("--cluster_id, --cluster-name, --filter or --all")
+	}
+	clusterNum := selectCluster(clusters, "Select Cluster")
+	return clusters[clusterNum : clusterNum+1], nil
+}
 
-		resourceGroup := strings.Split(cluster.ID, "/")[4]
-
-		var kubeConfig []byte
-		kubeConfigType := selectOption(nil, []string{"User Config", "Admin Config"}, "Select Config Type")
-		switch kubeConfigType {
-		case 0:
-			kubeConfig, err = azure.GetKubeConfig(cluster.Name, resourceGroup)
-		case 1:
-			kubeConfig, err = azure.GetAdminKubeConfig(cluster.Name, resourceGroup)
-		default:
-			return fmt.Errorf("invalid config type %d", kubeConfigType)
+// renameCloudContexts renames the contexts of a cloud kubeconfig with tmpl.
+func renameCloudContexts(config *clientcmdapi.Config, tmpl *template.Template, data cloudContextData) (*clientcmdapi.Config, error) {
+	out := config.DeepCopy()
+	out.Contexts = make(map[string]*clientcmdapi.Context)
+	for name, ctx := range config.Contexts {
+		data.Context = name
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return nil, fmt.Errorf("rendering --context-name-template: %w", err)
 		}
-
-		if err != nil {
-			return err
+		newName := strings.TrimSpace(buf.String())
+		if newName == "" {
+			return nil, fmt.Errorf("--context-name-template renders an empty name for context %q", name)
 		}
-		newConfig, err := clientcmd.Load(kubeConfig)
-		if err != nil {
-			return err
+		if _, ok := out.Contexts[newName]; ok {
+			return nil, fmt.Errorf("--context-name-template renders %q for several contexts of %s, add {{.Context}} to it", newName, data.Name)
+		}
+		out.Contexts[newName] = ctx.DeepCopy()
+		if config.CurrentContext == name {
+			out.CurrentContext = newName
 		}
-		return AddToLocal(newConfig, fmt.Sprintf("azure-%s", clusterID), "", cover, selectContext, contextTemplate, context, insecureSkipTLSVerify)
-
 	}
-	return nil
+	return out, nil
 }
 
 func cloudAddExample() string {
@@ -347,16 +453,30 @@ export ACCESS_KEY_ID=YOUR_AKID
 export ACCESS_KEY_SECRET=YOUR_SECRET_KEY
 kubecm cloud add --provider alibabacloud --cluster_id=xxxxxx
 
+# AlibabaCloud: clusters of one region, with private endpoint kubeconfigs expiring after 8 hours
+kubecm cloud add --provider alibabacloud --region_id cn-hangzhou --private-endpoint --temporary-duration 8h
+
+# AlibabaCloud with an STS token
+export ALIBABA_CLOUD_SECURITY_TOKEN=YOUR_STS_TOKEN
+kubecm cloud add --provider alibabacloud --non-interactive --all
+
 # Tencent Cloud
 export TENCENTCLOUD_SECRET_ID=YOUR_SECRET_ID
 export TENCENTCLOUD_SECRET_KEY=YOUR_SECRET_KEY
 kubecm cloud add --provider tencent --region_id=ap-guangzhou
 
+# Tencent Cloud with the credentials of a tccli profile, using the intranet endpoint
+export TCCLI_PROFILE=dev
+kubecm cloud add --provider tencent --region_id=ap-guangzhou --private-endpoint
+
 # Rancher
 export RANCHER_SERVER_URL=https://xxx
 export RANCHER_API_KEY=YOUR_API_KEY
 kubecm cloud add --provider rancher
 
+# Rancher behind a private CA: authorized cluster endpoint contexts with tokens expiring after 12 hours
+kubecm cloud add --provider rancher --certificate-authority ca.pem --rancher-endpoint ace --temporary-duration 12h
+
 # AWS with named profile (recommended)
 kubecm cloud add --provider aws --aws_profile my-profile --region_id us-east-1
 
@@ -364,6 +484,13 @@ kubecm cloud add --provider aws --aws_profile my-profile --region_id us-east-1
 export AWS_PROFILE=my-profile
 kubecm cloud add --provider aws
 
+# AWS assuming a role, with tokens from aws-iam-authenticator
+kubecm cloud add --provider aws --region_id us-east-1 \
+  --role-arn arn:aws:iam::123456789012:role/eks-admin --token-command aws-iam-authenticator
+
+# AWS with extra environment for the token command
+kubecm cloud add --provider aws --region_id us-east-1 --exec-env AWS_STS_REGIONAL_ENDPOINTS=regional
+
 # AWS with static credentials (backward compatible)
 export AWS_ACCESS_KEY_ID=YOUR_AKID
 export AWS_SECRET_ACCESS_KEY=YOUR_SECRET_KEY
@@ -372,10 +499,26 @@ kubecm cloud add --provider aws --region_id us-east-1
 # Azure with default SDK auth
 kubecm cloud add --provider azure
 
+# Azure admin credentials
+kubecm cloud add --provider azure --admin
+
+# Azure with Entra ID, authenticating through kubelogin with the Azure CLI session
+kubecm cloud add --provider azure --azure-login-mode azurecli
+
 # Azure with service principal
 export AZURE_CLIENT_ID=YOUR_CLIENT_ID
 export AZURE_CLIENT_SECRET=YOUR_CLIENT_SECRET
 export AZURE_TENANT_ID=YOUR_TENANT_ID
 kubecm cloud add --provider azure
+
+# Non-interactive (e.g. in CI): add every cluster whose name starts with prod-
+kubecm cloud add --provider aws --region_id us-east-1 --non-interactive \
+  --cluster-name 'prod-*' --context-name-template '{{.Provider}}-{{.Region}}-{{.Name}}'
+
+# Non-interactive: add all clusters of the account
+kubecm cloud add --provider alibabacloud --non-interactive --all
+
+# Add every cluster tagged env=prod, fetching kubeconfigs concurrently
+kubecm cloud add --provider aws --all-regions --all --filter env=prod
 `
 }
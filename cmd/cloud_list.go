@@ -1,8 +1,14 @@
 package cmd
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/bndr/gotabulate"
 	"github.com/spf13/cobra"
@@ -25,39 +31,77 @@ func (cl *CloudListCommand) Init() {
 		},
 		Example: cloudListExample(),
 	}
+	cl.command.Flags().StringP("output", "o", "", "output format, one of: wide, json")
 }
 
 func (cl *CloudListCommand) runCloudList(cmd *cobra.Command, args []string) error {
 	provider, _ := cl.command.Flags().GetString("provider")
-	regionID, _ := cl.command.Flags().GetString("region_id")
-	awsProfile, _ := cl.command.Flags().GetString("aws_profile")
-	var num int
-	if provider == "" {
-		num = selectCloud(Clouds, "Select Cloud")
-	} else {
-		num = checkFlags(provider)
+	output, _ := cl.command.Flags().GetString("output")
+	if output != "" && output != "wide" && output != "json" {
+		return fmt.Errorf("unsupported output format %q, use wide or json", output)
+	}
+	filters, err := clusterFilters(cmd.Flags())
+	if err != nil {
+		return err
+	}
+	spec, err := cloudProvider(provider)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("⛅  Selected: %s\n", spec.Name)
+
+	ctx := cmd.Context()
+	cfg, err := resolveProviderConfig(ctx, spec, cloudScopeFlags(cmd.Flags()))
+	if err != nil {
+		return err
 	}
-	clusters, err := getClusters(provider, regionID, awsProfile, num)
+	prov, err := spec.New(cfg)
 	if err != nil {
 		return err
 	}
-	if len(clusters) == 0 {
+	clusters, err := listCloudClusters(ctx, prov)
+	if err != nil {
+		return err
+	}
+	var matched []cloud.ClusterInfo
+	for _, cluster := range clusters {
+		if cluster.Matches(filters) {
+			matched = append(matched, cluster)
+		}
+	}
+	if len(matched) == 0 {
 		return errors.New("no clusters found")
 	}
-	return printListTable(clusters)
+	if output == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(matched)
+	}
+	return printListTable(matched, output == "wide")
 }
 
 // PrintTable generate table
-func printListTable(clusters []cloud.ClusterInfo) error {
+func printListTable(clusters []cloud.ClusterInfo, wide bool) error {
+	headers := []string{"ID", "ACCOUNT", "NAME", "REGION ID", "VERSION", "CONSOLE URL"}
+	if wide {
+		headers = append(headers, "STATUS", "NODES", "ENDPOINT", "CREATED", "TAGS")
+	}
 	var table [][]string
 	for _, k := range clusters {
 		conTmp := []string{k.ID, k.Account, k.Name, k.RegionID, k.K8sVersion, k.ConsoleURL}
+		if wide {
+			var created string
+			if !k.CreatedAt.IsZero() {
+				created = k.CreatedAt.Format(time.DateOnly)
+			}
+			conTmp = append(conTmp, k.Status, strconv.Itoa(k.NodeCount), k.Endpoint, created, formatTags(k.Tags))
+		}
 		table = append(table, conTmp)
 	}
 
 	if table != nil {
 		tabulate := gotabulate.Create(table)
-		tabulate.SetHeaders([]string{"ID", "ACCOUNT", "NAME", "REGION ID", "VERSION", "CONSOLE URL"})
+		tabulate.SetHeaders(headers)
 		// Turn On String Wrapping
 		tabulate.SetWrapStrings(false)
 		// Render the table
@@ -69,6 +113,16 @@ func printListTable(clusters []cloud.ClusterInfo) error {
 	return nil
 }
 
+// formatTags renders tags as sorted key=value pairs.
+func formatTags(tags map[string]string) string {
+	pairs := make([]string, 0, len(tags))
+	for k, v := range tags {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
 func cloudListExample() string {
 	return `
 # Supports AlibabaCloud, Tencent Cloud, Rancher, AWS and Azure
@@ -85,7 +139,16 @@ kubecm cloud list --provider aws --aws_profile my-profile --region_id eu-west-3
 # AWS with default credential chain
 kubecm cloud list --provider aws --region_id us-east-1
 
+# AWS across all regions and several profiles
+kubecm cloud list --provider aws --all-regions --profiles dev,prod
+
 # Azure
 kubecm cloud list --provider azure
+
+# Status, node count, endpoint visibility, creation time and tags
+kubecm cloud list --provider aws -o wide
+
+# Only clusters with a public endpoint tagged env=prod, as JSON
+kubecm cloud list --provider aws --filter endpoint=public,env=prod -o json
 `
 }
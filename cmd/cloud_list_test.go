@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"testing"
+	"time"
 
 	"github.com/sunny0826/kubecm/pkg/cloud"
 )
@@ -9,6 +10,7 @@ import (
 func Test_printListTable(t *testing.T) {
 	type args struct {
 		clusters []cloud.ClusterInfo
+		wide     bool
 	}
 	tests := []struct {
 		name    string
@@ -26,13 +28,35 @@ func Test_printListTable(t *testing.T) {
 					ConsoleURL: "https://www.test-cluster.com",
 				},
 			}}},
+		{name: "wide", args: args{
+			clusters: []cloud.ClusterInfo{
+				{
+					ID:        "id",
+					Name:      "test-cluster",
+					Status:    "ACTIVE",
+					NodeCount: 3,
+					Endpoint:  cloud.EndpointPrivate,
+					CreatedAt: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+					Tags:      map[string]string{"env": "prod"},
+				},
+			},
+			wide: true}},
 		{name: "not exist", args: args{}, wantErr: true},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if err := printListTable(tt.args.clusters); (err != nil) != tt.wantErr {
+			if err := printListTable(tt.args.clusters, tt.args.wide); (err != nil) != tt.wantErr {
 				t.Errorf("printListTable() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
 	}
 }
+
+func Test_formatTags(t *testing.T) {
+	if got := formatTags(map[string]string{"team": "a", "env": "prod"}); got != "env=prod,team=a" {
+		t.Errorf("formatTags() = %q", got)
+	}
+	if got := formatTags(nil); got != "" {
+		t.Errorf("formatTags(nil) = %q", got)
+	}
+}
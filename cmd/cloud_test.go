@@ -1,107 +1,419 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"os"
+	"path/filepath"
+	"sync"
 	"testing"
+	"text/template"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sunny0826/kubecm/pkg/cloud"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 )
 
-func Test_checkFlags(t *testing.T) {
-	type args struct {
-		provider string
-	}
+func Test_cloudProvider(t *testing.T) {
 	tests := []struct {
-		name string
-		args args
-		want int
+		name     string
+		provider string
+		want     string
+		wantErr  bool
 	}{
-		// TODO: Add test cases.
 		{
-			name: "exist",
-			args: args{
-				provider: "alibabacloud",
-			},
-			want: 0,
+			name:     "exist",
+			provider: "alibabacloud",
+			want:     "AlibabaCloud",
+		},
+		{
+			name:     "alias",
+			provider: "eks",
+			want:     "AWS",
 		},
 		{
-			name: "notExist",
-			args: args{
-				provider: "alibabaclou",
-			},
-			want: -1,
+			name:     "notExist",
+			provider: "alibabaclou",
+			wantErr:  true,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := checkFlags(tt.args.provider); got != tt.want {
-				t.Errorf("checkFlags() = %v, want %v", got, tt.want)
+			got, err := cloudProvider(tt.provider)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("cloudProvider() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got.Name != tt.want {
+				t.Errorf("cloudProvider() = %v, want %v", got.Name, tt.want)
 			}
 		})
 	}
 }
 
-func Test_checkEnvForSecret(t *testing.T) {
-	type args struct {
-		num int
-	}
+func Test_resolveProviderConfig(t *testing.T) {
 	tests := []struct {
-		name  string
-		args  args
-		want  string
-		want1 string
+		name     string
+		provider string
+		env      map[string]string
+		values   map[string]string
+		wantMode int
+		want     map[string]string
 	}{
 		{
-			name: "err_input",
-			args: args{
-				num: -1,
-			},
-			want:  "",
-			want1: "",
+			name:     "ali_env",
+			provider: "alicloud",
+			env:      map[string]string{"ACCESS_KEY_ID": "aliyun_env_id", "ACCESS_KEY_SECRET": "aliyun_env_sec", "ALIBABA_CLOUD_SECURITY_TOKEN": "", "ALIBABA_CLOUD_ROLE_ARN": ""},
+			want:     map[string]string{"ACCESS_KEY_ID": "aliyun_env_id", "ACCESS_KEY_SECRET": "aliyun_env_sec"},
+		},
+		{
+			name:     "ali_sts_token",
+			provider: "alicloud",
+			env:      map[string]string{"ACCESS_KEY_ID": "sts_id", "ACCESS_KEY_SECRET": "sts_sec", "ALIBABA_CLOUD_SECURITY_TOKEN": "token", "ALIBABA_CLOUD_ROLE_ARN": ""},
+			wantMode: 1,
+			want:     map[string]string{"ACCESS_KEY_ID": "sts_id", "ACCESS_KEY_SECRET": "sts_sec", "ALIBABA_CLOUD_SECURITY_TOKEN": "token"},
 		},
 		{
-			name: "ali_env",
-			args: args{
-				num: 0,
-			},
-			want:  "aliyun_env_id",
-			want1: "aliyun_env_sec",
+			name:     "ali_ram_role",
+			provider: "alicloud",
+			env:      map[string]string{"ACCESS_KEY_ID": "id", "ACCESS_KEY_SECRET": "sec", "ALIBABA_CLOUD_SECURITY_TOKEN": "", "ALIBABA_CLOUD_ROLE_ARN": "acs:ram::1:role/ack", "ALIBABA_CLOUD_ROLE_SESSION_NAME": ""},
+			wantMode: 2,
+			want:     map[string]string{"ACCESS_KEY_ID": "id", "ACCESS_KEY_SECRET": "sec", "ALIBABA_CLOUD_ROLE_ARN": "acs:ram::1:role/ack", "ALIBABA_CLOUD_ROLE_SESSION_NAME": ""},
 		},
 		{
-			name: "ten_env",
-			args: args{
-				num: 1,
-			},
-			want:  "ten_env_id",
-			want1: "ten_env_sec",
+			name:     "ten_env",
+			provider: "tencent",
+			env:      map[string]string{"TENCENTCLOUD_SECRET_ID": "ten_env_id", "TENCENTCLOUD_SECRET_KEY": "ten_env_sec"},
+			want:     map[string]string{"TENCENTCLOUD_SECRET_ID": "ten_env_id", "TENCENTCLOUD_SECRET_KEY": "ten_env_sec"},
 		},
 		{
-			name: "aws_env",
-			args: args{
-				num: 3,
-			},
-			want:  "aws_env_id",
-			want1: "aws_env_sec",
+			name:     "ten_tccli",
+			provider: "tencent",
+			env:      map[string]string{"TENCENTCLOUD_SECRET_ID": "", "TENCENTCLOUD_SECRET_KEY": "", "TCCLI_PROFILE": "dev"},
+			wantMode: 1,
+			want:     map[string]string{"TCCLI_PROFILE": "dev"},
+		},
+		{
+			name:     "aws_env",
+			provider: "aws",
+			env:      map[string]string{"AWS_ACCESS_KEY_ID": "aws_env_id", "AWS_SECRET_ACCESS_KEY": "aws_env_sec", "AWS_PROFILE": ""},
+			wantMode: 1,
+			want:     map[string]string{"AWS_ACCESS_KEY_ID": "aws_env_id", "AWS_SECRET_ACCESS_KEY": "aws_env_sec"},
+		},
+		{
+			name:     "aws_profile_flag",
+			provider: "aws",
+			env:      map[string]string{"AWS_ACCESS_KEY_ID": "aws_env_id", "AWS_SECRET_ACCESS_KEY": "aws_env_sec"},
+			values:   map[string]string{"AWS_PROFILE": "dev"},
+			want:     map[string]string{"AWS_PROFILE": "dev"},
+		},
+		{
+			name:     "azure_default",
+			provider: "azure",
+			env:      map[string]string{"AZURE_TENANT_ID": "", "AZURE_CLIENT_ID": "", "AZURE_CLIENT_SECRET": ""},
+			want:     map[string]string{"AZURE_TENANT_ID": ""},
 		},
 	}
+	nonInteractive = true
+	defer func() { nonInteractive = false }()
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			switch tt.name {
-			case "ali_env":
-				os.Setenv("ACCESS_KEY_ID", "aliyun_env_id")
-				os.Setenv("ACCESS_KEY_SECRET", "aliyun_env_sec")
-			case "ten_env":
-				os.Setenv("TENCENTCLOUD_SECRET_ID", "ten_env_id")
-				os.Setenv("TENCENTCLOUD_SECRET_KEY", "ten_env_sec")
-			case "aws_env":
-				os.Setenv("AWS_ACCESS_KEY_ID", "aws_env_id")
-				os.Setenv("AWS_SECRET_ACCESS_KEY", "aws_env_sec")
-			}
-			got, got1 := checkEnvForSecret(tt.args.num)
-			if got != tt.want {
-				t.Errorf("checkEnvForSecret() got = %v, want %v", got, tt.want)
-			}
-			if got1 != tt.want1 {
-				t.Errorf("checkEnvForSecret() got1 = %v, want %v", got1, tt.want1)
+			for k, v := range tt.env {
+				t.Setenv(k, v)
 			}
+			spec, ok := cloud.LookupProvider(tt.provider)
+			assert.True(t, ok)
+			cfg, err := resolveProviderConfig(context.Background(), spec, cloudScope{region: "us-east-1", awsProfile: tt.values["AWS_PROFILE"]})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantMode, cfg.AuthMode)
+			assert.Equal(t, tt.want, cfg.Credentials)
 		})
 	}
 }
+
+func Test_chooseClusters(t *testing.T) {
+	clusters := []cloud.ClusterInfo{
+		{ID: "1", Name: "prod-eu", Tags: map[string]string{"env": "prod", "team": "a"}},
+		{ID: "2", Name: "prod-us", Tags: map[string]string{"env": "prod", "team": "b"}},
+		{ID: "3", Name: "staging", Tags: map[string]string{"env": "staging", "team": "a"}},
+	}
+	nonInteractive = true
+	defer func() { nonInteractive = false }()
+
+	got, err := chooseClusters(clusters, "prod-*", nil, false)
+	assert.NoError(t, err)
+	assert.Equal(t, clusters[:2], got)
+
+	got, err = chooseClusters(clusters, "", nil, true)
+	assert.NoError(t, err)
+	assert.Equal(t, clusters, got)
+
+	got, err = chooseClusters(clusters, "", map[string]string{"team": "a"}, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []cloud.ClusterInfo{clusters[0], clusters[2]}, got)
+
+	got, err = chooseClusters(clusters, "prod-*", map[string]string{"team": "a"}, true)
+	assert.NoError(t, err)
+	assert.Equal(t, clusters[:1], got)
+
+	_, err = chooseClusters(clusters, "dev-*", nil, true)
+	assert.EqualError(t, err, "no clusters match the given --cluster-name and --filter")
+
+	_, err = chooseClusters(clusters, "", map[string]string{"env": "dev"}, true)
+	assert.EqualError(t, err, "no clusters match the given --cluster-name and --filter")
+
+	_, err = chooseClusters(clusters, "", nil, false)
+	assert.ErrorContains(t, err, "non-interactive")
+
+	got, err = chooseClusters(clusters[2:], "", nil, false)
+	assert.NoError(t, err)
+	assert.Equal(t, clusters[2:], got)
+
+	_, err = chooseClusters(nil, "", nil, true)
+	assert.EqualError(t, err, "no clusters found")
+}
+
+func Test_cloudCredentialOptions(t *testing.T) {
+	azure, _ := cloud.LookupProvider("azure")
+	aws, _ := cloud.LookupProvider("aws")
+
+	assert.NoError(t, cloudCredentialOptions{admin: true}.check(azure))
+	assert.NoError(t, cloudCredentialOptions{loginMode: "azurecli"}.check(azure))
+	assert.EqualError(t, cloudCredentialOptions{loginMode: "browser"}.check(azure), `invalid --azure-login-mode "browser", expected one of: devicecode, azurecli, workloadidentity, spn`)
+	assert.ErrorContains(t, cloudCredentialOptions{admin: true, loginMode: "spn"}.check(azure), "cannot be used together")
+	assert.EqualError(t, cloudCredentialOptions{admin: true}.check(aws), "--admin is not supported by AWS")
+	assert.EqualError(t, cloudCredentialOptions{loginMode: "spn"}.check(aws), "--azure-login-mode is not supported by AWS")
+
+	assert.NoError(t, cloudCredentialOptions{roleARN: "arn:aws:iam::1:role/r", tokenCommand: "aws-iam-authenticator"}.check(aws))
+	assert.EqualError(t, cloudCredentialOptions{tokenCommand: "kubelogin"}.check(aws), `invalid --token-command "kubelogin", expected one of: aws, aws-iam-authenticator`)
+	assert.EqualError(t, cloudCredentialOptions{roleARN: "arn:aws:iam::1:role/r"}.check(azure), "--role-arn is not supported by Azure")
+	assert.EqualError(t, cloudCredentialOptions{execEnv: map[string]string{"A": "b"}}.check(azure), "--exec-env is not supported by Azure")
+
+	ali, _ := cloud.LookupProvider("alicloud")
+	assert.NoError(t, cloudCredentialOptions{privateEndpoint: true, temporaryDuration: time.Hour}.check(ali))
+	assert.EqualError(t, cloudCredentialOptions{temporaryDuration: time.Second}.check(ali), "--temporary-duration must be between 15m0s and 72h0m0s for AlibabaCloud, got 1s")
+	assert.EqualError(t, cloudCredentialOptions{temporaryDuration: 73 * time.Hour}.check(ali), "--temporary-duration must be between 15m0s and 72h0m0s for AlibabaCloud, got 73h0m0s")
+	assert.NoError(t, cloudCredentialOptions{temporaryDuration: 72 * time.Hour}.check(ali))
+	assert.EqualError(t, cloudCredentialOptions{privateEndpoint: true}.check(aws), "--private-endpoint is not supported by AWS")
+	assert.EqualError(t, cloudCredentialOptions{temporaryDuration: time.Hour}.check(azure), "--temporary-duration is not supported by Azure")
+
+	rancher, _ := cloud.LookupProvider("rancher")
+	assert.NoError(t, cloudCredentialOptions{caFile: "ca.pem", clusterEndpoint: "ace", temporaryDuration: time.Hour}.check(rancher))
+	assert.EqualError(t, cloudCredentialOptions{temporaryDuration: time.Second}.check(rancher), "--temporary-duration must be at least 1m, got 1s")
+	assert.ErrorContains(t, cloudCredentialOptions{caFile: "ca.pem", insecureSkipTLSVerify: true}.check(rancher), "cannot be used together")
+	assert.EqualError(t, cloudCredentialOptions{clusterEndpoint: "direct"}.check(rancher), `invalid --rancher-endpoint "direct", expected one of: all, proxy, ace`)
+	assert.EqualError(t, cloudCredentialOptions{caFile: "ca.pem"}.check(aws), "--certificate-authority is not supported by AWS")
+	assert.EqualError(t, cloudCredentialOptions{clusterEndpoint: "ace"}.check(aws), "--rancher-endpoint is not supported by AWS")
+	assert.NoError(t, cloudCredentialOptions{insecureSkipTLSVerify: true}.check(aws))
+
+	var cfg cloud.ProviderConfig
+	cloudCredentialOptions{roleARN: "arn", tokenCommand: "aws", execEnv: map[string]string{}}.apply(&cfg)
+	assert.Equal(t, cloud.ProviderConfig{RoleARN: "arn", TokenCommand: "aws"}, cfg)
+}
+
+func Test_parseClusterFilters(t *testing.T) {
+	filters, err := parseClusterFilters([]string{"env=prod", "owner="})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"env": "prod", "owner": ""}, filters)
+
+	filters, err = parseClusterFilters(nil)
+	assert.NoError(t, err)
+	assert.Nil(t, filters)
+
+	_, err = parseClusterFilters([]string{"env"})
+	assert.EqualError(t, err, `invalid --filter "env", expected key=value`)
+}
+
+func Test_allClustersFlag(t *testing.T) {
+	for _, args := range [][]string{{"--all"}, {"--all-clusters"}} {
+		ca := &CloudAddCommand{}
+		ca.Init()
+		assert.NoError(t, ca.command.ParseFlags(args))
+		assert.True(t, allClustersFlag(ca.command.Flags()), args)
+	}
+	ca := &CloudAddCommand{}
+	ca.Init()
+	assert.NoError(t, ca.command.ParseFlags(nil))
+	assert.False(t, allClustersFlag(ca.command.Flags()))
+}
+
+func Test_renameCloudContexts(t *testing.T) {
+	config := clientcmdapi.NewConfig()
+	config.Contexts["kubernetes-admin@kubernetes"] = &clientcmdapi.Context{Cluster: "kubernetes", AuthInfo: "kubernetes-admin"}
+	config.CurrentContext = "kubernetes-admin@kubernetes"
+	data := cloudContextData{Provider: "alicloud", Name: "prod", ID: "c123", Region: "cn-hangzhou"}
+
+	tmpl := template.Must(template.New("t").Parse("{{.Provider}}-{{.Region}}-{{.Name}}"))
+	got, err := renameCloudContexts(config, tmpl, data)
+	assert.NoError(t, err)
+	assert.Contains(t, got.Contexts, "alicloud-cn-hangzhou-prod")
+	assert.Equal(t, "alicloud-cn-hangzhou-prod", got.CurrentContext)
+	assert.Equal(t, "kubernetes", got.Contexts["alicloud-cn-hangzhou-prod"].Cluster)
+
+	config.Contexts["other"] = &clientcmdapi.Context{Cluster: "kubernetes", AuthInfo: "other"}
+	_, err = renameCloudContexts(config, tmpl, data)
+	assert.ErrorContains(t, err, "several contexts")
+
+	tmpl = template.Must(template.New("t").Parse("{{.Name}}-{{.Context}}"))
+	got, err = renameCloudContexts(config, tmpl, data)
+	assert.NoError(t, err)
+	assert.Contains(t, got.Contexts, "prod-other")
+}
+
+func Test_nonInteractiveInput(t *testing.T) {
+	nonInteractive = true
+	defer func() { nonInteractive = false }()
+
+	_, err := cloudProvider("")
+	assert.EqualError(t, err, "--provider is required in non-interactive mode")
+	_, err = cloudProvider("gcp")
+	assert.ErrorContains(t, err, "'gcp' is not supported")
+
+	rancher, _ := cloud.LookupProvider("rancher")
+	t.Setenv("RANCHER_SERVER_URL", "https://rancher.example.com")
+	os.Unsetenv("RANCHER_API_KEY")
+	_, err = resolveProviderConfig(context.Background(), rancher, cloudScope{})
+	assert.EqualError(t, err, "RANCHER_API_KEY must be set in non-interactive mode")
+
+	t.Setenv("RANCHER_API_KEY", "token")
+	cfg, err := resolveProviderConfig(context.Background(), rancher, cloudScope{})
+	assert.NoError(t, err)
+	assert.Equal(t, "https://rancher.example.com", cfg.Credentials["RANCHER_SERVER_URL"])
+	assert.Equal(t, "token", cfg.Credentials["RANCHER_API_KEY"])
+
+	aws, _ := cloud.LookupProvider("aws")
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_DEFAULT_REGION", "")
+	_, err = resolveProviderConfig(context.Background(), aws, cloudScope{awsProfile: "dev"})
+	assert.EqualError(t, err, "--region_id is required in non-interactive mode")
+
+	t.Setenv("AWS_DEFAULT_REGION", "eu-west-1")
+	cfg, err = resolveProviderConfig(context.Background(), aws, cloudScope{awsProfile: "dev"})
+	assert.NoError(t, err)
+	assert.Equal(t, "eu-west-1", cfg.Region)
+
+	cfg, err = resolveProviderConfig(context.Background(), aws, cloudScope{allRegions: true, profiles: []string{"a", "b"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "a", cfg.Credentials["AWS_PROFILE"])
+	assert.Equal(t, []string{"a", "b"}, cfg.Profiles)
+	assert.Contains(t, cfg.Regions, "us-east-1")
+
+	_, err = resolveProviderConfig(context.Background(), rancher, cloudScope{allRegions: true})
+	assert.EqualError(t, err, "--all-regions is not supported by Rancher")
+}
+
+// fakeProvider serves fixed clusters with one context each.
+type fakeProvider struct {
+	clusters []cloud.ClusterInfo
+	broken   map[string]bool
+	mu       sync.Mutex
+	fetched  []string
+}
+
+func (f *fakeProvider) ListClusters(ctx context.Context) ([]cloud.ClusterInfo, error) {
+	return f.clusters, nil
+}
+
+func (f *fakeProvider) GetKubeconfig(ctx context.Context, cluster cloud.ClusterInfo) (*clientcmdapi.Config, error) {
+	f.mu.Lock()
+	f.fetched = append(f.fetched, cluster.ID)
+	f.mu.Unlock()
+	if f.broken[cluster.ID] {
+		return nil, errors.New("access denied")
+	}
+	config := clientcmdapi.NewConfig()
+	config.Clusters[cluster.ID] = &clientcmdapi.Cluster{Server: "https://" + cluster.ID}
+	config.AuthInfos[cluster.ID] = &clientcmdapi.AuthInfo{Token: cluster.ID}
+	config.Contexts["admin@"+cluster.ID] = &clientcmdapi.Context{Cluster: cluster.ID, AuthInfo: cluster.ID}
+	config.CurrentContext = "admin@" + cluster.ID
+	return config, nil
+}
+
+func Test_addCloudClusters(t *testing.T) {
+	nonInteractive = true
+	silenceTable = true
+	defer func() {
+		nonInteractive = false
+		silenceTable = false
+	}()
+
+	file := filepath.Join(t.TempDir(), "config")
+	assert.NoError(t, clientcmd.WriteToFile(*clientcmdapi.NewConfig(), file))
+	oldCfgFile := cfgFile
+	cfgFile = file
+	defer func() { cfgFile = oldCfgFile }()
+
+	spec := cloud.ProviderSpec{Name: "Fake", Key: "fake"}
+	prov := &fakeProvider{clusters: []cloud.ClusterInfo{
+		{ID: "c1", Name: "prod-a", RegionID: "r1"},
+		{ID: "c2", Name: "prod-b", RegionID: "r1"},
+		{ID: "c3", Name: "dev", RegionID: "r2"},
+	}}
+	opts := cloudAddOptions{
+		clusterName:  "prod-*",
+		nameTemplate: template.Must(template.New("t").Parse("{{.Provider}}-{{.Region}}-{{.Name}}")),
+	}
+	err := addCloudClusters(context.Background(), spec, cloud.ProviderConfig{}, prov, opts)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"c1", "c2"}, prov.fetched)
+
+	config, err := clientcmd.LoadFromFile(file)
+	assert.NoError(t, err)
+	assert.Contains(t, config.Contexts, "fake-r1-prod-a")
+	assert.Contains(t, config.Contexts, "fake-r1-prod-b")
+	assert.Len(t, config.Contexts, 2)
+
+	// Adding the same context again fails instead of prompting for a rename
+	opts = cloudAddOptions{clusterID: "c1"}
+	prov.clusters = nil
+	err = addCloudClusters(context.Background(), spec, cloud.ProviderConfig{}, prov, opts)
+	assert.NoError(t, err)
+	config, err = clientcmd.LoadFromFile(file)
+	assert.NoError(t, err)
+	assert.Contains(t, config.Contexts, "admin@c1")
+
+	err = addCloudClusters(context.Background(), spec, cloud.ProviderConfig{}, prov, opts)
+	assert.EqualError(t, err, `context "admin@c1" already exists`)
+}
+
+func Test_bulkAddCloudClusters(t *testing.T) {
+	nonInteractive = true
+	silenceTable = true
+	defer func() {
+		nonInteractive = false
+		silenceTable = false
+	}()
+
+	file := filepath.Join(t.TempDir(), "config")
+	assert.NoError(t, clientcmd.WriteToFile(*clientcmdapi.NewConfig(), file))
+	oldCfgFile := cfgFile
+	cfgFile = file
+	defer func() { cfgFile = oldCfgFile }()
+
+	spec := cloud.ProviderSpec{Name: "Fake", Key: "fake"}
+	prov := &fakeProvider{clusters: []cloud.ClusterInfo{
+		{ID: "c1", Name: "a"},
+		{ID: "c2", Name: "b"},
+	}}
+	err := addCloudClusters(context.Background(), spec, cloud.ProviderConfig{}, prov, cloudAddOptions{all: true})
+	assert.NoError(t, err)
+
+	// Existing contexts are skipped, failures are reported after the others are written
+	prov.clusters = append(prov.clusters, cloud.ClusterInfo{ID: "c3", Name: "c"}, cloud.ClusterInfo{ID: "c4", Name: "d"})
+	prov.broken = map[string]bool{"c4": true}
+	err = addCloudClusters(context.Background(), spec, cloud.ProviderConfig{}, prov, cloudAddOptions{all: true})
+	assert.EqualError(t, err, "1 of 4 clusters failed")
+
+	config, err := clientcmd.LoadFromFile(file)
+	assert.NoError(t, err)
+	assert.Len(t, config.Contexts, 3)
+	assert.Contains(t, config.Contexts, "admin@c3")
+	assert.NotContains(t, config.Contexts, "admin@c4")
+
+	// A single cluster found by --all takes the bulk path too, skipping its existing context
+	prov.clusters = []cloud.ClusterInfo{{ID: "c1", Name: "a"}}
+	prov.broken = nil
+	err = addCloudClusters(context.Background(), spec, cloud.ProviderConfig{}, prov, cloudAddOptions{all: true})
+	assert.NoError(t, err)
+}
@@ -23,6 +23,7 @@ func NewBaseCommand() *BaseCommand {
 		&ExportCommand{},     // export command
 		&DocsCommand{},       // docs command
 		&RegistryCommand{},   // registry command
+		&TagCommand{},        // tag command
 	)
 
 	return baseCmd
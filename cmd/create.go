@@ -2,22 +2,34 @@ package cmd
 
 import (
 	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/pem"
+	"errors"
 	"fmt"
 	"github.com/manifoldco/promptui"
 	"github.com/spf13/cobra"
+	authenticationv1 "k8s.io/api/authentication/v1"
 	certificatesv1 "k8s.io/api/certificates/v1"
 	coreV1 "k8s.io/api/core/v1"
 	rbacV1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/clientcmd"
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -33,8 +45,130 @@ type CreateOptions struct {
 	contextName string
 	userName    string
 	namespace   string
+	method      string
+	duration    time.Duration
+	// namespaces lists where the role is bound, namespace being the first.
+	namespaces []string
+	// clusterWide binds the cluster role with a ClusterRoleBinding.
+	clusterWide bool
+	// roleKind is the kind of role, ClusterRole unless empty.
+	roleKind string
+	// groups are the organizations of the client certificate.
+	groups []string
+	// keyType and keySize describe the private key of the client
+	// certificate, an RSA 2048 one when empty.
+	keyType string
+	keySize int
+	// expiration is the requested lifetime of the client certificate.
+	expiration time.Duration
+	// wait bounds how long the CSR may take to be signed.
+	wait time.Duration
+	// methodProbed is set when the method was not given but resolved from
+	// the APIs the cluster serves, so that it may still fall back.
+	methodProbed bool
+	// csr names the CSR of the user, the user name when empty.
+	csr string
+	// certificateExpiry is when the issued client certificate expires.
+	certificateExpiry time.Time
 }
 
+// Key types of the client certificate.
+const (
+	createKeyRSA     = "rsa"
+	createKeyECDSA   = "ecdsa"
+	createKeyEd25519 = "ed25519"
+)
+
+// defaultCreateWait is how long the CSR may take to be signed by default.
+const defaultCreateWait = 30 * time.Second
+
+// Ways kubecm create authenticates the new user.
+const (
+	// createMethodCSR signs a client certificate with the
+	// kubernetes.io/kube-apiserver-client signer.
+	createMethodCSR = "csr"
+	// createMethodServiceAccount requests a bound ServiceAccount token.
+	createMethodServiceAccount = "serviceaccount"
+)
+
+// Labels and annotations of the objects kubecm create makes, used by create
+// list, revoke and renew to find them again.
+const (
+	createManagedByLabel = "app.kubernetes.io/managed-by"
+	createUserLabel      = "kubecm.io/user"
+	createMethodLabel    = "kubecm.io/method"
+	// createTokenExpiryAnnotation records on a ServiceAccount when its last
+	// token expires.
+	createTokenExpiryAnnotation = "kubecm.io/token-expires-at"
+	// The bindings of a certificate user record how its certificate was
+	// issued and when it expires, as CSRs are garbage collected an hour
+	// after they are approved.
+	createGroupsAnnotation            = "kubecm.io/groups"
+	createKeyTypeAnnotation           = "kubecm.io/key-type"
+	createKeySizeAnnotation           = "kubecm.io/key-size"
+	createExpirationAnnotation        = "kubecm.io/certificate-lifetime"
+	createCertificateExpiryAnnotation = "kubecm.io/certificate-expires-at"
+)
+
+// createSelector selects the objects kubecm create made, for user if set.
+func createSelector(user string) string {
+	selector := createManagedByLabel + "=kubecm"
+	if user != "" {
+		selector += "," + createUserLabel + "=" + user
+	}
+	return selector
+}
+
+// csrName returns the name of the CSR of the user.
+func (co *CreateOptions) csrName() string {
+	if co.csr != "" {
+		return co.csr
+	}
+	return co.userName
+}
+
+// annotations returns the annotations of the bindings of the user, which
+// record how a client certificate was issued.
+func (co *CreateOptions) annotations() map[string]string {
+	if co.method != createMethodCSR {
+		return nil
+	}
+	annotations := map[string]string{}
+	if len(co.groups) > 0 {
+		annotations[createGroupsAnnotation] = strings.Join(co.groups, ",")
+	}
+	if co.keyType != "" {
+		annotations[createKeyTypeAnnotation] = co.keyType
+	}
+	if co.keySize != 0 {
+		annotations[createKeySizeAnnotation] = strconv.Itoa(co.keySize)
+	}
+	if co.expiration != 0 {
+		annotations[createExpirationAnnotation] = co.expiration.String()
+	}
+	if !co.certificateExpiry.IsZero() {
+		annotations[createCertificateExpiryAnnotation] = co.certificateExpiry.UTC().Format(time.RFC3339)
+	}
+	return annotations
+}
+
+// labels returns the labels of an object authenticating the user with
+// method.
+func (co *CreateOptions) labels(method string) map[string]string {
+	return map[string]string{
+		createManagedByLabel: "kubecm",
+		createUserLabel:      co.userName,
+		createMethodLabel:    method,
+	}
+}
+
+// errCSRUnsupported reports a cluster that does not sign client
+// certificates, as on EKS or GKE.
+var errCSRUnsupported = errors.New("the cluster does not sign client certificates")
+
+// errCreateCancelled reports RBAC objects the user declined to create.
+var errCreateCancelled = errors.New("create operation cancelled")
+
 // Init CreateCommand
 func (ce *CreateCommand) Init() {
 	ce.command = &cobra.Command{
@@ -53,8 +187,25 @@ WARNING: This command is experimental and this feature is only supported in kube
 	ce.command.Flags().String("user", "", "user name for kubeconfig")
 	ce.command.Flags().StringP("namespace", "n", "", "namespace for user")
 	ce.command.Flags().String("cluster-role", "", "cluster role for user")
-	ce.command.Flags().String("context-name", "", "context name for kubeconfig")
+	ce.command.PersistentFlags().String("context-name", "", "context name for kubeconfig")
 	ce.command.Flags().Bool("print-clean-up", false, "print clean up command")
+	ce.command.Flags().String("method", "", "how the user authenticates, csr (client certificate) or serviceaccount (bound token); by default csr, falling back to serviceaccount when the cluster does not sign client certificates")
+	ce.command.Flags().Duration("duration", 0, "lifetime of the serviceaccount token, e.g. 720h; the cluster default when unset")
+	ce.command.Flags().StringSlice("namespaces", nil, "namespaces to bind the role in, e.g. a,b,c; the first one is the context namespace")
+	ce.command.Flags().Bool("cluster-wide", false, "bind the cluster role in all namespaces with a ClusterRoleBinding")
+	ce.command.Flags().String("role", "", "namespaced Role for user, bound instead of a cluster role; it must exist in every namespace")
+	ce.command.Flags().StringSlice("group", nil, "groups of the user, written to the certificate subject (O=)")
+	ce.command.Flags().Bool("dry-run", false, "print the RBAC objects without creating anything")
+	ce.command.Flags().BoolP("yes", "y", false, "Skip confirmation prompt")
+	ce.command.Flags().BoolVar(&nonInteractive, "non-interactive", false, "fail with an error instead of prompting for missing input")
+	ce.command.Flags().String("key-type", createKeyRSA, "private key type of the client certificate, one of: rsa, ecdsa, ed25519")
+	ce.command.Flags().Int("key-size", 0, "private key size, 2048 (default), 3072 or 4096 bits for rsa, 256 (default), 384 or 521 for the ecdsa curve")
+	ce.command.Flags().Duration("expiration", 0, "requested lifetime of the client certificate, e.g. 720h; the signer default when unset")
+	ce.command.Flags().Duration("wait", defaultCreateWait, "how long to wait for the CSR to be signed")
+	ce.command.Flags().Bool("add", false, "add the new context to the kubeconfig like kubecm add, instead of writing <user>-kubeconfig.yaml")
+	ce.AddCommands(&CreateListCommand{})
+	ce.AddCommands(&CreateRevokeCommand{})
+	ce.AddCommands(&CreateRenewCommand{})
 }
 
 func (ce *CreateCommand) runCreate(cmd *cobra.Command, args []string) error {
@@ -63,6 +214,61 @@ func (ce *CreateCommand) runCreate(cmd *cobra.Command, args []string) error {
 	clusterRole, _ := ce.command.Flags().GetString("cluster-role")
 	contextName, _ := ce.command.Flags().GetString("context-name")
 	clean, _ := ce.command.Flags().GetBool("print-clean-up")
+	method, _ := ce.command.Flags().GetString("method")
+	duration, _ := ce.command.Flags().GetDuration("duration")
+	namespaces, _ := ce.command.Flags().GetStringSlice("namespaces")
+	clusterWide, _ := ce.command.Flags().GetBool("cluster-wide")
+	role, _ := ce.command.Flags().GetString("role")
+	groups, _ := ce.command.Flags().GetStringSlice("group")
+	dryRun, _ := ce.command.Flags().GetBool("dry-run")
+	yes, _ := ce.command.Flags().GetBool("yes")
+	keyType, _ := ce.command.Flags().GetString("key-type")
+	keySize, _ := ce.command.Flags().GetInt("key-size")
+	expiration, _ := ce.command.Flags().GetDuration("expiration")
+	wait, _ := ce.command.Flags().GetDuration("wait")
+	add, _ := ce.command.Flags().GetBool("add")
+	if err := checkKey(keyType, keySize); err != nil {
+		return err
+	}
+	if expiration != 0 {
+		if method == createMethodServiceAccount {
+			return errors.New("--expiration is only supported with --method csr, use --duration")
+		}
+		if expiration < 10*time.Minute {
+			return fmt.Errorf("--expiration must be at least 10m, got %s", expiration)
+		}
+	}
+	if wait <= 0 {
+		return fmt.Errorf("--wait must be positive, got %s", wait)
+	}
+	if method != "" && method != createMethodCSR && method != createMethodServiceAccount {
+		return fmt.Errorf("invalid --method %q, expected csr or serviceaccount", method)
+	}
+	if duration != 0 {
+		if method == createMethodCSR {
+			return errors.New("--duration is only supported with --method serviceaccount")
+		}
+		if duration < 10*time.Minute {
+			return fmt.Errorf("--duration must be at least 10m, got %s", duration)
+		}
+	}
+	if role != "" {
+		if clusterRole != "" {
+			return errors.New("--role and --cluster-role cannot be used together")
+		}
+		if clusterWide {
+			return errors.New("--role cannot be bound with --cluster-wide, use --cluster-role")
+		}
+	}
+	if clusterWide && len(namespaces) > 0 {
+		return errors.New("--namespaces and --cluster-wide cannot be used together")
+	}
+	if len(groups) > 0 && method == createMethodServiceAccount {
+		return errors.New("--group is only supported with --method csr, ServiceAccount groups are set by the cluster")
+	}
+	if userName == "" && !isInteractive() {
+		return errors.New("--user is required in non-interactive mode")
+	}
 
 	printYellow(os.Stdout, "WARNING: This feature is only supported in kubernates v1.24 and later.\n")
 	kubeconfig, err := SelectKubeconfigFile("Select The Kubeconfig file To Export Context From")
@@ -77,54 +283,77 @@ func (ce *CreateCommand) runCreate(cmd *cobra.Command, args []string) error {
 		userName = PromptUI("user name", "")
 	}
 	co := CreateOptions{
-		config:   config,
-		userName: userName,
+		config:      config,
+		userName:    userName,
+		method:      method,
+		duration:    duration,
+		clusterWide: clusterWide,
+		groups:      groups,
+		keyType:     keyType,
+		keySize:     keySize,
+		expiration:  expiration,
+		wait:        wait,
 	}
-	if contextName == "" {
-		err = co.chooseContext()
-		if err != nil {
-			return err
+	if err := co.connect(contextName); err != nil {
+		return err
+	}
+	switch {
+	case len(namespaces) > 0:
+		co.namespaces = namespaces
+		if namespace != "" && !slices.Contains(namespaces, namespace) {
+			co.namespaces = append([]string{namespace}, namespaces...)
 		}
-	} else {
-		co.contextName = contextName
-		co.config.CurrentContext = contextName
-		set, err := GetClientSet(kubeconfig)
+		co.namespace = co.namespaces[0]
+	case namespace != "":
+		co.namespace = namespace
+	case clusterWide:
+		co.namespace = metav1.NamespaceDefault
+	default:
+		err = co.chooseNamespace()
 		if err != nil {
 			return err
 		}
-		co.clientSet = set
 	}
-	if namespace == "" {
-		err = co.chooseNamespace()
+
+	switch {
+	case role != "":
+		co.role, co.roleKind = role, "Role"
+		for _, ns := range co.bindingNamespaces() {
+			if _, err := co.clientSet.RbacV1().Roles(ns).Get(context.TODO(), role, metav1.GetOptions{}); err != nil {
+				return err
+			}
+		}
+	case clusterRole != "":
+		co.role = clusterRole
+	default:
+		// select ClusterRole
+		err = co.selectClusterRole()
 		if err != nil {
 			return err
 		}
-	} else {
-		co.namespace = namespace
 	}
 
-	// create CSR
-	_, privateKey, err := co.createCSR()
-	if err != nil {
+	if err := co.resolveMethod(); err != nil {
 		return err
 	}
+	if dryRun {
+		co.printRBACPreview()
+		if co.methodProbed {
+			printWarning(os.Stdout, "The subject becomes a ServiceAccount if the cluster does not sign client certificates.\n")
+		}
+		return nil
+	}
 
-	// approve CSR
-	err = co.approveCSR()
+	// nothing is created before the RBAC objects are confirmed
+	confirm := func() bool { return co.confirmRBAC(yes) }
+	if !confirm() {
+		return errCreateCancelled
+	}
+	authInfo, err := co.authInfo(confirm)
 	if err != nil {
 		return err
 	}
 
-	if clusterRole == "" {
-		// select ClusterRole
-		err = co.selectClusterRole()
-		if err != nil {
-			return err
-		}
-	} else {
-		co.role = clusterRole
-	}
-
 	// create RoleBinding
 	err = co.createRoleBinding()
 	if err != nil {
@@ -132,16 +361,158 @@ func (ce *CreateCommand) runCreate(cmd *cobra.Command, args []string) error {
 	}
 
 	if clean {
-		printCleanCmd(co.userName, co.role)
+		co.printCleanCmd()
 	}
 
 	// create new kubeconfig
-	return co.createKubeConfig(privateKey)
+	if add {
+		return co.addKubeConfig(authInfo)
+	}
+	return co.createKubeConfig(authInfo)
+}
+
+// resolveMethod resolves an unset method from the APIs of the cluster,
+// without creating anything: csr when it serves CertificateSigningRequests,
+// serviceaccount otherwise.
+func (co *CreateOptions) resolveMethod() error {
+	if co.method != "" {
+		return nil
+	}
+	co.methodProbed = true
+	resources, err := co.clientSet.Discovery().ServerResourcesForGroupVersion(certificatesv1.SchemeGroupVersion.String())
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	if resources != nil && slices.ContainsFunc(resources.APIResources, func(r metav1.APIResource) bool {
+		return r.Name == "certificatesigningrequests"
+	}) {
+		co.method = createMethodCSR
+		return nil
+	}
+	co.fallBackToServiceAccount(errCSRUnsupported)
+	return nil
+}
+
+// fallBackToServiceAccount switches a probed method to serviceaccount.
+func (co *CreateOptions) fallBackToServiceAccount(err error) {
+	printWarning(os.Stderr, fmt.Sprintf("Warning: %v, falling back to --method serviceaccount\n", err))
+	if len(co.groups) > 0 {
+		printWarning(os.Stderr, "Warning: --group does not apply to ServiceAccount tokens\n")
+	}
+	co.method = createMethodServiceAccount
+}
+
+// confirmRBAC previews the RBAC objects and asks to create them, unless yes
+// is set or there is no terminal to ask on.
+func (co *CreateOptions) confirmRBAC(yes bool) bool {
+	co.printRBACPreview()
+	if yes || !isInteractive() {
+		return true
+	}
+	return strings.EqualFold(BoolUI("Create these RBAC objects?"), "True")
+}
+
+// authInfo issues the credentials of the user with the resolved method. A
+// probed csr method falls back to a ServiceAccount token when the cluster
+// does not sign the CSR after all, once confirm accepts the new subject.
+func (co *CreateOptions) authInfo(confirm func() bool) (*clientcmdapi.AuthInfo, error) {
+	if co.method == createMethodServiceAccount {
+		return co.serviceAccountAuthInfo()
+	}
+	authInfo, err := co.certificateAuthInfo()
+	if err == nil || !co.methodProbed || !errors.Is(err, errCSRUnsupported) {
+		return authInfo, err
+	}
+	co.fallBackToServiceAccount(err)
+	if !confirm() {
+		return nil, errCreateCancelled
+	}
+	return co.serviceAccountAuthInfo()
+}
+
+// certificateAuthInfo authenticates the user with a client certificate
+// signed through a CSR. A CSR that is not signed is cleaned up and reported
+// as errCSRUnsupported.
+func (co *CreateOptions) certificateAuthInfo() (*clientcmdapi.AuthInfo, error) {
+	// create CSR
+	_, privateKey, err := co.createCSR()
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("%w: %v", errCSRUnsupported, err)
+		}
+		return nil, err
+	}
+	// record the key actually generated, for renew to generate the same
+	co.keyType, co.keySize = keyOf(privateKey.Public())
+
+	// approve CSR
+	err = co.approveCSR()
+	if err != nil {
+		return nil, err
+	}
+
+	certData, err := co.waitCertificate()
+	if err != nil {
+		if errors.Is(err, errCSRUnsupported) {
+			_ = co.clientSet.CertificatesV1().CertificateSigningRequests().Delete(context.TODO(), co.csrName(), metav1.DeleteOptions{})
+		}
+		return nil, err
+	}
+	keyData, err := encodePrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	co.certificateExpiry, _ = certificateNotAfter(certData)
+	return &clientcmdapi.AuthInfo{
+		ClientCertificateData: certData,
+		ClientKeyData:         keyData,
+	}, nil
+}
+
+// serviceAccountAuthInfo authenticates the user as a ServiceAccount of the
+// namespace, created unless it exists, with a token from the TokenRequest
+// API.
+func (co *CreateOptions) serviceAccountAuthInfo() (*clientcmdapi.AuthInfo, error) {
+	ctx := context.TODO()
+	sa := &coreV1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      co.userName,
+			Namespace: co.namespace,
+			Labels:    co.labels(createMethodServiceAccount),
+		},
+	}
+	_, err := co.clientSet.CoreV1().ServiceAccounts(co.namespace).Create(ctx, sa, metav1.CreateOptions{})
+	switch {
+	case err == nil:
+		printString(os.Stdout, "ServiceAccount: "+co.userName+" create success\n")
+	case apierrors.IsAlreadyExists(err):
+		printString(os.Stdout, "ServiceAccount: "+co.userName+" already exists\n")
+	default:
+		return nil, err
+	}
+
+	tr := &authenticationv1.TokenRequest{}
+	if co.duration != 0 {
+		seconds := int64(co.duration.Seconds())
+		tr.Spec.ExpirationSeconds = &seconds
+	}
+	tr, err = co.clientSet.CoreV1().ServiceAccounts(co.namespace).CreateToken(ctx, co.userName, tr, metav1.CreateOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if tr.Status.Token == "" {
+		return nil, errors.New("token data is empty")
+	}
+	if err := co.recordTokenExpiry(tr.Status.ExpirationTimestamp.Time); err != nil {
+		return nil, err
+	}
+	printString(os.Stdout, "Token: expires at "+tr.Status.ExpirationTimestamp.Format(time.RFC3339)+"\n")
+	return &clientcmdapi.AuthInfo{Token: tr.Status.Token}, nil
 }
 
 // createCSR create CSR
-func (co *CreateOptions) createCSR() ([]byte, *rsa.PrivateKey, error) {
-	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+func (co *CreateOptions) createCSR() ([]byte, crypto.Signer, error) {
+	privateKey, err := generateKey(co.keyType, co.keySize)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -149,10 +520,8 @@ func (co *CreateOptions) createCSR() ([]byte, *rsa.PrivateKey, error) {
 	template := x509.CertificateRequest{
 		Subject: pkix.Name{
 			CommonName:   co.userName,
-			Organization: []string{"kubecm"},
+			Organization: co.organizations(),
 		},
-		SignatureAlgorithm: x509.SHA256WithRSA,
-		PublicKeyAlgorithm: x509.RSA,
 	}
 
 	csrBytes, err := x509.CreateCertificateRequest(rand.Reader, &template, privateKey)
@@ -164,14 +533,22 @@ func (co *CreateOptions) createCSR() ([]byte, *rsa.PrivateKey, error) {
 
 	csr := &certificatesv1.CertificateSigningRequest{
 		ObjectMeta: metav1.ObjectMeta{
-			Name: co.userName,
+			Name:   co.csrName(),
+			Labels: co.labels(createMethodCSR),
 		},
 		Spec: certificatesv1.CertificateSigningRequestSpec{
 			Request:    pemCSR,
-			Usages:     []certificatesv1.KeyUsage{certificatesv1.UsageDigitalSignature, certificatesv1.UsageKeyEncipherment, certificatesv1.UsageClientAuth},
+			Usages:     []certificatesv1.KeyUsage{certificatesv1.UsageDigitalSignature, certificatesv1.UsageClientAuth},
 			SignerName: certificatesv1.KubeAPIServerClientSignerName,
 		},
 	}
+	if _, ok := privateKey.(*rsa.PrivateKey); ok {
+		csr.Spec.Usages = append(csr.Spec.Usages, certificatesv1.UsageKeyEncipherment)
+	}
+	if co.expiration != 0 {
+		seconds := int32(co.expiration.Seconds())
+		csr.Spec.ExpirationSeconds = &seconds
+	}
 
 	csr, err = co.clientSet.CertificatesV1().CertificateSigningRequests().Create(context.TODO(), csr, metav1.CreateOptions{})
 	if err != nil {
@@ -183,7 +560,7 @@ func (co *CreateOptions) createCSR() ([]byte, *rsa.PrivateKey, error) {
 
 // approveCSR approve CSR
 func (co *CreateOptions) approveCSR() error {
-	csr, err := co.clientSet.CertificatesV1().CertificateSigningRequests().Get(context.TODO(), co.userName, metav1.GetOptions{})
+	csr, err := co.clientSet.CertificatesV1().CertificateSigningRequests().Get(context.TODO(), co.csrName(), metav1.GetOptions{})
 	if err != nil {
 		return err
 	}
@@ -207,7 +584,7 @@ func (co *CreateOptions) approveCSR() error {
 
 	csr.Status.Conditions = append(csr.Status.Conditions, approvalCondition)
 
-	_, err = co.clientSet.CertificatesV1().CertificateSigningRequests().UpdateApproval(context.TODO(), co.userName, csr, metav1.UpdateOptions{})
+	_, err = co.clientSet.CertificatesV1().CertificateSigningRequests().UpdateApproval(context.TODO(), co.csrName(), csr, metav1.UpdateOptions{})
 	if err != nil {
 		return err
 	}
@@ -215,33 +592,187 @@ func (co *CreateOptions) approveCSR() error {
 	return err
 }
 
-// createKubeConfig create kubeconfig
-func (co *CreateOptions) createKubeConfig(privateKey *rsa.PrivateKey) error {
-	var csr *certificatesv1.CertificateSigningRequest
-	var err error
-	for i := 0; i < 3; i++ { // Retry up to 3 times
-		csr, err = co.clientSet.CertificatesV1().CertificateSigningRequests().Get(context.TODO(), co.userName, metav1.GetOptions{})
-		if err != nil {
-			return err
+// recordTokenExpiry annotates the ServiceAccount with the expiry of its
+// last token, shown by create list
+func (co *CreateOptions) recordTokenExpiry(expiry time.Time) error {
+	sa, err := co.clientSet.CoreV1().ServiceAccounts(co.namespace).Get(context.TODO(), co.userName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if sa.Annotations == nil {
+		sa.Annotations = map[string]string{}
+	}
+	sa.Annotations[createTokenExpiryAnnotation] = expiry.UTC().Format(time.RFC3339)
+	_, err = co.clientSet.CoreV1().ServiceAccounts(co.namespace).Update(context.TODO(), sa, metav1.UpdateOptions{})
+	return err
+}
+
+// waitCertificate waits for the CSR to be signed, watching it for up to
+// co.wait
+func (co *CreateOptions) waitCertificate() ([]byte, error) {
+	wait := co.wait
+	if wait == 0 {
+		wait = defaultCreateWait
+	}
+	ctx, cancel := context.WithTimeout(context.TODO(), wait)
+	defer cancel()
+	csrs := co.clientSet.CertificatesV1().CertificateSigningRequests()
+
+	csr, err := csrs.Get(ctx, co.csrName(), metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if certData, done, err := csrIssued(csr); done {
+		return certData, err
+	}
+	w, err := csrs.Watch(ctx, metav1.ListOptions{
+		FieldSelector:   fields.OneTermEqualSelector("metadata.name", co.csrName()).String(),
+		ResourceVersion: csr.ResourceVersion,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer w.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%w: CSR %s was approved but not signed within %s", errCSRUnsupported, co.csrName(), wait)
+		case event, ok := <-w.ResultChan():
+			if !ok {
+				return nil, fmt.Errorf("watching CSR %s stopped before it was signed", co.csrName())
+			}
+			if event.Type == watch.Deleted {
+				return nil, fmt.Errorf("CSR %s was deleted before it was signed", co.csrName())
+			}
+			csr, ok := event.Object.(*certificatesv1.CertificateSigningRequest)
+			if !ok || csr.Name != co.csrName() {
+				continue
+			}
+			if certData, done, err := csrIssued(csr); done {
+				return certData, err
+			}
+		}
+	}
+}
+
+// csrIssued reports whether a CSR is done, either signed or failed
+func csrIssued(csr *certificatesv1.CertificateSigningRequest) ([]byte, bool, error) {
+	if len(csr.Status.Certificate) != 0 {
+		return csr.Status.Certificate, true, nil
+	}
+	for _, condition := range csr.Status.Conditions {
+		if condition.Type == certificatesv1.CertificateFailed {
+			return nil, true, fmt.Errorf("%w: CSR %s failed: %s", errCSRUnsupported, csr.Name, condition.Message)
 		}
+	}
+	return nil, false, nil
+}
 
-		if len(csr.Status.Certificate) != 0 {
-			break
+// checkKey checks a key type and size
+func checkKey(keyType string, keySize int) error {
+	switch keyType {
+	case createKeyRSA:
+		if keySize != 0 && keySize != 2048 && keySize != 3072 && keySize != 4096 {
+			return fmt.Errorf("invalid --key-size %d for rsa, expected 2048, 3072 or 4096", keySize)
+		}
+	case createKeyECDSA:
+		if keySize != 0 && keySize != 256 && keySize != 384 && keySize != 521 {
+			return fmt.Errorf("invalid --key-size %d for ecdsa, expected 256, 384 or 521", keySize)
 		}
+	case createKeyEd25519:
+		if keySize != 0 {
+			return errors.New("--key-size is not supported for ed25519 keys")
+		}
+	default:
+		return fmt.Errorf("invalid --key-type %q, expected rsa, ecdsa or ed25519", keyType)
+	}
+	return nil
+}
 
-		//fmt.Printf("Waiting for CSR to be signed...  %v\n", i)
-		// Sleep for a second before retrying
-		time.Sleep(1 * time.Second)
+// generateKey generates the private key of a client certificate
+func generateKey(keyType string, keySize int) (crypto.Signer, error) {
+	if keyType == "" {
+		keyType = createKeyRSA
 	}
+	if err := checkKey(keyType, keySize); err != nil {
+		return nil, err
+	}
+	switch keyType {
+	case createKeyECDSA:
+		curve := elliptic.P256()
+		switch keySize {
+		case 384:
+			curve = elliptic.P384()
+		case 521:
+			curve = elliptic.P521()
+		}
+		return ecdsa.GenerateKey(curve, rand.Reader)
+	case createKeyEd25519:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		return key, err
+	default:
+		if keySize == 0 {
+			keySize = 2048
+		}
+		return rsa.GenerateKey(rand.Reader, keySize)
+	}
+}
 
-	certData := csr.Status.Certificate
-	if len(certData) == 0 {
-		return fmt.Errorf("certificate data is empty")
+// encodePrivateKey encodes a private key to PEM, in PKCS #1 or SEC 1 form
+// for RSA and ECDSA keys and PKCS #8 otherwise
+func encodePrivateKey(key crypto.Signer) ([]byte, error) {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(k)}), nil
+	case *ecdsa.PrivateKey:
+		der, err := x509.MarshalECPrivateKey(k)
+		if err != nil {
+			return nil, err
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), nil
+	default:
+		der, err := x509.MarshalPKCS8PrivateKey(k)
+		if err != nil {
+			return nil, err
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
 	}
+}
 
-	cluster := co.config.Clusters[co.contextName]
+// createKubeConfig create kubeconfig
+func (co *CreateOptions) createKubeConfig(authInfo *clientcmdapi.AuthInfo) error {
+	newKubeConfig, err := co.newKubeConfig(authInfo)
+	if err != nil {
+		return err
+	}
+
+	// write to file
+	err = clientcmd.WriteToFile(*newKubeConfig, co.userName+"-kubeconfig.yaml")
+	if err != nil {
+		return err
+	}
+	printString(os.Stdout, "kubeconfig: "+co.userName+" create success\n")
+	return err
+}
+
+// addKubeConfig adds the new context to a kubeconfig file like kubecm add
+func (co *CreateOptions) addKubeConfig(authInfo *clientcmdapi.AuthInfo) error {
+	newKubeConfig, err := co.newKubeConfig(authInfo)
+	if err != nil {
+		return err
+	}
+	return AddToLocal(newKubeConfig, co.userName, "", false, false, nil, nil, false)
+}
+
+// newKubeConfig returns a kubeconfig with a context for the user
+func (co *CreateOptions) newKubeConfig(authInfo *clientcmdapi.AuthInfo) (*clientcmdapi.Config, error) {
+	clusterName := co.contextName
+	if ctx, ok := co.config.Contexts[co.contextName]; ok && ctx.Cluster != "" {
+		clusterName = ctx.Cluster
+	}
+	cluster := co.config.Clusters[clusterName]
 	if cluster == nil {
-		return fmt.Errorf("cluster configuration not found")
+		return nil, fmt.Errorf("cluster configuration not found")
 	}
 
 	newKubeConfig := clientcmdapi.NewConfig()
@@ -249,27 +780,47 @@ func (co *CreateOptions) createKubeConfig(privateKey *rsa.PrivateKey) error {
 		Server:                   cluster.Server,
 		CertificateAuthorityData: cluster.CertificateAuthorityData,
 	}
-	newKubeConfig.AuthInfos[co.userName] = &clientcmdapi.AuthInfo{
-		ClientCertificateData: certData,
-		ClientKeyData:         pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey)}),
-	}
+	newKubeConfig.AuthInfos[co.userName] = authInfo
 	newKubeConfig.Contexts[co.userName] = &clientcmdapi.Context{
-		Cluster:  co.contextName,
-		AuthInfo: co.userName,
+		Cluster:   co.contextName,
+		AuthInfo:  co.userName,
+		Namespace: co.namespace,
 	}
 	newKubeConfig.CurrentContext = co.userName
+	return newKubeConfig, nil
+}
 
-	// write to file
-	err = clientcmd.WriteToFile(*newKubeConfig, co.userName+"-kubeconfig.yaml")
+// newCreateOptions loads a kubeconfig file and connects to the cluster of
+// contextName, chosen from a prompt when empty
+func newCreateOptions(contextName string) (*CreateOptions, error) {
+	co, err := loadCreateOptions()
 	if err != nil {
-		return err
+		return nil, err
 	}
-	printString(os.Stdout, "kubeconfig: "+co.userName+" create success\n")
-	return err
+	if err := co.connect(contextName); err != nil {
+		return nil, err
+	}
+	return co, nil
+}
+
+// loadCreateOptions loads a kubeconfig file
+func loadCreateOptions() (*CreateOptions, error) {
+	kubeconfig, err := SelectKubeconfigFile("Select The Kubeconfig file To Export Context From")
+	if err != nil {
+		return nil, err
+	}
+	config, err := clientcmd.LoadFromFile(kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	return &CreateOptions{config: config}, nil
 }
 
 // chooseContext choose context
 func (co *CreateOptions) chooseContext() error {
+	if !isInteractive() {
+		return errors.New("--context-name is required in non-interactive mode")
+	}
 	var kubeItems []Needle
 	current := co.config.CurrentContext
 	for key, obj := range co.config.Contexts {
@@ -282,11 +833,33 @@ func (co *CreateOptions) chooseContext() error {
 	num := SelectUI(kubeItems, "Select Kube Context")
 	co.contextName = kubeItems[num].Name
 	co.config.CurrentContext = co.contextName
+	return co.newClientSet()
+}
+
+// connect connects to the cluster of contextName, chosen from a prompt when
+// empty
+func (co *CreateOptions) connect(contextName string) error {
+	if contextName == "" {
+		return co.chooseContext()
+	}
+	if _, ok := co.config.Contexts[contextName]; !ok {
+		return fmt.Errorf("context %q not found", contextName)
+	}
+	co.contextName = contextName
+	co.config.CurrentContext = contextName
+	return co.newClientSet()
+}
+
+// newClientSet connects to the cluster of the current context
+func (co *CreateOptions) newClientSet() error {
 	clientConfig := clientcmd.NewDefaultClientConfig(
 		*co.config,
 		&clientcmd.ConfigOverrides{},
 	)
-	c, _ := clientConfig.ClientConfig()
+	c, err := clientConfig.ClientConfig()
+	if err != nil {
+		return err
+	}
 	clientSet, err := kubernetes.NewForConfig(c)
 	if err != nil {
 		return err
@@ -297,6 +870,9 @@ func (co *CreateOptions) chooseContext() error {
 
 // chooseNamespace choose namespace
 func (co *CreateOptions) chooseNamespace() error {
+	if !isInteractive() {
+		return errors.New("--namespace, --namespaces or --cluster-wide is required in non-interactive mode")
+	}
 	var nss []Namespaces
 	ctx := context.TODO()
 	namespaceList, err := co.clientSet.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
@@ -313,6 +889,9 @@ func (co *CreateOptions) chooseNamespace() error {
 
 // selectClusterRole select cluster role
 func (co *CreateOptions) selectClusterRole() error {
+	if !isInteractive() {
+		return errors.New("--cluster-role or --role is required in non-interactive mode")
+	}
 	clusterRoleList := []string{
 		"view", "edit", "admin", "cluster-admin", "custom",
 	}
@@ -346,41 +925,145 @@ func (co *CreateOptions) selectClusterRole() error {
 	return nil
 }
 
-// createRoleBinding create role binding
+// createRoleBinding creates the RoleBindings, or the ClusterRoleBinding, of
+// the user
 func (co *CreateOptions) createRoleBinding() error {
-	rb := &rbacV1.RoleBinding{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      fmt.Sprintf("%s-%s", co.userName, co.role),
-			Namespace: co.namespace,
-		},
-		Subjects: []rbacV1.Subject{
-			{
-				Kind:     "User",
-				Name:     co.userName,
-				APIGroup: "rbac.authorization.k8s.io",
+	if co.clusterWide {
+		crb, err := co.clientSet.RbacV1().ClusterRoleBindings().Create(context.TODO(), co.clusterRoleBinding(), metav1.CreateOptions{})
+		if err != nil {
+			return err
+		}
+		printString(os.Stdout, "ClusterRoleBinding")
+		fmt.Printf(" : %s create success\n", crb.Name)
+		return nil
+	}
+	for _, rb := range co.roleBindings() {
+		newRoleBinding, err := co.clientSet.RbacV1().RoleBindings(rb.Namespace).Create(context.TODO(), rb, metav1.CreateOptions{})
+		if err != nil {
+			return err
+		}
+		printString(os.Stdout, "RoleBinding")
+		fmt.Printf(" : %s/%s create success\n", newRoleBinding.Namespace, newRoleBinding.Name)
+	}
+	return nil
+}
+
+// bindingName is the name of the bindings of the user.
+func (co *CreateOptions) bindingName() string {
+	return fmt.Sprintf("%s-%s", co.userName, co.role)
+}
+
+// bindingNamespaces returns the namespaces the role is bound in.
+func (co *CreateOptions) bindingNamespaces() []string {
+	if len(co.namespaces) > 0 {
+		return co.namespaces
+	}
+	return []string{co.namespace}
+}
+
+// roleRef returns the role bound to the user.
+func (co *CreateOptions) roleRef() rbacV1.RoleRef {
+	kind := co.roleKind
+	if kind == "" {
+		kind = "ClusterRole"
+	}
+	return rbacV1.RoleRef{
+		APIGroup: "rbac.authorization.k8s.io",
+		Kind:     kind,
+		Name:     co.role,
+	}
+}
+
+func (co *CreateOptions) roleBindings() []*rbacV1.RoleBinding {
+	var bindings []*rbacV1.RoleBinding
+	for _, ns := range co.bindingNamespaces() {
+		bindings = append(bindings, &rbacV1.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        co.bindingName(),
+				Namespace:   ns,
+				Labels:      co.labels(co.method),
+				Annotations: co.annotations(),
 			},
+			Subjects: []rbacV1.Subject{co.subject()},
+			RoleRef:  co.roleRef(),
+		})
+	}
+	return bindings
+}
+
+func (co *CreateOptions) clusterRoleBinding() *rbacV1.ClusterRoleBinding {
+	return &rbacV1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        co.bindingName(),
+			Labels:      co.labels(co.method),
+			Annotations: co.annotations(),
 		},
-		RoleRef: rbacV1.RoleRef{
-			APIGroup: "rbac.authorization.k8s.io",
-			Kind:     "ClusterRole",
-			Name:     co.role,
-		},
+		Subjects: []rbacV1.Subject{co.subject()},
+		RoleRef:  co.roleRef(),
 	}
-	newRoleBinding, err := co.clientSet.RbacV1().RoleBindings(co.namespace).Create(context.TODO(), rb, metav1.CreateOptions{})
-	if err != nil {
-		return err
+}
+
+// printRBACPreview prints the RBAC objects about to be created
+func (co *CreateOptions) printRBACPreview() {
+	ref := co.roleRef()
+	role := ref.Kind + "/" + ref.Name
+	subject := co.subject()
+	user := subject.Kind + "/" + subject.Name
+	if len(co.groups) > 0 {
+		user += " (groups: " + strings.Join(co.groups, ", ") + ")"
 	}
-	printString(os.Stdout, "RoleBinding")
-	fmt.Printf(" : %s create success\n", newRoleBinding.Name)
-	return nil
+	var rows [][]string
+	if co.clusterWide {
+		rows = append(rows, []string{"ClusterRoleBinding", "", co.bindingName(), role, user})
+	} else {
+		for _, ns := range co.bindingNamespaces() {
+			rows = append(rows, []string{"RoleBinding", ns, co.bindingName(), role, user})
+		}
+	}
+	printRegistryTable([]string{"KIND", "NAMESPACE", "NAME", "ROLE", "SUBJECT"}, rows)
 }
 
-func printCleanCmd(user, role string) {
-	fmt.Print(`
-# Clean up commands
-kubectl delete certificatesigningrequests.certificates.k8s.io ` + user + `
-kubectl delete rolebinding ` + user + `-` + role + `
+// organizations returns the organizations of the client certificate
+func (co *CreateOptions) organizations() []string {
+	if len(co.groups) > 0 {
+		return co.groups
+	}
+	return []string{"kubecm"}
+}
+
+// subject returns the RBAC subject of the user.
+func (co *CreateOptions) subject() rbacV1.Subject {
+	if co.method == createMethodServiceAccount {
+		return rbacV1.Subject{
+			Kind:      "ServiceAccount",
+			Name:      co.userName,
+			Namespace: co.namespace,
+		}
+	}
+	return rbacV1.Subject{
+		Kind:     "User",
+		Name:     co.userName,
+		APIGroup: "rbac.authorization.k8s.io",
+	}
+}
 
+func (co *CreateOptions) printCleanCmd() {
+	credential := "certificatesigningrequests.certificates.k8s.io " + co.csrName()
+	if co.method == createMethodServiceAccount {
+		credential = "serviceaccount -n " + co.namespace + " " + co.userName
+	}
+	bindings := ""
+	if co.clusterWide {
+		bindings = "kubectl delete clusterrolebinding " + co.bindingName() + "\n"
+	} else {
+		for _, ns := range co.bindingNamespaces() {
+			bindings += "kubectl delete rolebinding -n " + ns + " " + co.bindingName() + "\n"
+		}
+	}
+	fmt.Print(`
+# Clean up commands, or: kubecm create revoke ` + co.userName + `
+kubectl delete ` + credential + `
+` + bindings + `
 `)
 }
 
@@ -390,5 +1073,17 @@ func createExample() string {
 kubecm create
 # Create new KubeConfig(experiment) with flags
 kubecm create --user test --namespace default --cluster-role view --context-name kind-kind
+# Create new KubeConfig(experiment) with a ServiceAccount token valid for 30 days, e.g. on EKS or GKE
+kubecm create --method serviceaccount --duration 720h --user test --namespace default --cluster-role view
+# Bind the namespaced Role "deployer" in several namespaces, for a user in the "dev" group
+kubecm create --user test --namespaces dev,staging --role deployer --group dev --context-name kind-kind
+# Bind a cluster role in all namespaces, previewing the RBAC objects only
+kubecm create --user test --cluster-wide --cluster-role view --context-name kind-kind --dry-run
+# Create new KubeConfig(experiment) with an ECDSA P-384 key, a certificate valid for 7 days, added to the kubeconfig
+kubecm create --user test --key-type ecdsa --key-size 384 --expiration 168h --add
+# List, renew and revoke the users created by kubecm
+kubecm create list --context-name kind-kind
+kubecm create renew test --context-name kind-kind
+kubecm create revoke test --context-name kind-kind
 `
 }
@@ -0,0 +1,219 @@
+package cmd
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// CreateListCommand list subcommand for create command
+type CreateListCommand struct {
+	BaseCommand
+}
+
+// Init CreateListCommand
+func (cl *CreateListCommand) Init() {
+	cl.command = &cobra.Command{
+		Use:   "list",
+		Short: "List the users created by kubecm",
+		Long:  "List the users created by kubecm create, with their bindings and credential expiry",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cl.runList(cmd, args)
+		},
+		Example: createListExample(),
+	}
+	cl.command.Flags().Bool("all-contexts", false, "list the users of the clusters of every context")
+}
+
+func (cl *CreateListCommand) runList(cmd *cobra.Command, args []string) error {
+	contextName, _ := cl.command.Flags().GetString("context-name")
+	allContexts, _ := cl.command.Flags().GetBool("all-contexts")
+	if allContexts && contextName != "" {
+		return fmt.Errorf("--all-contexts and --context-name cannot be used together")
+	}
+
+	var contexts []string
+	var co *CreateOptions
+	var err error
+	if allContexts {
+		co, err = loadCreateOptions()
+		if err != nil {
+			return err
+		}
+		for name := range co.config.Contexts {
+			contexts = append(contexts, name)
+		}
+		sort.Strings(contexts)
+	} else {
+		co, err = newCreateOptions(contextName)
+		if err != nil {
+			return err
+		}
+		contexts = []string{co.contextName}
+	}
+
+	var rows [][]string
+	for _, name := range contexts {
+		if allContexts {
+			if err := co.connect(name); err != nil {
+				printWarning(os.Stderr, fmt.Sprintf("Warning: %s: %v\n", name, err))
+				continue
+			}
+		}
+		users, err := listCreatedUsers(cmd.Context(), co.clientSet)
+		if err != nil {
+			if !allContexts {
+				return err
+			}
+			printWarning(os.Stderr, fmt.Sprintf("Warning: %s: %v\n", name, err))
+			continue
+		}
+		for _, u := range users {
+			rows = append(rows, []string{name, u.name, u.method, strings.Join(u.bindings, ", "), u.expiry(time.Now())})
+		}
+	}
+	if len(rows) == 0 {
+		fmt.Println("No users created by kubecm found")
+		return nil
+	}
+	printRegistryTable([]string{"CONTEXT", "USER", "METHOD", "BINDINGS", "EXPIRES"}, rows)
+	return nil
+}
+
+// createdUser is a user made by kubecm create, gathered from the labels of
+// its objects.
+type createdUser struct {
+	name     string
+	method   string
+	bindings []string
+	expires  time.Time
+	pending  bool
+}
+
+// expiry describes when the credentials of the user expire.
+func (u createdUser) expiry(now time.Time) string {
+	switch {
+	case u.pending:
+		return "pending"
+	case u.expires.IsZero():
+		return "unknown"
+	case now.After(u.expires):
+		return u.expires.Local().Format("2006-01-02 15:04") + " (expired)"
+	default:
+		return u.expires.Local().Format("2006-01-02 15:04")
+	}
+}
+
+// listCreatedUsers lists the users made by kubecm create in a cluster.
+func listCreatedUsers(ctx context.Context, clientSet kubernetes.Interface) ([]createdUser, error) {
+	users := map[string]*createdUser{}
+	user := func(labels map[string]string) *createdUser {
+		name := labels[createUserLabel]
+		if users[name] == nil {
+			users[name] = &createdUser{name: name, method: labels[createMethodLabel]}
+		}
+		return users[name]
+	}
+	opts := metav1.ListOptions{LabelSelector: createSelector("")}
+
+	csrs, err := clientSet.CertificatesV1().CertificateSigningRequests().List(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	for _, csr := range csrs.Items {
+		u := user(csr.Labels)
+		u.method = createMethodCSR
+		u.expires, u.pending = certificateExpiry(csr)
+	}
+	sas, err := clientSet.CoreV1().ServiceAccounts(metav1.NamespaceAll).List(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	for _, sa := range sas.Items {
+		u := user(sa.Labels)
+		u.method = createMethodServiceAccount
+		u.expires, _ = time.Parse(time.RFC3339, sa.Annotations[createTokenExpiryAnnotation])
+	}
+	rbs, err := clientSet.RbacV1().RoleBindings(metav1.NamespaceAll).List(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	for _, rb := range rbs.Items {
+		u := user(rb.Labels)
+		u.bindings = append(u.bindings, rb.Namespace+": "+rb.RoleRef.Name)
+		u.bindingExpiry(rb.Annotations)
+	}
+	crbs, err := clientSet.RbacV1().ClusterRoleBindings().List(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	for _, crb := range crbs.Items {
+		u := user(crb.Labels)
+		u.bindings = append(u.bindings, "cluster: "+crb.RoleRef.Name)
+		u.bindingExpiry(crb.Annotations)
+	}
+
+	out := make([]createdUser, 0, len(users))
+	for _, u := range users {
+		sort.Strings(u.bindings)
+		out = append(out, *u)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].name < out[j].name })
+	return out, nil
+}
+
+// bindingExpiry takes the expiry of a certificate user from the annotations
+// of its bindings, which outlive its CSR.
+func (u *createdUser) bindingExpiry(annotations map[string]string) {
+	expires, err := time.Parse(time.RFC3339, annotations[createCertificateExpiryAnnotation])
+	if err != nil || u.method != createMethodCSR {
+		return
+	}
+	if expires.After(u.expires) {
+		u.expires = expires
+	}
+	u.pending = false
+}
+
+// certificateExpiry returns when the certificate issued for a CSR expires,
+// or pending when none was issued yet.
+func certificateExpiry(csr certificatesv1.CertificateSigningRequest) (expires time.Time, pending bool) {
+	if len(csr.Status.Certificate) == 0 {
+		return time.Time{}, true
+	}
+	expires, _ = certificateNotAfter(csr.Status.Certificate)
+	return expires, false
+}
+
+// certificateNotAfter returns when a PEM certificate expires.
+func certificateNotAfter(certData []byte) (time.Time, error) {
+	block, _ := pem.Decode(certData)
+	if block == nil {
+		return time.Time{}, errors.New("no PEM certificate found")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return cert.NotAfter, nil
+}
+
+func createListExample() string {
+	return `
+# List the users created by kubecm in a cluster
+kubecm create list --context-name kind-kind
+# List them in the clusters of every context
+kubecm create list --all-contexts
+`
+}
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	coreV1 "k8s.io/api/core/v1"
+	rbacV1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// testCertificate returns a PEM certificate expiring at notAfter.
+func testCertificate(t *testing.T, notAfter time.Time) []byte {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "alice"},
+		NotBefore:    notAfter.Add(-time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+// createdObjects returns the objects kubecm create makes for a certificate
+// user alice and a ServiceAccount user bob, plus an unrelated binding.
+func createdObjects(t *testing.T, expires time.Time) []runtime.Object {
+	alice := (&CreateOptions{userName: "alice"}).labels(createMethodCSR)
+	aliceCertificate := map[string]string{
+		createGroupsAnnotation:            "dev,ops",
+		createKeyTypeAnnotation:           createKeyECDSA,
+		createKeySizeAnnotation:           "384",
+		createCertificateExpiryAnnotation: expires.Format(time.RFC3339),
+	}
+	bob := (&CreateOptions{userName: "bob"}).labels(createMethodServiceAccount)
+	return []runtime.Object{
+		&certificatesv1.CertificateSigningRequest{
+			ObjectMeta: metav1.ObjectMeta{Name: "alice", Labels: alice},
+			Status:     certificatesv1.CertificateSigningRequestStatus{Certificate: testCertificate(t, expires)},
+		},
+		&rbacV1.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "alice-view", Namespace: "dev", Labels: alice, Annotations: aliceCertificate},
+			RoleRef:    rbacV1.RoleRef{Kind: "ClusterRole", Name: "view"},
+		},
+		&rbacV1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "alice-view", Labels: alice, Annotations: aliceCertificate},
+			RoleRef:    rbacV1.RoleRef{Kind: "ClusterRole", Name: "view"},
+		},
+		&coreV1.ServiceAccount{
+			ObjectMeta: metav1.ObjectMeta{Name: "bob", Namespace: "ci", Labels: bob,
+				Annotations: map[string]string{createTokenExpiryAnnotation: expires.Format(time.RFC3339)}},
+		},
+		&rbacV1.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "bob-edit", Namespace: "ci", Labels: bob},
+			RoleRef:    rbacV1.RoleRef{Kind: "ClusterRole", Name: "edit"},
+		},
+		&rbacV1.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "dev"},
+			RoleRef:    rbacV1.RoleRef{Kind: "ClusterRole", Name: "admin"},
+		},
+	}
+}
+
+func TestListCreatedUsers(t *testing.T) {
+	expires := time.Date(2030, 1, 2, 3, 4, 0, 0, time.UTC)
+	clientset := fake.NewSimpleClientset(createdObjects(t, expires)...)
+
+	users, err := listCreatedUsers(context.Background(), clientset)
+	require.NoError(t, err)
+	assert.Equal(t, []createdUser{
+		{name: "alice", method: createMethodCSR, bindings: []string{"cluster: view", "dev: view"}, expires: expires},
+		{name: "bob", method: createMethodServiceAccount, bindings: []string{"ci: edit"}, expires: expires},
+	}, users)
+
+	// the expiry of a certificate user outlives its garbage collected CSR
+	require.NoError(t, clientset.CertificatesV1().CertificateSigningRequests().Delete(context.Background(), "alice", metav1.DeleteOptions{}))
+	users, err = listCreatedUsers(context.Background(), clientset)
+	require.NoError(t, err)
+	assert.Equal(t, expires, users[0].expires)
+	assert.False(t, users[0].pending)
+}
+
+func TestCreatedUserExpiry(t *testing.T) {
+	now := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, "pending", createdUser{pending: true}.expiry(now))
+	assert.Equal(t, "unknown", createdUser{}.expiry(now))
+	assert.Contains(t, createdUser{expires: now.Add(-time.Hour)}.expiry(now), "(expired)")
+	assert.NotContains(t, createdUser{expires: now.Add(time.Hour)}.expiry(now), "(expired)")
+}
@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	rbacV1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// CreateRenewCommand renew subcommand for create command
+type CreateRenewCommand struct {
+	BaseCommand
+}
+
+// Init CreateRenewCommand
+func (cr *CreateRenewCommand) Init() {
+	cr.command = &cobra.Command{
+		Use:   "renew <user>",
+		Short: "Renew the credentials of a user created by kubecm",
+		Long:  "Renew the credentials of a user created by kubecm create, reissuing its certificate, with the same groups, key type and lifetime, or its ServiceAccount token into <user>-kubeconfig.yaml",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cr.runRenew(cmd, args)
+		},
+		Example: createRenewExample(),
+	}
+	cr.command.Flags().Duration("duration", 0, "lifetime of the serviceaccount token, e.g. 720h; the cluster default when unset")
+}
+
+func (cr *CreateRenewCommand) runRenew(cmd *cobra.Command, args []string) error {
+	contextName, _ := cr.command.Flags().GetString("context-name")
+	duration, _ := cr.command.Flags().GetDuration("duration")
+	if duration != 0 && duration < 10*time.Minute {
+		return fmt.Errorf("--duration must be at least 10m, got %s", duration)
+	}
+	co, err := newCreateOptions(contextName)
+	if err != nil {
+		return err
+	}
+	co.userName = args[0]
+	co.duration = duration
+	authInfo, err := co.renew(cmd.Context())
+	if err != nil {
+		return err
+	}
+	return co.createKubeConfig(authInfo)
+}
+
+// renew reissues the credentials of a user made by kubecm create: a new
+// token for a ServiceAccount, otherwise a new certificate issued like the
+// previous one, as recorded on the bindings of the user.
+func (co *CreateOptions) renew(ctx context.Context) (*clientcmdapi.AuthInfo, error) {
+	opts := metav1.ListOptions{LabelSelector: createSelector(co.userName)}
+	sas, err := co.clientSet.CoreV1().ServiceAccounts(metav1.NamespaceAll).List(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(sas.Items) > 0 {
+		co.method = createMethodServiceAccount
+		co.namespace = sas.Items[0].Namespace
+		return co.serviceAccountAuthInfo()
+	}
+
+	bindings, err := co.certificateBindings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(bindings) == 0 {
+		return nil, fmt.Errorf("no user %q created by kubecm found", co.userName)
+	}
+	if co.duration != 0 {
+		return nil, fmt.Errorf("--duration is only supported for ServiceAccount users, %s uses a certificate", co.userName)
+	}
+	co.method = createMethodCSR
+	annotations := bindings[0].GetAnnotations()
+	if groups := annotations[createGroupsAnnotation]; groups != "" {
+		co.groups = strings.Split(groups, ",")
+	}
+	co.keyType = annotations[createKeyTypeAnnotation]
+	if co.keyType == "" {
+		co.keyType = createKeyRSA
+	}
+	co.keySize, _ = strconv.Atoi(annotations[createKeySizeAnnotation])
+	co.expiration, _ = time.ParseDuration(annotations[createExpirationAnnotation])
+	for _, binding := range bindings {
+		if binding.GetNamespace() != "" {
+			co.namespace = binding.GetNamespace()
+			break
+		}
+	}
+
+	// the previous CSR, if not garbage collected yet, is only deleted once
+	// the new certificate is issued
+	previous, err := co.clientSet.CertificatesV1().CertificateSigningRequests().List(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	co.csr = fmt.Sprintf("%s-%d", co.userName, time.Now().Unix())
+	authInfo, err := co.certificateAuthInfo()
+	if err != nil {
+		return nil, err
+	}
+	for _, csr := range previous.Items {
+		if err := co.clientSet.CertificatesV1().CertificateSigningRequests().Delete(ctx, csr.Name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return nil, err
+		}
+	}
+	if err := co.recordCertificateExpiry(ctx, bindings); err != nil {
+		return nil, err
+	}
+	return authInfo, nil
+}
+
+// certificateBindings returns the RoleBindings and ClusterRoleBindings of a
+// certificate user.
+func (co *CreateOptions) certificateBindings(ctx context.Context) ([]metav1.Object, error) {
+	opts := metav1.ListOptions{LabelSelector: createSelector(co.userName) + "," + createMethodLabel + "=" + createMethodCSR}
+	var bindings []metav1.Object
+	rbs, err := co.clientSet.RbacV1().RoleBindings(metav1.NamespaceAll).List(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	for i := range rbs.Items {
+		bindings = append(bindings, &rbs.Items[i])
+	}
+	crbs, err := co.clientSet.RbacV1().ClusterRoleBindings().List(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	for i := range crbs.Items {
+		bindings = append(bindings, &crbs.Items[i])
+	}
+	return bindings, nil
+}
+
+// recordCertificateExpiry records the expiry of the renewed certificate on
+// the bindings of the user.
+func (co *CreateOptions) recordCertificateExpiry(ctx context.Context, bindings []metav1.Object) error {
+	expiry := co.certificateExpiry.UTC().Format(time.RFC3339)
+	for _, binding := range bindings {
+		annotations := binding.GetAnnotations()
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[createCertificateExpiryAnnotation] = expiry
+		binding.SetAnnotations(annotations)
+		var err error
+		switch b := binding.(type) {
+		case *rbacV1.RoleBinding:
+			_, err = co.clientSet.RbacV1().RoleBindings(b.Namespace).Update(ctx, b, metav1.UpdateOptions{})
+		case *rbacV1.ClusterRoleBinding:
+			_, err = co.clientSet.RbacV1().ClusterRoleBindings().Update(ctx, b, metav1.UpdateOptions{})
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// keyOf returns the key type and size of a public key.
+func keyOf(publicKey any) (string, int) {
+	switch k := publicKey.(type) {
+	case *rsa.PublicKey:
+		return createKeyRSA, k.N.BitLen()
+	case *ecdsa.PublicKey:
+		return createKeyECDSA, k.Curve.Params().BitSize
+	case ed25519.PublicKey:
+		return createKeyEd25519, 0
+	}
+	return "", 0
+}
+
+func createRenewExample() string {
+	return `
+# Reissue the certificate or token of a user created by kubecm
+kubecm create renew test --context-name kind-kind
+# Reissue a ServiceAccount token valid for 30 days
+kubecm create renew test --context-name kind-kind --duration 720h
+`
+}
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestCreateOptionsRenew(t *testing.T) {
+	clientset := fake.NewSimpleClientset(createdObjects(t, time.Now().Add(time.Hour))...)
+	expires := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	clientset.PrependReactor("create", "serviceaccounts", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "token" {
+			return false, nil, nil
+		}
+		tr := action.(k8stesting.CreateAction).GetObject().(*authenticationv1.TokenRequest)
+		tr.Status.Token = "renewed"
+		tr.Status.ExpirationTimestamp = metav1.NewTime(expires)
+		return true, tr, nil
+	})
+	ctx := context.Background()
+
+	co := &CreateOptions{clientSet: clientset, userName: "bob"}
+	authInfo, err := co.renew(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "renewed", authInfo.Token)
+	assert.Equal(t, "ci", co.namespace)
+	sa, err := clientset.CoreV1().ServiceAccounts("ci").Get(ctx, "bob", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, expires.Format(time.RFC3339), sa.Annotations[createTokenExpiryAnnotation])
+
+	_, err = (&CreateOptions{clientSet: clientset, userName: "alice", duration: time.Hour}).renew(ctx)
+	assert.EqualError(t, err, "--duration is only supported for ServiceAccount users, alice uses a certificate")
+
+	_, err = (&CreateOptions{clientSet: clientset, userName: "carol"}).renew(ctx)
+	assert.EqualError(t, err, `no user "carol" created by kubecm found`)
+}
+
+// signCSRs makes the fake cluster sign CSRs with a certificate expiring at
+// expires, or fail them when expires is zero.
+func signCSRs(t *testing.T, clientset *fake.Clientset, expires time.Time) {
+	clientset.PrependReactor("create", "certificatesigningrequests", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		csr := action.(k8stesting.CreateAction).GetObject().(*certificatesv1.CertificateSigningRequest)
+		if expires.IsZero() {
+			csr.Status.Conditions = []certificatesv1.CertificateSigningRequestCondition{{Type: certificatesv1.CertificateFailed, Message: "denied"}}
+		} else {
+			csr.Status.Certificate = testCertificate(t, expires)
+		}
+		return false, nil, nil
+	})
+}
+
+func TestCreateOptionsRenewCertificate(t *testing.T) {
+	ctx := context.Background()
+	expires := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC).Truncate(time.Second)
+
+	// the CSR of alice was garbage collected, the bindings are enough
+	clientset := fake.NewSimpleClientset(createdObjects(t, time.Now().Add(time.Hour))...)
+	require.NoError(t, clientset.CertificatesV1().CertificateSigningRequests().Delete(ctx, "alice", metav1.DeleteOptions{}))
+	signCSRs(t, clientset, expires)
+	co := &CreateOptions{clientSet: clientset, userName: "alice", wait: time.Second}
+	authInfo, err := co.renew(ctx)
+	require.NoError(t, err)
+	assert.NotEmpty(t, authInfo.ClientCertificateData)
+	assert.Equal(t, "dev", co.namespace)
+
+	csrs, err := clientset.CertificatesV1().CertificateSigningRequests().List(ctx, metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, csrs.Items, 1)
+	block, _ := pem.Decode(csrs.Items[0].Spec.Request)
+	request, err := x509.ParseCertificateRequest(block.Bytes)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"dev", "ops"}, request.Subject.Organization)
+	keyType, keySize := keyOf(request.PublicKey)
+	assert.Equal(t, createKeyECDSA, keyType)
+	assert.Equal(t, 384, keySize)
+	crb, err := clientset.RbacV1().ClusterRoleBindings().Get(ctx, "alice-view", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, expires.Format(time.RFC3339), crb.Annotations[createCertificateExpiryAnnotation])
+
+	// a failed renewal keeps the previous CSR
+	clientset = fake.NewSimpleClientset(createdObjects(t, time.Now().Add(time.Hour))...)
+	signCSRs(t, clientset, time.Time{})
+	_, err = (&CreateOptions{clientSet: clientset, userName: "alice", wait: time.Second}).renew(ctx)
+	require.Error(t, err)
+	_, err = clientset.CertificatesV1().CertificateSigningRequests().Get(ctx, "alice", metav1.GetOptions{})
+	assert.NoError(t, err, "previous CSR must be kept")
+}
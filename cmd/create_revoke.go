@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// CreateRevokeCommand revoke subcommand for create command
+type CreateRevokeCommand struct {
+	BaseCommand
+}
+
+// Init CreateRevokeCommand
+func (cr *CreateRevokeCommand) Init() {
+	cr.command = &cobra.Command{
+		Use:   "revoke <user>",
+		Short: "Revoke a user created by kubecm",
+		Long: `Revoke a user created by kubecm create, deleting its bindings, ServiceAccount and CSR
+
+Client certificates cannot be revoked in Kubernetes: they stay valid until they expire, but no longer grant any permission.
+`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cr.runRevoke(cmd, args)
+		},
+		Example: createRevokeExample(),
+	}
+	cr.command.Flags().BoolP("yes", "y", false, "Skip confirmation prompt")
+}
+
+func (cr *CreateRevokeCommand) runRevoke(cmd *cobra.Command, args []string) error {
+	contextName, _ := cr.command.Flags().GetString("context-name")
+	yes, _ := cr.command.Flags().GetBool("yes")
+	co, err := newCreateOptions(contextName)
+	if err != nil {
+		return err
+	}
+	if !yes {
+		if !strings.EqualFold(BoolUI(fmt.Sprintf("Are you sure you want to revoke user %s in %s?", args[0], co.contextName)), "True") {
+			return errors.New("revoke operation cancelled")
+		}
+	}
+	deleted, err := revokeCreatedUser(cmd.Context(), co.clientSet, args[0])
+	for _, object := range deleted {
+		printString(cmd.OutOrStdout(), object)
+		fmt.Fprintln(cmd.OutOrStdout(), " : delete success")
+	}
+	return err
+}
+
+// revokeCreatedUser deletes the objects kubecm create made for user,
+// bindings first so that its permissions go away even if a later deletion
+// fails. It returns the deleted objects.
+func revokeCreatedUser(ctx context.Context, clientSet kubernetes.Interface, user string) ([]string, error) {
+	opts := metav1.ListOptions{LabelSelector: createSelector(user)}
+	var deleted []string
+
+	rbs, err := clientSet.RbacV1().RoleBindings(metav1.NamespaceAll).List(ctx, opts)
+	if err != nil {
+		return deleted, err
+	}
+	for _, rb := range rbs.Items {
+		if err := clientSet.RbacV1().RoleBindings(rb.Namespace).Delete(ctx, rb.Name, metav1.DeleteOptions{}); err != nil {
+			return deleted, err
+		}
+		deleted = append(deleted, "RoleBinding "+rb.Namespace+"/"+rb.Name)
+	}
+	crbs, err := clientSet.RbacV1().ClusterRoleBindings().List(ctx, opts)
+	if err != nil {
+		return deleted, err
+	}
+	for _, crb := range crbs.Items {
+		if err := clientSet.RbacV1().ClusterRoleBindings().Delete(ctx, crb.Name, metav1.DeleteOptions{}); err != nil {
+			return deleted, err
+		}
+		deleted = append(deleted, "ClusterRoleBinding "+crb.Name)
+	}
+	sas, err := clientSet.CoreV1().ServiceAccounts(metav1.NamespaceAll).List(ctx, opts)
+	if err != nil {
+		return deleted, err
+	}
+	for _, sa := range sas.Items {
+		if err := clientSet.CoreV1().ServiceAccounts(sa.Namespace).Delete(ctx, sa.Name, metav1.DeleteOptions{}); err != nil {
+			return deleted, err
+		}
+		deleted = append(deleted, "ServiceAccount "+sa.Namespace+"/"+sa.Name)
+	}
+	csrs, err := clientSet.CertificatesV1().CertificateSigningRequests().List(ctx, opts)
+	if err != nil {
+		return deleted, err
+	}
+	for _, csr := range csrs.Items {
+		if err := clientSet.CertificatesV1().CertificateSigningRequests().Delete(ctx, csr.Name, metav1.DeleteOptions{}); err != nil {
+			return deleted, err
+		}
+		deleted = append(deleted, "CSR "+csr.Name)
+	}
+	if len(deleted) == 0 {
+		return nil, fmt.Errorf("no user %q created by kubecm found", user)
+	}
+	return deleted, nil
+}
+
+func createRevokeExample() string {
+	return `
+# Revoke a user created by kubecm
+kubecm create revoke test --context-name kind-kind
+`
+}
@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestRevokeCreatedUser(t *testing.T) {
+	clientset := fake.NewSimpleClientset(createdObjects(t, time.Now().Add(time.Hour))...)
+	ctx := context.Background()
+
+	deleted, err := revokeCreatedUser(ctx, clientset, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"RoleBinding dev/alice-view", "ClusterRoleBinding alice-view", "CSR alice"}, deleted)
+
+	users, err := listCreatedUsers(ctx, clientset)
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	assert.Equal(t, "bob", users[0].name)
+	_, err = clientset.RbacV1().RoleBindings("dev").Get(ctx, "other", metav1.GetOptions{})
+	assert.NoError(t, err, "unrelated binding must be kept")
+
+	_, err = revokeCreatedUser(ctx, clientset, "alice")
+	assert.EqualError(t, err, `no user "alice" created by kubecm found`)
+}
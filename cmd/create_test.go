@@ -2,11 +2,21 @@ package cmd
 
 import (
 	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
 	"testing"
+	"time"
 
+	authenticationv1 "k8s.io/api/authentication/v1"
+	certificatesv1 "k8s.io/api/certificates/v1"
 	rbacV1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
 )
 
 func TestCreateRoleBinding(t *testing.T) {
@@ -47,3 +57,337 @@ func TestCreateRoleBinding(t *testing.T) {
 		t.Errorf("Unexpected subjects: got %v, want %v", rb.Subjects, []rbacV1.Subject{{Name: co.userName}})
 	}
 }
+
+func TestServiceAccountAuthInfo(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	var expiration int64
+	clientset.PrependReactor("create", "serviceaccounts", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "token" {
+			return false, nil, nil
+		}
+		tr := action.(k8stesting.CreateAction).GetObject().(*authenticationv1.TokenRequest)
+		expiration = *tr.Spec.ExpirationSeconds
+		tr.Status.Token = "bound-token"
+		return true, tr, nil
+	})
+	co := &CreateOptions{
+		clientSet: clientset,
+		userName:  "test-user",
+		namespace: "test-namespace",
+		method:    createMethodServiceAccount,
+		duration:  24 * time.Hour,
+	}
+
+	authInfo, err := co.serviceAccountAuthInfo()
+	if err != nil {
+		t.Fatalf("serviceAccountAuthInfo() error = %v", err)
+	}
+	if authInfo.Token != "bound-token" {
+		t.Errorf("Unexpected token: got %v", authInfo.Token)
+	}
+	if expiration != 86400 {
+		t.Errorf("Unexpected expiration: got %v, want 86400", expiration)
+	}
+	if _, err := clientset.CoreV1().ServiceAccounts(co.namespace).Get(context.TODO(), co.userName, metav1.GetOptions{}); err != nil {
+		t.Fatalf("Failed to get service account: %v", err)
+	}
+	// an existing ServiceAccount is reused
+	if _, err := co.serviceAccountAuthInfo(); err != nil {
+		t.Fatalf("serviceAccountAuthInfo() error = %v", err)
+	}
+
+	co.role = "view"
+	if err := co.createRoleBinding(); err != nil {
+		t.Fatalf("createRoleBinding() error = %v", err)
+	}
+	rb, err := clientset.RbacV1().RoleBindings(co.namespace).Get(context.TODO(), "test-user-view", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get role binding: %v", err)
+	}
+	want := rbacV1.Subject{Kind: "ServiceAccount", Name: co.userName, Namespace: co.namespace}
+	if len(rb.Subjects) != 1 || rb.Subjects[0] != want {
+		t.Errorf("Unexpected subjects: got %v, want %v", rb.Subjects, want)
+	}
+}
+
+func TestCertificateAuthInfoUnsupported(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	// a signer that refuses the request, as on clusters not honouring
+	// kubernetes.io/kube-apiserver-client
+	clientset.PrependReactor("get", "certificatesigningrequests", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, &certificatesv1.CertificateSigningRequest{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-user"},
+			Status: certificatesv1.CertificateSigningRequestStatus{
+				Conditions: []certificatesv1.CertificateSigningRequestCondition{
+					{Type: certificatesv1.CertificateApproved},
+					{Type: certificatesv1.CertificateFailed, Message: "signer not supported"},
+				},
+			},
+		}, nil
+	})
+	co := &CreateOptions{clientSet: clientset, userName: "test-user"}
+
+	_, err := co.certificateAuthInfo()
+	if !errors.Is(err, errCSRUnsupported) {
+		t.Fatalf("certificateAuthInfo() error = %v, want errCSRUnsupported", err)
+	}
+	list, _ := clientset.CertificatesV1().CertificateSigningRequests().List(context.TODO(), metav1.ListOptions{})
+	if len(list.Items) != 0 {
+		t.Errorf("CSR not cleaned up: %v", list.Items)
+	}
+}
+
+func TestAuthInfoFallback(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	clientset.PrependReactor("get", "certificatesigningrequests", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, &certificatesv1.CertificateSigningRequest{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-user"},
+			Status: certificatesv1.CertificateSigningRequestStatus{
+				Conditions: []certificatesv1.CertificateSigningRequestCondition{
+					{Type: certificatesv1.CertificateApproved},
+					{Type: certificatesv1.CertificateFailed, Message: "signer not supported"},
+				},
+			},
+		}, nil
+	})
+	clientset.PrependReactor("create", "serviceaccounts", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "token" {
+			return false, nil, nil
+		}
+		tr := action.(k8stesting.CreateAction).GetObject().(*authenticationv1.TokenRequest)
+		tr.Status.Token = "bound-token"
+		return true, tr, nil
+	})
+	co := &CreateOptions{clientSet: clientset, userName: "test-user", namespace: "test-namespace", wait: time.Second, method: createMethodCSR, methodProbed: true}
+
+	// declining the new subject creates no ServiceAccount
+	if _, err := co.authInfo(func() bool { return false }); !errors.Is(err, errCreateCancelled) {
+		t.Fatalf("authInfo() error = %v, want errCreateCancelled", err)
+	}
+	if _, err := clientset.CoreV1().ServiceAccounts(co.namespace).Get(context.TODO(), co.userName, metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Errorf("ServiceAccount created before the confirmation: %v", err)
+	}
+
+	co.method = createMethodCSR
+	if _, err := co.authInfo(func() bool { return true }); err != nil {
+		t.Fatalf("authInfo() error = %v", err)
+	}
+	// the preview shows the subject of the method actually used
+	if co.method != createMethodServiceAccount || co.subject().Kind != "ServiceAccount" {
+		t.Errorf("method = %q, subject = %v", co.method, co.subject())
+	}
+
+	// a method given with --method never falls back
+	co = &CreateOptions{clientSet: clientset, userName: "test-user", namespace: "test-namespace", wait: time.Second, method: createMethodCSR}
+	if _, err := co.authInfo(func() bool { return true }); !errors.Is(err, errCSRUnsupported) {
+		t.Errorf("authInfo() error = %v, want errCSRUnsupported", err)
+	}
+}
+
+func TestResolveMethod(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	co := &CreateOptions{clientSet: clientset}
+	if err := co.resolveMethod(); err != nil || co.method != createMethodServiceAccount || !co.methodProbed {
+		t.Errorf("resolveMethod() without the CSR API: method = %q, err = %v", co.method, err)
+	}
+
+	clientset.Resources = []*metav1.APIResourceList{{
+		GroupVersion: certificatesv1.SchemeGroupVersion.String(),
+		APIResources: []metav1.APIResource{{Name: "certificatesigningrequests"}},
+	}}
+	co = &CreateOptions{clientSet: clientset}
+	if err := co.resolveMethod(); err != nil || co.method != createMethodCSR {
+		t.Errorf("resolveMethod() with the CSR API: method = %q, err = %v", co.method, err)
+	}
+	if list, _ := clientset.CertificatesV1().CertificateSigningRequests().List(context.TODO(), metav1.ListOptions{}); len(list.Items) != 0 {
+		t.Errorf("resolveMethod() created CSRs: %v", list.Items)
+	}
+
+	co = &CreateOptions{clientSet: fake.NewSimpleClientset(), method: createMethodCSR}
+	if err := co.resolveMethod(); err != nil || co.method != createMethodCSR || co.methodProbed {
+		t.Errorf("resolveMethod() with --method csr: method = %q, err = %v", co.method, err)
+	}
+}
+
+func TestCreateRoleBindingScopes(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	co := &CreateOptions{
+		clientSet:  clientset,
+		userName:   "test-user",
+		role:       "deployer",
+		roleKind:   "Role",
+		namespace:  "dev",
+		namespaces: []string{"dev", "staging"},
+	}
+	if err := co.createRoleBinding(); err != nil {
+		t.Fatalf("createRoleBinding() error = %v", err)
+	}
+	for _, ns := range co.namespaces {
+		rb, err := clientset.RbacV1().RoleBindings(ns).Get(context.TODO(), "test-user-deployer", metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("Failed to get role binding in %s: %v", ns, err)
+		}
+		if rb.RoleRef.Kind != "Role" {
+			t.Errorf("Unexpected role ref kind: got %v, want Role", rb.RoleRef.Kind)
+		}
+	}
+
+	co = &CreateOptions{
+		clientSet:   clientset,
+		userName:    "test-user",
+		role:        "view",
+		namespace:   "default",
+		clusterWide: true,
+	}
+	if err := co.createRoleBinding(); err != nil {
+		t.Fatalf("createRoleBinding() error = %v", err)
+	}
+	crb, err := clientset.RbacV1().ClusterRoleBindings().Get(context.TODO(), "test-user-view", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get cluster role binding: %v", err)
+	}
+	if crb.RoleRef.Kind != "ClusterRole" || crb.Subjects[0].Kind != "User" {
+		t.Errorf("Unexpected cluster role binding: %v", crb)
+	}
+	bindings, _ := clientset.RbacV1().RoleBindings("default").List(context.TODO(), metav1.ListOptions{})
+	if len(bindings.Items) != 0 {
+		t.Errorf("Unexpected role bindings with --cluster-wide: %v", bindings.Items)
+	}
+}
+
+func TestCreateCSRGroups(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	co := &CreateOptions{clientSet: clientset, userName: "test-user", groups: []string{"dev", "ops"}}
+	pemCSR, _, err := co.createCSR()
+	if err != nil {
+		t.Fatalf("createCSR() error = %v", err)
+	}
+	block, _ := pem.Decode(pemCSR)
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		t.Fatalf("ParseCertificateRequest() error = %v", err)
+	}
+	if got := csr.Subject.Organization; len(got) != 2 || got[0] != "dev" || got[1] != "ops" {
+		t.Errorf("Unexpected organizations: got %v, want [dev ops]", got)
+	}
+	if got := (&CreateOptions{}).organizations(); len(got) != 1 || got[0] != "kubecm" {
+		t.Errorf("Unexpected default organizations: got %v", got)
+	}
+}
+
+func TestGenerateKey(t *testing.T) {
+	tests := []struct {
+		keyType string
+		keySize int
+		pemType string
+		wantErr bool
+	}{
+		{keyType: "", pemType: "RSA PRIVATE KEY"},
+		{keyType: createKeyRSA, keySize: 3072, pemType: "RSA PRIVATE KEY"},
+		{keyType: createKeyECDSA, pemType: "EC PRIVATE KEY"},
+		{keyType: createKeyECDSA, keySize: 384, pemType: "EC PRIVATE KEY"},
+		{keyType: createKeyEd25519, pemType: "PRIVATE KEY"},
+		{keyType: createKeyRSA, keySize: 1024, wantErr: true},
+		{keyType: createKeyECDSA, keySize: 2048, wantErr: true},
+		{keyType: createKeyEd25519, keySize: 256, wantErr: true},
+		{keyType: "dsa", wantErr: true},
+	}
+	for _, tt := range tests {
+		key, err := generateKey(tt.keyType, tt.keySize)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("generateKey(%q, %d) error = %v, wantErr %v", tt.keyType, tt.keySize, err, tt.wantErr)
+		}
+		if tt.wantErr {
+			continue
+		}
+		data, err := encodePrivateKey(key)
+		if err != nil {
+			t.Fatalf("encodePrivateKey() error = %v", err)
+		}
+		block, _ := pem.Decode(data)
+		if block == nil || block.Type != tt.pemType {
+			t.Errorf("generateKey(%q, %d) encoded as %v, want %s", tt.keyType, tt.keySize, block, tt.pemType)
+		}
+		if keyType, keySize := keyOf(key.Public()); tt.keyType != "" && (keyType != tt.keyType || (tt.keySize != 0 && keySize != tt.keySize)) {
+			t.Errorf("keyOf() = %s %d, want %s %d", keyType, keySize, tt.keyType, tt.keySize)
+		}
+	}
+}
+
+func TestCreateCSRExpiration(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	co := &CreateOptions{clientSet: clientset, userName: "test-user", keyType: createKeyEd25519, expiration: 48 * time.Hour}
+	if _, _, err := co.createCSR(); err != nil {
+		t.Fatalf("createCSR() error = %v", err)
+	}
+	csr, err := clientset.CertificatesV1().CertificateSigningRequests().Get(context.TODO(), "test-user", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get CSR: %v", err)
+	}
+	if csr.Spec.ExpirationSeconds == nil || *csr.Spec.ExpirationSeconds != 172800 {
+		t.Errorf("Unexpected expirationSeconds: %v", csr.Spec.ExpirationSeconds)
+	}
+	for _, usage := range csr.Spec.Usages {
+		if usage == certificatesv1.UsageKeyEncipherment {
+			t.Errorf("key encipherment requested for an ed25519 key")
+		}
+	}
+}
+
+func TestWaitCertificate(t *testing.T) {
+	clientset := fake.NewSimpleClientset(&certificatesv1.CertificateSigningRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-user"},
+	})
+	watching := make(chan struct{})
+	clientset.PrependWatchReactor("certificatesigningrequests", func(action k8stesting.Action) (bool, watch.Interface, error) {
+		defer close(watching)
+		w, err := clientset.Tracker().Watch(action.GetResource(), "")
+		return true, w, err
+	})
+	go func() {
+		<-watching
+		csr, _ := clientset.CertificatesV1().CertificateSigningRequests().Get(context.TODO(), "test-user", metav1.GetOptions{})
+		csr.Status.Certificate = []byte("signed")
+		_, _ = clientset.CertificatesV1().CertificateSigningRequests().UpdateStatus(context.TODO(), csr, metav1.UpdateOptions{})
+	}()
+
+	co := &CreateOptions{clientSet: clientset, userName: "test-user", wait: 5 * time.Second}
+	certData, err := co.waitCertificate()
+	if err != nil {
+		t.Fatalf("waitCertificate() error = %v", err)
+	}
+	if string(certData) != "signed" {
+		t.Errorf("Unexpected certificate: %q", certData)
+	}
+
+	co = &CreateOptions{clientSet: fake.NewSimpleClientset(&certificatesv1.CertificateSigningRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-user"},
+	}), userName: "test-user", wait: 50 * time.Millisecond}
+	if _, err := co.waitCertificate(); !errors.Is(err, errCSRUnsupported) {
+		t.Errorf("waitCertificate() error = %v, want errCSRUnsupported", err)
+	}
+}
+
+func TestCreateNonInteractive(t *testing.T) {
+	nonInteractive = true
+	defer func() { nonInteractive = false }()
+
+	co := &CreateOptions{clientSet: fake.NewSimpleClientset()}
+	if err := co.chooseContext(); err == nil || err.Error() != "--context-name is required in non-interactive mode" {
+		t.Errorf("chooseContext() error = %v", err)
+	}
+	if err := co.chooseNamespace(); err == nil || err.Error() != "--namespace, --namespaces or --cluster-wide is required in non-interactive mode" {
+		t.Errorf("chooseNamespace() error = %v", err)
+	}
+	if err := co.selectClusterRole(); err == nil || err.Error() != "--cluster-role or --role is required in non-interactive mode" {
+		t.Errorf("selectClusterRole() error = %v", err)
+	}
+
+	ce := &CreateCommand{}
+	ce.Init()
+	// Init resets the variable behind --non-interactive
+	nonInteractive = true
+	if err := ce.runCreate(ce.command, nil); err == nil || err.Error() != "--user is required in non-interactive mode" {
+		t.Errorf("runCreate() error = %v", err)
+	}
+}
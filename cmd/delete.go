@@ -3,7 +3,7 @@ package cmd
 import (
 	"errors"
 	"fmt"
-	"slices"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"k8s.io/client-go/tools/clientcmd"
@@ -27,11 +27,20 @@ func (dc *DeleteCommand) Init() {
 		},
 		Example: deleteExample(),
 	}
+	addSelectorFlag(dc.command.Flags())
+	dc.command.Flags().BoolP("yes", "y", false, "Skip confirmation prompt")
 	dc.AddCommands(&RangeCommand{})
 	dc.AddCommands(&DocsCommand{})
 }
 
 func (dc *DeleteCommand) runDelete(command *cobra.Command, args []string) error {
+	sel, err := selectorFlag(command.Flags())
+	if err != nil {
+		return err
+	}
+	if len(sel) > 0 && len(args) > 0 {
+		return errors.New("context names cannot be used with --selector")
+	}
 	kubeconfig, err := SelectKubeconfigFile("Select The Kubeconfig file To Delete From")
 	if err != nil {
 		return err
@@ -40,7 +49,24 @@ func (dc *DeleteCommand) runDelete(command *cobra.Command, args []string) error
 	if err != nil {
 		return err
 	}
-	if len(args) == 0 {
+	if len(sel) > 0 {
+		yes, _ := command.Flags().GetBool("yes")
+		needDeleteContexts := sel.selectContexts(config)
+		if len(needDeleteContexts) == 0 {
+			return errors.New("no contexts matched the selector")
+		}
+		fmt.Printf("Found %d contexts matching the selector:\n", len(needDeleteContexts))
+		for _, ctx := range needDeleteContexts {
+			fmt.Printf("  - %s\n", ctx)
+		}
+		if !yes && !strings.EqualFold(BoolUI(fmt.Sprintf("Are you sure you want to delete these %d contexts?", len(needDeleteContexts))), "True") {
+			return errors.New("nothing deleted！")
+		}
+		err = deleteContext(needDeleteContexts, config)
+		if err != nil {
+			return err
+		}
+	} else if len(args) == 0 {
 		confirm, kubeName, err := selectDeleteContext(config)
 		if err != nil {
 			return err
@@ -111,17 +137,8 @@ func checkClusterAndUserNameExceptContextToDelete(oldConfig *clientcmdapi.Config
 }
 
 func selectDeleteContext(config *clientcmdapi.Config) (string, string, error) {
-	var kubeItems []Needle
-	for key, obj := range config.Contexts {
-		if key != config.CurrentContext {
-			kubeItems = append(kubeItems, Needle{Name: key, Cluster: obj.Cluster, User: obj.AuthInfo})
-		} else {
-			kubeItems = append([]Needle{{Name: key, Cluster: obj.Cluster, User: obj.AuthInfo, Center: "(*)"}}, kubeItems...)
-		}
-	}
-	slices.SortFunc(kubeItems, compareKubeItems)
 	// exit option
-	kubeItems, err := ExitOption(kubeItems)
+	kubeItems, err := ExitOption(contextNeedles(config, nil))
 	if err != nil {
 		return "", "", err
 	}
@@ -139,5 +156,9 @@ kubecm delete
 kubecm delete my-context
 # Deleting multiple contexts
 kubecm delete my-context1 my-context2
+# Delete the contexts of a cluster, without confirmation
+kubecm delete -l cluster=old-cluster -y
+# Delete the test contexts pointing to a local server
+kubecm delete -l 'test-*' -l 'server~^https://(127\.0\.0\.1|localhost)'
 `
 }
@@ -22,22 +22,34 @@ func (rc *RangeCommand) Init() {
 	rc.command = &cobra.Command{
 		Use:   "range",
 		Short: "Delete contexts matching a pattern",
-		Long:  `Delete all contexts that match a specified pattern from the kubeconfig`,
+		Long:  `Delete all contexts that match a specified pattern, and the --selector terms, from the kubeconfig`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return rc.runRange(cmd, args)
 		},
 		Example: rangeExample(),
 	}
 
-	rc.command.Flags().StringVarP(&rc.matchMode, "mode", "", "prefix", "Match mode: prefix, suffix, or contains")
+	rc.command.Flags().StringVarP(&rc.matchMode, "mode", "", "prefix", "Match mode: prefix, suffix, contains, or regex")
 	rc.command.Flags().BoolVarP(&rc.yes, "yes", "y", false, "Skip confirmation prompt")
+	addSelectorFlag(rc.command.Flags())
 	rc.AddCommands(&DocsCommand{})
 }
 
 func (rc *RangeCommand) runRange(command *cobra.Command, args []string) error {
-	if len(args) == 0 {
+	sel, err := selectorFlag(command.Flags())
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 && len(sel) == 0 {
 		return errors.New("no pattern specified")
 	}
+	if len(args) > 0 {
+		term, err := matchTerm(args[0], rc.matchMode)
+		if err != nil {
+			return err
+		}
+		sel = append(contextSelector{term}, sel...)
+	}
 
 	kubeconfig, err := SelectKubeconfigFile("Select the kubeconfig file to delete from")
 	if err != nil {
@@ -49,17 +61,17 @@ func (rc *RangeCommand) runRange(command *cobra.Command, args []string) error {
 	}
 
 	// Select contexts to delete
-	needDeleteContexts, err := matchContexts(config.Contexts, args[0], rc.matchMode)
-	if err != nil {
-		return err
-	}
-
+	needDeleteContexts := sel.selectContexts(config)
 	if len(needDeleteContexts) == 0 {
 		return errors.New("no contexts matched the specified pattern")
 	}
 
 	// Confirm delete
-	fmt.Printf("Found %d contexts matching %s mode with pattern %q:\n", len(needDeleteContexts), rc.matchMode, args[0])
+	if len(args) > 0 {
+		fmt.Printf("Found %d contexts matching %s mode with pattern %q:\n", len(needDeleteContexts), rc.matchMode, args[0])
+	} else {
+		fmt.Printf("Found %d contexts matching the selector:\n", len(needDeleteContexts))
+	}
 	for _, ctx := range needDeleteContexts {
 		fmt.Printf("  - %s\n", ctx)
 	}
@@ -84,32 +96,11 @@ func (rc *RangeCommand) runRange(command *cobra.Command, args []string) error {
 
 // matchContexts selects contexts that match the given pattern and mode.
 func matchContexts(contexts map[string]*clientcmdapi.Context, pattern, matchMode string) ([]string, error) {
-	if pattern == "" {
-		return nil, errors.New("pattern cannot be empty")
-	}
-
-	validModes := map[string]bool{"prefix": true, "suffix": true, "contains": true}
-	if !validModes[matchMode] {
-		return nil, fmt.Errorf("invalid match mode: %s, must be one of: prefix, suffix, contains", matchMode)
-	}
-
-	var matches []string
-	for contextName := range contexts {
-		var matched bool
-		switch matchMode {
-		case "prefix":
-			matched = strings.HasPrefix(contextName, pattern)
-		case "suffix":
-			matched = strings.HasSuffix(contextName, pattern)
-		case "contains":
-			matched = strings.Contains(contextName, pattern)
-		}
-		if matched {
-			matches = append(matches, contextName)
-		}
+	term, err := matchTerm(pattern, matchMode)
+	if err != nil {
+		return nil, err
 	}
-
-	return matches, nil
+	return contextSelector{term}.selectContexts(&clientcmdapi.Config{Contexts: contexts}), nil
 }
 
 // rangeDeleteContexts deletes the specified contexts and their associated clusters and auth infos if not used elsewhere.
@@ -149,6 +140,12 @@ kubecm delete range --mode suffix prod
 # Delete all contexts containing "staging"
 kubecm delete range --mode contains staging
 
+# Delete all contexts matching a regular expression
+kubecm delete range --mode regex '^(dev|test)-'
+
+# Delete the contexts with prefix "dev-" of the kind clusters
+kubecm delete range dev- -l 'server=https://127.0.0.1:*'
+
 # Force delete all contexts with prefix "dev-" (skip confirmation)
 kubecm delete range dev- -y
 `
@@ -64,7 +64,22 @@ func TestMatchContexts(t *testing.T) {
 			pattern:       "dev-",
 			matchMode:     "invalid",
 			expected:      nil,
-			expectedError: fmt.Errorf("invalid match mode: %s, must be one of: prefix, suffix, contains", "invalid"),
+			expectedError: fmt.Errorf("invalid match mode: %s, must be one of: prefix, suffix, contains, regex", "invalid"),
+		},
+		{
+			name:      "regex dev or staging",
+			contexts:  createTestContexts(),
+			pattern:   `^dev-cluster[12]$|staging$`,
+			matchMode: "regex",
+			expected:  []string{"dev-cluster1", "dev-cluster2", "test-staging"},
+		},
+		{
+			name:          "invalid regex",
+			contexts:      createTestContexts(),
+			pattern:       "dev-(",
+			matchMode:     "regex",
+			expected:      nil,
+			expectedError: errors.New("invalid regex pattern \"dev-(\": error parsing regexp: missing closing ): `dev-(`"),
 		},
 		{
 			name:          "empty pattern",
@@ -3,9 +3,11 @@ package cmd
 import (
 	"errors"
 	"fmt"
-	"slices"
+	"os"
+	"sort"
 
 	"github.com/spf13/cobra"
+	"github.com/sunny0826/kubecm/pkg/bundle"
 	"k8s.io/client-go/tools/clientcmd"
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 )
@@ -29,10 +31,40 @@ func (ec *ExportCommand) Init() {
 	}
 	ec.command.Flags().StringP("file", "f", "", "Path to export kubeconfig files")
 	_ = ec.command.MarkFlagRequired("file")
+	ec.command.Flags().Bool("flatten", false, "embed the referenced certificate and key files as *-data fields")
+	ec.command.Flags().Bool("redact", false, "strip the user credentials, exporting only the cluster endpoints")
+	ec.command.Flags().Bool("minify", false, "export only the current context")
+	ec.command.Flags().String("match", "", "export the contexts matching the pattern argument, one of: prefix, suffix, contains, regex")
+	addSelectorFlag(ec.command.Flags())
+	ec.command.Flags().Bool("encrypt", false, "write an age encrypted bundle, to the --recipient keys or else with a passphrase from $KUBECM_PASSPHRASE or a prompt")
+	ec.command.Flags().StringSlice("recipient", nil, "age public key (age1...) the bundle is encrypted to, can be repeated")
 	ec.AddCommands(&DocsCommand{})
 }
 
 func (ec *ExportCommand) runExport(args []string) error {
+	flatten, _ := ec.command.Flags().GetBool("flatten")
+	redact, _ := ec.command.Flags().GetBool("redact")
+	minify, _ := ec.command.Flags().GetBool("minify")
+	match, _ := ec.command.Flags().GetString("match")
+	encrypt, _ := ec.command.Flags().GetBool("encrypt")
+	recipients, _ := ec.command.Flags().GetStringSlice("recipient")
+	if len(recipients) > 0 && !encrypt {
+		return errors.New("--recipient requires --encrypt")
+	}
+	sel, err := selectorFlag(ec.command.Flags())
+	if err != nil {
+		return err
+	}
+	if minify && (match != "" || len(sel) > 0 || len(args) > 0) {
+		return errors.New("--minify exports the current context, it cannot be used with context names, --match or --selector")
+	}
+	if len(sel) > 0 && (match != "" || len(args) > 0) {
+		return errors.New("--selector cannot be used with context names or --match")
+	}
+	if match != "" && len(args) != 1 {
+		return errors.New("--match requires exactly one pattern argument")
+	}
+
 	kubeconfig, err := SelectKubeconfigFile("Select The Kubeconfig file To Export Context From")
 	if err != nil {
 		return err
@@ -41,6 +73,28 @@ func (ec *ExportCommand) runExport(args []string) error {
 	if err != nil {
 		return err
 	}
+	switch {
+	case minify:
+		if config.CurrentContext == "" {
+			return errors.New("current-context must be set to use --minify")
+		}
+		args = []string{config.CurrentContext}
+	case match != "":
+		matched, err := matchContexts(config.Contexts, args[0], match)
+		if err != nil {
+			return err
+		}
+		if len(matched) == 0 {
+			return errors.New("no contexts matched the specified pattern")
+		}
+		sort.Strings(matched)
+		args = matched
+	case len(sel) > 0:
+		args = sel.selectContexts(config)
+		if len(args) == 0 {
+			return errors.New("no contexts matched the selector")
+		}
+	}
 	if len(args) == 0 {
 		confirm, kubeName, err := selectExportContext(config)
 		if err != nil {
@@ -61,7 +115,14 @@ func (ec *ExportCommand) runExport(args []string) error {
 		}
 	}
 
+	if err := flattenExport(config, flatten, redact, encrypt); err != nil {
+		return err
+	}
+
 	file, _ := ec.command.Flags().GetString("file")
+	if encrypt {
+		return writeBundle(config, file, recipients)
+	}
 	err = clientcmd.WriteToFile(*config, file)
 	if err != nil {
 		return err
@@ -69,14 +130,83 @@ func (ec *ExportCommand) runExport(args []string) error {
 	//}
 	return nil
 }
+
+// writeBundle writes config as an age encrypted bundle, which add decrypts.
+func writeBundle(config *clientcmdapi.Config, file string, recipients []string) error {
+	data, err := clientcmd.Write(*config)
+	if err != nil {
+		return err
+	}
+	var passphrase string
+	if len(recipients) == 0 {
+		passphrase, err = newBundlePassphrase()
+		if err != nil {
+			return err
+		}
+	}
+	encrypted, err := bundle.Encrypt(data, recipients, passphrase)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, encrypted, 0o600)
+}
+
+// newBundlePassphrase returns $KUBECM_PASSPHRASE or asks for a passphrase
+// twice.
+func newBundlePassphrase() (string, error) {
+	if passphrase := os.Getenv(passphraseEnv); passphrase != "" {
+		return passphrase, nil
+	}
+	if err := passphrasePromptable(); err != nil {
+		return "", err
+	}
+	passphrase, err := PassphraseUI("Passphrase")
+	if err != nil {
+		return "", err
+	}
+	confirm, err := PassphraseUI("Confirm passphrase")
+	if err != nil {
+		return "", err
+	}
+	if passphrase != confirm {
+		return "", errors.New("passphrases do not match")
+	}
+	return passphrase, nil
+}
+
+// flattenExport redacts and flattens the exported config as requested. A
+// bundle is opened on another machine, so it carries the referenced files,
+// and so does a redacted export; credentials are redacted first, so that
+// missing client certificate or key files do not matter.
+func flattenExport(config *clientcmdapi.Config, flatten, redact, encrypt bool) error {
+	if redact {
+		redactConfig(config)
+	}
+	if flatten || redact || encrypt {
+		return clientcmdapi.FlattenConfig(config)
+	}
+	return nil
+}
+
+// redactConfig strips the credentials of every user, leaving the contexts
+// and cluster endpoints.
+func redactConfig(config *clientcmdapi.Config) {
+	for name := range config.AuthInfos {
+		config.AuthInfos[name] = clientcmdapi.NewAuthInfo()
+	}
+}
 func exportContext(ctxs []string, config *clientcmdapi.Config) (*clientcmdapi.Config, error) {
 	var notFinds []string
 	exportConfig := clientcmdapi.NewConfig()
 	for _, ctx := range ctxs {
 		if ec, ok := config.Contexts[ctx]; ok {
-			exportConfig.AuthInfos[ec.AuthInfo] = config.AuthInfos[ec.AuthInfo]
-			exportConfig.Clusters[ec.Cluster] = config.Clusters[ec.Cluster]
-			exportConfig.Contexts[ctx] = config.Contexts[ctx]
+			if authInfo, ok := config.AuthInfos[ec.AuthInfo]; ok {
+				exportConfig.AuthInfos[ec.AuthInfo] = authInfo.DeepCopy()
+			}
+			if cluster, ok := config.Clusters[ec.Cluster]; ok {
+				exportConfig.Clusters[ec.Cluster] = cluster.DeepCopy()
+			}
+			exportConfig.Contexts[ctx] = ec.DeepCopy()
 			exportConfig.CurrentContext = ctx
 			fmt.Printf("Context Export:「%s」\n", ctx)
 		} else {
@@ -91,17 +221,8 @@ func exportContext(ctxs []string, config *clientcmdapi.Config) (*clientcmdapi.Co
 }
 
 func selectExportContext(config *clientcmdapi.Config) (string, string, error) {
-	var kubeItems []Needle
-	for key, obj := range config.Contexts {
-		if key != config.CurrentContext {
-			kubeItems = append(kubeItems, Needle{Name: key, Cluster: obj.Cluster, User: obj.AuthInfo})
-		} else {
-			kubeItems = append([]Needle{{Name: key, Cluster: obj.Cluster, User: obj.AuthInfo, Center: "(*)"}}, kubeItems...)
-		}
-	}
-	slices.SortFunc(kubeItems, compareKubeItems)
 	// exit option
-	kubeItems, err := ExitOption(kubeItems)
+	kubeItems, err := ExitOption(contextNeedles(config, nil))
 	if err != nil {
 		return "", "", err
 	}
@@ -117,5 +238,17 @@ func exportExample() string {
 kubecm export -f myconfig.yaml my-context1
 # Export multiple contexts to myconfig.yaml file
 kubecm export -f myconfig.yaml my-context1 my-context2
+# Export the current context with its certificates and keys embedded
+kubecm export -f myconfig.yaml --minify --flatten
+# Export the contexts starting with "dev-", without credentials, to share the cluster endpoints
+kubecm export -f endpoints.yaml --match prefix dev- --redact
+# Export the contexts matching a regular expression
+kubecm export -f myconfig.yaml --match regex '^(dev|test)-.*-eu$'
+# Export the contexts in the prod namespace of the EKS clusters
+kubecm export -f prod.yaml -l 'server=*.eks.amazonaws.com' -l namespace=prod
+# Export a context as an encrypted bundle for a teammate's age public key
+kubecm export -f dev.age --encrypt --recipient age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p my-context1
+# Export a context as a passphrase encrypted bundle
+KUBECM_PASSPHRASE=... kubecm export -f dev.age --encrypt my-context1
 `
 }
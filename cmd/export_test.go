@@ -2,8 +2,13 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"filippo.io/age"
+	"k8s.io/client-go/tools/clientcmd"
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 )
 
@@ -85,3 +90,166 @@ func Test_exportContext(t *testing.T) {
 		})
 	}
 }
+
+func Test_exportContextCopies(t *testing.T) {
+	exportedConfig, err := exportContext([]string{"root-context"}, &exportTestConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	redactConfig(exportedConfig)
+	if exportTestConfig.AuthInfos["black-user"].Token != "black-token" {
+		t.Errorf("redacting the export changed the source config")
+	}
+	if got := exportedConfig.AuthInfos["black-user"]; got.Token != "" {
+		t.Errorf("redactConfig() left token %q", got.Token)
+	}
+	if exportedConfig.Clusters["pig-cluster"].Server != "http://pig.org:8080" {
+		t.Errorf("redactConfig() changed the cluster endpoint")
+	}
+}
+
+func Test_exportFlatten(t *testing.T) {
+	dir := t.TempDir()
+	for name, data := range map[string]string{"ca.crt": "ca-data", "client.crt": "cert-data", "client.key": "key-data"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	kubeconfig := filepath.Join(dir, "config")
+	if err := os.WriteFile(kubeconfig, []byte(`apiVersion: v1
+kind: Config
+clusters:
+- name: c
+  cluster:
+    server: https://c.example.com
+    certificate-authority: ca.crt
+contexts:
+- name: ctx
+  context:
+    cluster: c
+    user: u
+users:
+- name: u
+  user:
+    client-certificate: client.crt
+    client-key: client.key
+`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name                     string
+		flatten, redact, encrypt bool
+		// missingKey points the client key at a file that does not exist
+		missingKey bool
+		wantErr    bool
+		wantCA     string
+		wantCert   string
+	}{
+		{name: "as is"},
+		{name: "flatten", flatten: true, wantCA: "ca-data", wantCert: "cert-data"},
+		{name: "encrypt", encrypt: true, wantCA: "ca-data", wantCert: "cert-data"},
+		{name: "redact", redact: true, wantCA: "ca-data"},
+		{name: "redact a missing client key", redact: true, missingKey: true, wantCA: "ca-data"},
+		{name: "flatten a missing client key", flatten: true, missingKey: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := clientcmd.LoadFromFile(kubeconfig)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.missingKey {
+				config.AuthInfos["u"].ClientKey = filepath.Join(dir, "missing.key")
+			}
+			exportedConfig, err := exportContext([]string{"ctx"}, config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = flattenExport(exportedConfig, tt.flatten, tt.redact, tt.encrypt)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("flattenExport() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			cluster, user := exportedConfig.Clusters["c"], exportedConfig.AuthInfos["u"]
+			if string(cluster.CertificateAuthorityData) != tt.wantCA {
+				t.Errorf("certificate-authority-data = %q, want %q", cluster.CertificateAuthorityData, tt.wantCA)
+			}
+			if string(user.ClientCertificateData) != tt.wantCert {
+				t.Errorf("client-certificate-data = %q, want %q", user.ClientCertificateData, tt.wantCert)
+			}
+			switch {
+			case tt.redact:
+				if user.ClientCertificate != "" || user.ClientKey != "" || len(user.ClientKeyData) != 0 {
+					t.Errorf("redacted user = %+v", user)
+				}
+			case tt.flatten || tt.encrypt:
+				if user.ClientCertificate != "" || cluster.CertificateAuthority != "" || string(user.ClientKeyData) != "key-data" {
+					t.Errorf("flattened user = %+v, cluster = %+v", user, cluster)
+				}
+			default:
+				if user.ClientCertificate != "client.crt" {
+					t.Errorf("client-certificate = %q, want the original reference", user.ClientCertificate)
+				}
+			}
+		})
+	}
+}
+
+func Test_writeBundle(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	identityFile := filepath.Join(dir, "identity.txt")
+	if err := os.WriteFile(identityFile, []byte(identity.String()+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	exportedConfig, err := exportContext([]string{"root-context"}, &exportTestConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recipientBundle := filepath.Join(dir, "recipient.age")
+	if err := writeBundle(exportedConfig, recipientBundle, []string{identity.Recipient().String()}); err != nil {
+		t.Fatalf("writeBundle() error = %v", err)
+	}
+	loaded, err := loadAddFile(recipientBundle, identityFile)
+	if err != nil {
+		t.Fatalf("loadAddFile() error = %v", err)
+	}
+	if loaded.AuthInfos["black-user"].Token != "black-token" {
+		t.Errorf("loadAddFile() = %+v", loaded.AuthInfos)
+	}
+
+	t.Setenv(passphraseEnv, "secret")
+	passphraseBundle := filepath.Join(dir, "passphrase.age")
+	if err := writeBundle(exportedConfig, passphraseBundle, nil); err != nil {
+		t.Fatalf("writeBundle() error = %v", err)
+	}
+	t.Setenv("KUBECM_HOME", dir)
+	loaded, err = loadAddFile(passphraseBundle, "")
+	if err != nil {
+		t.Fatalf("loadAddFile() error = %v", err)
+	}
+	if _, ok := loaded.Contexts["root-context"]; !ok {
+		t.Errorf("loadAddFile() contexts = %v", loaded.Contexts)
+	}
+	if _, err := loadAddFile(passphraseBundle, filepath.Join(dir, "missing.txt")); err == nil {
+		t.Error("loadAddFile() with a missing --identity succeeded")
+	}
+
+	// without $KUBECM_PASSPHRASE, non-interactive runs fail rather than prompt
+	t.Setenv(passphraseEnv, "")
+	nonInteractive = true
+	defer func() { nonInteractive = false }()
+	if _, err := loadAddFile(passphraseBundle, ""); err == nil || !strings.Contains(err.Error(), passphraseEnv) {
+		t.Errorf("loadAddFile() error = %v, want it to mention %s", err, passphraseEnv)
+	}
+	if err := writeBundle(exportedConfig, passphraseBundle, nil); err == nil || !strings.Contains(err.Error(), passphraseEnv) {
+		t.Errorf("writeBundle() error = %v, want it to mention %s", err, passphraseEnv)
+	}
+}
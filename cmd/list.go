@@ -1,13 +1,15 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
-	"strings"
+	"slices"
 
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 
 	"github.com/spf13/cobra"
+	"github.com/sunny0826/kubecm/pkg/metadata"
 	"k8s.io/client-go/tools/clientcmd"
 )
 
@@ -33,10 +35,15 @@ func (lc *ListCommand) Init() {
 	lc.command.DisableFlagsInUseLine = true
 	lc.command.Flags().BoolVar(&lc.shortServer, "short-server", false, "Shorten the server endpoint")
 	lc.command.Flags().BoolVar(&lc.noServer, "no-server", false, "Hide the server column")
+	addSelectorFlag(lc.command.Flags())
 	lc.AddCommands(&DocsCommand{})
 }
 
 func (lc *ListCommand) runList(command *cobra.Command, args []string) error {
+	sel, err := selectorFlag(command.Flags())
+	if err != nil {
+		return err
+	}
 	clusterMessageChan := make(chan *ClusterStatusCheck)
 	go func() {
 		info, _ := ClusterStatus(2)
@@ -54,9 +61,14 @@ func (lc *ListCommand) runList(command *cobra.Command, args []string) error {
 		if err != nil {
 			return err
 		}
+		outConfig, err = filterSelector(sel, outConfig)
+		if err != nil {
+			return err
+		}
 		err = PrintTable(os.Stdout, outConfig, &PrintOption{
 			ShortServer: lc.shortServer,
 			NoServer:    lc.noServer,
+			Metadata:    metadata.HasAny(outConfig),
 		})
 		if err != nil {
 			return err
@@ -79,16 +91,17 @@ func filterArgs(args []string, config *clientcmdapi.Config) (*clientcmdapi.Confi
 	if len(args) == 0 {
 		return config, nil
 	}
-	contextList := make(map[string]string)
-	for key := range config.Contexts {
-		for _, search := range args {
-			if strings.Contains(key, search) {
-				contextList[key] = search
-			}
+	// a context is kept when its name contains any of the args
+	var sels []contextSelector
+	for _, search := range args {
+		term, err := matchTerm(search, "contains")
+		if err != nil {
+			return nil, err
 		}
+		sels = append(sels, contextSelector{term})
 	}
 	for key := range config.Contexts {
-		if _, ok := contextList[key]; !ok {
+		if !slices.ContainsFunc(sels, func(sel contextSelector) bool { return sel.matches(config, key) }) {
 			delete(config.Contexts, key)
 		}
 	}
@@ -98,6 +111,22 @@ func filterArgs(args []string, config *clientcmdapi.Config) (*clientcmdapi.Confi
 	return config, nil
 }
 
+// filterSelector keeps the contexts selected by sel.
+func filterSelector(sel contextSelector, config *clientcmdapi.Config) (*clientcmdapi.Config, error) {
+	if len(sel) == 0 {
+		return config, nil
+	}
+	for key := range config.Contexts {
+		if !sel.matches(config, key) {
+			delete(config.Contexts, key)
+		}
+	}
+	if len(config.Contexts) == 0 {
+		return nil, errors.New("no contexts matched the selector")
+	}
+	return config, nil
+}
+
 func listExample() string {
 	return `
 # List all the contexts in your KubeConfig file
@@ -107,6 +136,13 @@ kubecm ls
 kubecm l
 # Filter out keywords(Multi-keyword support)
 kubecm ls kind k3s
+# List the contexts whose name matches a glob or a regular expression
+kubecm ls -l 'dev-*'
+kubecm ls -l '/^(dev|test)-/'
+# List the contexts of EKS clusters using the default namespace
+kubecm ls -l 'server=*.eks.amazonaws.com' -l namespace=default
+# List the production contexts, by the tier set with kubecm tag
+kubecm ls -l tier=prod
 # Useful environment variables
 KUBECM_DISABLE_K8S_MORE_INFO: it will disable the k8s more info in the output
 `
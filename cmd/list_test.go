@@ -70,3 +70,18 @@ func Test_filterArgs(t *testing.T) {
 		})
 	}
 }
+
+func Test_filterSelector(t *testing.T) {
+	sel, _ := parseSelector([]string{"server~:6443$"})
+	got, err := filterSelector(sel, selectorTestConfig.DeepCopy())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Contexts) != 2 || got.Contexts["dev-kind"] == nil || got.Contexts["test-kind"] == nil {
+		t.Errorf("filterSelector() contexts = %v", got.Contexts)
+	}
+	sel, _ = parseSelector([]string{"staging-*"})
+	if _, err := filterSelector(sel, selectorTestConfig.DeepCopy()); err == nil {
+		t.Error("filterSelector() with no selected context succeeded")
+	}
+}
@@ -22,5 +22,8 @@ func (rc *RegistryCommand) Init() {
 		&RegistrySyncCommand{},
 		&RegistryRemoveCommand{},
 		&RegistryUpdateCommand{},
+		&RegistryInfoCommand{},
+		&RegistryDiffCommand{},
+		&RegistryRolesCommand{},
 	)
 }
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
@@ -23,17 +24,19 @@ func (c *RegistryAddCommand) Init() {
 kubecm registry add --name rubix --url git@bitbucket.org:rubixdig/kubeconfig-registry.git --role devops --var Username=clark.n
 
 # Add a registry (will prompt for required variables)
-kubecm registry add --name rubix --url git@bitbucket.org:rubixdig/kubeconfig-registry.git --role devops`,
+kubecm registry add --name rubix --url git@bitbucket.org:rubixdig/kubeconfig-registry.git --role devops
+
+# Add a registry and pick the role from a list
+kubecm registry add --name rubix --url git@bitbucket.org:rubixdig/kubeconfig-registry.git`,
 		RunE: c.runAdd,
 	}
 	c.command.Flags().String("name", "", "registry name (required)")
 	c.command.Flags().String("url", "", "git repository URL (required)")
-	c.command.Flags().String("role", "", "role to use (required)")
+	c.command.Flags().String("role", "", "role to use (prompted for if omitted)")
 	c.command.Flags().String("ref", "main", "git branch/ref")
 	c.command.Flags().StringSlice("var", nil, "template variables as KEY=VALUE (repeatable)")
 	_ = c.command.MarkFlagRequired("name")
 	_ = c.command.MarkFlagRequired("url")
-	_ = c.command.MarkFlagRequired("role")
 }
 
 func (c *RegistryAddCommand) runAdd(cmd *cobra.Command, args []string) error {
@@ -43,6 +46,10 @@ func (c *RegistryAddCommand) runAdd(cmd *cobra.Command, args []string) error {
 	ref, _ := cmd.Flags().GetString("ref")
 	varSlice, _ := cmd.Flags().GetStringSlice("var")
 
+	if role == "" && !isInteractive() {
+		return errors.New("--role is required in non-interactive mode")
+	}
+
 	// Load config
 	cfg, err := registry.LoadConfig()
 	if err != nil {
@@ -73,6 +80,25 @@ func (c *RegistryAddCommand) runAdd(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	// Pick a role interactively if none was given
+	if role == "" {
+		roles, err := registry.ListRoleSummaries(repoDir)
+		if err != nil {
+			os.RemoveAll(repoDir)
+			return err
+		}
+		if len(roles) == 0 {
+			os.RemoveAll(repoDir)
+			return fmt.Errorf("registry %q has no roles", name)
+		}
+		i, err := selectRoleWithRunner(roles, nil)
+		if err != nil {
+			os.RemoveAll(repoDir)
+			return err
+		}
+		role = roles[i].Name
+	}
+
 	// Validate role exists
 	if _, err := registry.LoadRole(repoDir, role); err != nil {
 		os.RemoveAll(repoDir)
@@ -86,7 +112,14 @@ func (c *RegistryAddCommand) runAdd(cmd *cobra.Command, args []string) error {
 	for _, spec := range meta.Variables {
 		if _, ok := vars[spec.Name]; !ok {
 			if spec.Required {
-				val := PromptUI(fmt.Sprintf("Variable %q (%s)", spec.Name, spec.Description), spec.Default)
+				if !isInteractive() && spec.Default == "" {
+					os.RemoveAll(repoDir)
+					return fmt.Errorf("variable %q is required in non-interactive mode, set it with --var %s=<value>", spec.Name, spec.Name)
+				}
+				val := spec.Default
+				if isInteractive() {
+					val = PromptUI(fmt.Sprintf("Variable %q (%s)", spec.Name, spec.Description), spec.Default)
+				}
 				vars[spec.Name] = val
 			} else if spec.Default != "" {
 				vars[spec.Name] = spec.Default
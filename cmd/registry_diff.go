@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/sunny0826/kubecm/pkg/registry"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// RegistryDiffCommand diff a registry against the kubeconfig
+type RegistryDiffCommand struct {
+	BaseCommand
+}
+
+// Init RegistryDiffCommand
+func (c *RegistryDiffCommand) Init() {
+	c.command = &cobra.Command{
+		Use:   "diff <name>",
+		Short: "Show what a sync would change in the kubeconfig",
+		Long:  "Show a field-level diff between the current kubeconfig and what sync would write",
+		Example: `# Show pending changes of a registry
+kubecm registry diff rubix`,
+		Args: cobra.ExactArgs(1),
+		RunE: c.runDiff,
+	}
+}
+
+func (c *RegistryDiffCommand) runDiff(cmd *cobra.Command, args []string) error {
+	name := args[0]
+
+	cfg, err := registry.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	entry := cfg.GetRegistry(name)
+	if entry == nil {
+		return fmt.Errorf("registry %q not found", name)
+	}
+
+	repoDir, err := registry.RegistryDir(name)
+	if err != nil {
+		return err
+	}
+
+	if err := registry.GitPull(repoDir); err != nil {
+		fmt.Printf("  Warning: git pull failed: %v (using cached copy)\n", err)
+	}
+
+	kubeConfig, err := clientcmd.LoadFromFile(cfgFile)
+	if err != nil {
+		return fmt.Errorf("loading kubeconfig: %w", err)
+	}
+
+	diffs, result, err := registry.Diff(repoDir, entry, kubeConfig)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Diff for registry %q:\n", name)
+	fmt.Print(registry.FormatDiff(diffs))
+
+	// Conflicts and errors are not part of the diff but explain missing entries
+	if len(result.Skipped) > 0 || len(result.Errors) > 0 {
+		fmt.Print(registry.FormatSyncResult(&registry.SyncResult{
+			Skipped: result.Skipped,
+			Errors:  result.Errors,
+		}))
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/bndr/gotabulate"
+	"github.com/spf13/cobra"
+	"github.com/sunny0826/kubecm/pkg/registry"
+)
+
+// RegistryInfoCommand show details of a registry
+type RegistryInfoCommand struct {
+	BaseCommand
+}
+
+// Init RegistryInfoCommand
+func (c *RegistryInfoCommand) Init() {
+	c.command = &cobra.Command{
+		Use:   "info <name>",
+		Short: "Show details of a registry",
+		Long:  "Show the roles, clusters, users and variables of a registry without syncing",
+		Example: `# Show registry details
+kubecm registry info rubix`,
+		Args: cobra.ExactArgs(1),
+		RunE: c.runInfo,
+	}
+}
+
+func (c *RegistryInfoCommand) runInfo(cmd *cobra.Command, args []string) error {
+	name := args[0]
+
+	cfg, err := registry.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	entry := cfg.GetRegistry(name)
+	if entry == nil {
+		return fmt.Errorf("registry %q not found", name)
+	}
+
+	repoDir, err := registry.RegistryDir(name)
+	if err != nil {
+		return err
+	}
+
+	meta, err := registry.LoadRegistryMeta(repoDir)
+	if err != nil {
+		return err
+	}
+
+	commit, err := registry.GitHead(repoDir)
+	if err != nil {
+		commit = "unknown"
+	}
+	lastSync := "never"
+	if entry.LastSync != nil {
+		lastSync = entry.LastSync.Format("2006-01-02 15:04:05")
+	}
+
+	fmt.Printf("Name:         %s\n", entry.Name)
+	if meta.Metadata.Description != "" {
+		fmt.Printf("Description:  %s\n", meta.Metadata.Description)
+	}
+	fmt.Printf("URL:          %s\n", entry.URL)
+	fmt.Printf("Ref:          %s\n", entry.Ref)
+	fmt.Printf("Commit:       %s\n", commit)
+	fmt.Printf("Role:         %s\n", entry.Role)
+	fmt.Printf("Contexts:     %d\n", len(entry.ManagedContexts))
+	fmt.Printf("Last sync:    %s\n", lastSync)
+
+	fmt.Println("\nVariables:")
+	printVariablesTable(meta.Variables, entry.Variables)
+
+	fmt.Println("\nRoles:")
+	roles, err := registry.ListRoleSummaries(repoDir)
+	if err != nil {
+		return err
+	}
+	printRolesTable(roles, entry.Role)
+
+	fmt.Println("\nClusters:")
+	if err := printClustersTable(repoDir); err != nil {
+		return err
+	}
+
+	fmt.Println("\nUsers:")
+	return printUsersTable(repoDir)
+}
+
+// printVariablesTable prints declared variables with their current values.
+// Variables set locally but not declared in registry.yaml are listed too.
+func printVariablesTable(specs []registry.VariableSpec, values map[string]string) {
+	var table [][]string
+	declared := make(map[string]bool)
+	for _, spec := range specs {
+		declared[spec.Name] = true
+		value, ok := values[spec.Name]
+		if !ok {
+			value = "<unset>"
+		}
+		table = append(table, []string{spec.Name, value, fmt.Sprintf("%t", spec.Required), spec.Description})
+	}
+	var extra []string
+	for k := range values {
+		if !declared[k] {
+			extra = append(extra, k)
+		}
+	}
+	sort.Strings(extra)
+	for _, k := range extra {
+		table = append(table, []string{k, values[k], "false", "(not declared in registry.yaml)"})
+	}
+	printRegistryTable([]string{"NAME", "VALUE", "REQUIRED", "DESCRIPTION"}, table)
+}
+
+func printClustersTable(repoDir string) error {
+	clusters, err := registry.ListClusters(repoDir)
+	if err != nil {
+		return err
+	}
+	var table [][]string
+	for _, name := range clusters {
+		cl, err := registry.LoadCluster(repoDir, name)
+		if err != nil {
+			return err
+		}
+		table = append(table, []string{name, cl.Provider, cl.Metadata.Description})
+	}
+	printRegistryTable([]string{"NAME", "PROVIDER", "DESCRIPTION"}, table)
+	return nil
+}
+
+func printUsersTable(repoDir string) error {
+	users, err := registry.ListUsers(repoDir)
+	if err != nil {
+		return err
+	}
+	var table [][]string
+	for _, name := range users {
+		u, err := registry.LoadUser(repoDir, name)
+		if err != nil {
+			return err
+		}
+		provider := u.Provider
+		if u.OIDC != nil && provider == "" {
+			provider = "oidc"
+		}
+		table = append(table, []string{name, provider, u.Metadata.Description})
+	}
+	printRegistryTable([]string{"NAME", "PROVIDER", "DESCRIPTION"}, table)
+	return nil
+}
+
+// printRegistryTable renders a table in the style of `registry list`.
+func printRegistryTable(headers []string, table [][]string) {
+	if len(table) == 0 {
+		fmt.Println("  (none)")
+		return
+	}
+	tabulate := gotabulate.Create(table)
+	tabulate.SetHeaders(headers)
+	tabulate.SetWrapStrings(false)
+	tabulate.SetAlign("left")
+	fmt.Fprintln(os.Stdout, tabulate.Render("grid", "left"))
+}
@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/manifoldco/promptui"
+	"github.com/spf13/cobra"
+	"github.com/sunny0826/kubecm/pkg/registry"
+)
+
+// RegistryRolesCommand list the roles of a registry
+type RegistryRolesCommand struct {
+	BaseCommand
+}
+
+// Init RegistryRolesCommand
+func (c *RegistryRolesCommand) Init() {
+	c.command = &cobra.Command{
+		Use:   "roles <url|name>",
+		Short: "List the roles offered by a registry",
+		Long:  "List the roles of a configured registry or of a registry URL before joining it",
+		Example: `# Roles of a configured registry
+kubecm registry roles rubix
+
+# Roles of a registry you have not added yet
+kubecm registry roles git@bitbucket.org:rubixdig/kubeconfig-registry.git`,
+		Args: cobra.ExactArgs(1),
+		RunE: c.runRoles,
+	}
+	c.command.Flags().String("ref", "main", "git branch/ref, when a URL is given")
+}
+
+func (c *RegistryRolesCommand) runRoles(cmd *cobra.Command, args []string) error {
+	ref, _ := cmd.Flags().GetString("ref")
+
+	cfg, err := registry.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	current := ""
+	var repoDir string
+	if entry := cfg.GetRegistry(args[0]); entry != nil {
+		current = entry.Role
+		repoDir, err = registry.RegistryDir(entry.Name)
+		if err != nil {
+			return err
+		}
+	} else {
+		tmpDir, err := os.MkdirTemp("", "kubecm-registry-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(tmpDir)
+
+		repoDir = tmpDir
+		fmt.Printf("Cloning %s...\n", args[0])
+		if err := registry.GitClone(args[0], ref, repoDir); err != nil {
+			return err
+		}
+	}
+
+	roles, err := registry.ListRoleSummaries(repoDir)
+	if err != nil {
+		return err
+	}
+	printRolesTable(roles, current)
+	return nil
+}
+
+// printRolesTable prints role summaries, marking the current role.
+func printRolesTable(roles []registry.RoleSummary, current string) {
+	var table [][]string
+	for _, role := range roles {
+		marker := ""
+		if role.Name == current {
+			marker = "*"
+		}
+		table = append(table, []string{
+			marker,
+			role.Name,
+			fmt.Sprintf("%d", role.Clusters),
+			role.ContextPrefix,
+			role.Description,
+		})
+	}
+	printRegistryTable([]string{"CURRENT", "NAME", "CLUSTERS", "PREFIX", "DESCRIPTION"}, table)
+}
+
+// selectRoleWithRunner prompts for one of the given roles.
+func selectRoleWithRunner(roles []registry.RoleSummary, runner SelectRunner) (int, error) {
+	if len(roles) == 0 {
+		return 0, errors.New("registry has no roles")
+	}
+	templates := &promptui.SelectTemplates{
+		Label:    "{{ . }}",
+		Active:   "\U0001F680 {{ .Name | red }}",
+		Inactive: "  {{ .Name | cyan }}",
+		Selected: "\U0001F465 Selected: {{ .Name | green }}",
+		Details: `
+--------- Info ----------
+{{ "Name:" | faint }}	{{ .Name }}
+{{- with .Description }}
+{{ "Description:" | faint }}	{{ . }}
+{{- end }}
+{{ "Clusters:" | faint }}	{{ .Clusters }}
+{{- with .ContextPrefix }}
+{{ "Prefix:" | faint }}	{{ . }}
+{{- end }}`,
+	}
+	searcher := func(input string, index int) bool {
+		name := strings.ReplaceAll(strings.ToLower(roles[index].Name), " ", "")
+		input = strings.ReplaceAll(strings.ToLower(input), " ", "")
+		return strings.Contains(name, input)
+	}
+	prompt := promptui.Select{
+		Label:     "Select Role",
+		Items:     roles,
+		Templates: templates,
+		Size:      uiSize,
+		Searcher:  searcher,
+	}
+	if runner == nil {
+		runner = &prompt
+	}
+	i, _, err := runner.Run()
+	if err != nil {
+		return 0, err
+	}
+	return i, nil
+}
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sunny0826/kubecm/pkg/registry"
+)
+
+func TestSelectRole(t *testing.T) {
+	roles := []registry.RoleSummary{
+		{Name: "devops", Description: "Full access", Clusters: 3},
+		{Name: "readonly", Clusters: 1},
+	}
+
+	i, err := selectRoleWithRunner(roles, &testSelectNamespacePrompt{index: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, i)
+
+	_, err = selectRoleWithRunner(roles, &testSelectNamespacePrompt{err: errors.New("prompt error")})
+	assert.Error(t, err)
+
+	_, err = selectRoleWithRunner(nil, &testSelectNamespacePrompt{})
+	assert.Error(t, err)
+}
+
+func TestRegistryAddRoleRequired(t *testing.T) {
+	t.Setenv("KUBECM_HOME", t.TempDir())
+	c := &RegistryAddCommand{}
+	c.Init()
+	assert.NoError(t, c.command.ParseFlags([]string{"--name", "acme", "--url", "https://example.com/registry.git"}))
+
+	nonInteractive = true
+	defer func() { nonInteractive = false }()
+	err := c.runAdd(c.command, nil)
+	assert.EqualError(t, err, "--role is required in non-interactive mode")
+}
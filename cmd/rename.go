@@ -3,7 +3,9 @@ package cmd
 import (
 	"errors"
 	"fmt"
-	"slices"
+	"regexp"
+	"strings"
+	"unicode"
 
 	"github.com/spf13/cobra"
 	"k8s.io/client-go/tools/clientcmd"
@@ -27,10 +29,48 @@ func (rc *RenameCommand) Init() {
 		},
 		Example: renameExample(),
 	}
+	addSelectorFlag(rc.command.Flags())
+	rc.command.Flags().BoolP("yes", "y", false, "Skip confirmation prompt")
+	rc.command.Flags().Bool("all", false, "rename all the contexts with the templates")
+	rc.command.Flags().String("template", "", "Go template for the new context names, fields: .Name .Cluster .User .Namespace .Server, functions: short lower upper replace trimPrefix trimSuffix default")
+	rc.command.Flags().String("cluster-template", "", "Go template renaming the clusters of the contexts, fields: .Name .Server")
+	rc.command.Flags().String("user-template", "", "Go template renaming the users of the contexts, fields: .Name")
 	rc.AddCommands(&DocsCommand{})
 }
 
 func (rc *RenameCommand) runRename(command *cobra.Command, args []string) error {
+	sel, err := selectorFlag(command.Flags())
+	if err != nil {
+		return err
+	}
+	yes, _ := command.Flags().GetBool("yes")
+	all, _ := command.Flags().GetBool("all")
+	contextTemplate, _ := command.Flags().GetString("template")
+	clusterTemplate, _ := command.Flags().GetString("cluster-template")
+	userTemplate, _ := command.Flags().GetString("user-template")
+	var tmpls *renameTemplates
+	if all || contextTemplate != "" || clusterTemplate != "" || userTemplate != "" {
+		if len(args) > 0 {
+			return errors.New("--all and the templates cannot be used with arguments")
+		}
+		if all == (len(sel) > 0) {
+			return errors.New("the templates rename the contexts of either --all or --selector")
+		}
+		parsed, err := parseRenameTemplates(contextTemplate, clusterTemplate, userTemplate)
+		if err != nil {
+			return err
+		}
+		tmpls = &parsed
+	}
+	var sub *substitution
+	if len(args) == 1 {
+		sub, err = parseSubstitution(args[0])
+		if err != nil {
+			return err
+		}
+	} else if len(sel) > 0 && len(args) > 0 {
+		return errors.New("--selector cannot be used with context names")
+	}
 	kubeconfig, err := SelectKubeconfigFile("Select the kubeconfig file to rename from")
 	if err != nil {
 		return err
@@ -39,15 +79,13 @@ func (rc *RenameCommand) runRename(command *cobra.Command, args []string) error
 	if err != nil {
 		return err
 	}
-	var kubeItems []Needle
-	for key, obj := range config.Contexts {
-		if key != config.CurrentContext {
-			kubeItems = append(kubeItems, Needle{Name: key, Cluster: obj.Cluster, User: obj.AuthInfo})
-		} else {
-			kubeItems = append([]Needle{{Name: key, Cluster: obj.Cluster, User: obj.AuthInfo, Center: "(*)"}}, kubeItems...)
-		}
+	if tmpls != nil {
+		return templateBulkRename(kubeconfig, config, sel, *tmpls, yes)
 	}
-	slices.SortFunc(kubeItems, compareKubeItems)
+	if sub != nil {
+		return bulkRename(kubeconfig, config, sel, sub, yes)
+	}
+	kubeItems := contextNeedles(config, sel)
 	var kubeName string
 	var rename string
 	// args option
@@ -59,6 +97,9 @@ func (rc *RenameCommand) runRename(command *cobra.Command, args []string) error
 		kubeName = args[0]
 		rename = args[1]
 	} else {
+		if len(kubeItems) == 0 {
+			return errors.New("no contexts matched the selector")
+		}
 		// exit option
 		kubeItems, err = ExitOption(kubeItems)
 		if err != nil {
@@ -93,6 +134,177 @@ func renameComplete(rename, kubeName string, config *clientcmdapi.Config) (*clie
 	return config, nil
 }
 
+// bulkRename renames the contexts selected by sel whose name matches sub.
+func bulkRename(kubeconfig string, config *clientcmdapi.Config, sel contextSelector, sub *substitution, yes bool) error {
+	renames, err := renameContexts(config, sel.selectContexts(config), sub)
+	if err != nil {
+		return err
+	}
+	if len(renames) == 0 {
+		return errors.New("no contexts matched the specified pattern")
+	}
+	table := make([][]string, len(renames))
+	for i, r := range renames {
+		table[i] = []string{r.from, r.to}
+	}
+	printRegistryTable([]string{"CONTEXT", "NEW NAME"}, table)
+	if !yes && !strings.EqualFold(BoolUI(fmt.Sprintf("Are you sure you want to rename these %d contexts?", len(renames))), "True") {
+		return errors.New("rename operation cancelled")
+	}
+	if err := WriteConfig(true, kubeconfig, config); err != nil {
+		return err
+	}
+	return MacNotifier(fmt.Sprintf("Renamed %d contexts\n", len(renames)))
+}
+
+// templateBulkRename renames the contexts selected by sel, and their clusters
+// and users, with the templates.
+func templateBulkRename(kubeconfig string, config *clientcmdapi.Config, sel contextSelector, tmpls renameTemplates, yes bool) error {
+	renames, err := renameWithTemplates(config, sel.selectContexts(config), tmpls)
+	if err != nil {
+		return err
+	}
+	if len(renames) == 0 {
+		fmt.Println("No names to change.")
+		return nil
+	}
+	table := make([][]string, len(renames))
+	for i, r := range renames {
+		table[i] = []string{r.kind, r.from, r.to}
+	}
+	printRegistryTable([]string{"KIND", "NAME", "NEW NAME"}, table)
+	if !yes && !strings.EqualFold(BoolUI(fmt.Sprintf("Are you sure you want to apply these %d renames?", len(renames))), "True") {
+		return errors.New("rename operation cancelled")
+	}
+	if err := WriteConfig(true, kubeconfig, config); err != nil {
+		return err
+	}
+	return MacNotifier(fmt.Sprintf("Renamed %d names\n", len(renames)))
+}
+
+// contextRename is a context renamed from one name to another.
+type contextRename struct {
+	from, to string
+}
+
+// renameContexts renames the contexts of names matching sub all at once,
+// so that contexts may take each other's names. Nothing is renamed when a
+// new name is empty or collides with another context.
+func renameContexts(config *clientcmdapi.Config, names []string, sub *substitution) ([]contextRename, error) {
+	var renames []contextRename
+	renamed := make(map[string]bool)
+	targets := make(map[string]string)
+	for _, name := range names {
+		to, ok := sub.apply(name)
+		if !ok || to == name {
+			continue
+		}
+		if to == "" {
+			return nil, fmt.Errorf("context %q would be renamed to an empty name", name)
+		}
+		if other, ok := targets[to]; ok {
+			return nil, fmt.Errorf("contexts %q and %q would both be renamed to %q", other, name, to)
+		}
+		targets[to] = name
+		renamed[name] = true
+		renames = append(renames, contextRename{from: name, to: to})
+	}
+	for _, r := range renames {
+		if _, ok := config.Contexts[r.to]; ok && !renamed[r.to] {
+			return nil, errors.New("Name: " + r.to + " already exists")
+		}
+	}
+
+	contexts := make(map[string]*clientcmdapi.Context, len(renames))
+	current := config.CurrentContext
+	for _, r := range renames {
+		contexts[r.to] = config.Contexts[r.from]
+		delete(config.Contexts, r.from)
+		if r.from == current {
+			config.CurrentContext = r.to
+		}
+	}
+	for to, ctx := range contexts {
+		config.Contexts[to] = ctx
+	}
+	return renames, nil
+}
+
+// substitution is a sed like s/pattern/replacement/ expression, replacing
+// the first match of pattern, or every match with the g flag. Any symbol
+// may delimit it, \1 in replacement refers to a capture group and $ is
+// literal.
+type substitution struct {
+	re          *regexp.Regexp
+	replacement string
+	global      bool
+}
+
+func parseSubstitution(expr string) (*substitution, error) {
+	invalid := fmt.Errorf("invalid expression %q, expected s/pattern/replacement/ or two context names", expr)
+	if len(expr) < 4 || expr[0] != 's' || !isSubstitutionDelimiter(expr[1]) {
+		return nil, invalid
+	}
+	delim := expr[1]
+	var parts []string
+	var part strings.Builder
+	rest := expr[2:]
+	for len(parts) < 2 {
+		if rest == "" {
+			return nil, invalid
+		}
+		switch c := rest[0]; {
+		case c == '\\' && len(rest) > 1 && rest[1] == delim:
+			part.WriteByte(delim)
+			rest = rest[2:]
+		case c == delim:
+			parts = append(parts, part.String())
+			part.Reset()
+			rest = rest[1:]
+		default:
+			part.WriteByte(c)
+			rest = rest[1:]
+		}
+	}
+	if rest != "" && rest != "g" {
+		return nil, fmt.Errorf("invalid flags %q in %q, only g is supported", rest, expr)
+	}
+	re, err := regexp.Compile(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex pattern %q: %w", parts[0], err)
+	}
+	return &substitution{
+		re:          re,
+		replacement: sedGroupRef.ReplaceAllString(strings.ReplaceAll(parts[1], "$", "$$"), "$${$1}"),
+		global:      rest == "g",
+	}, nil
+}
+
+// isSubstitutionDelimiter reports whether c may delimit a substitution,
+// like the / of s/a/b/ or the | of s|a|b|.
+func isSubstitutionDelimiter(c byte) bool {
+	return c < unicode.MaxASCII && c != '\\' && !unicode.IsLetter(rune(c)) && !unicode.IsDigit(rune(c)) && !unicode.IsSpace(rune(c))
+}
+
+// sedGroupRef matches the \1 capture group references of sed.
+var sedGroupRef = regexp.MustCompile(`\\([0-9])`)
+
+// apply returns name with the substitution applied, and whether it matched.
+func (s *substitution) apply(name string) (string, bool) {
+	if s.global {
+		if !s.re.MatchString(name) {
+			return name, false
+		}
+		return s.re.ReplaceAllString(name, s.replacement), true
+	}
+	m := s.re.FindStringSubmatchIndex(name)
+	if m == nil {
+		return name, false
+	}
+	replaced := s.re.ExpandString(nil, s.replacement, name, m)
+	return name[:m[0]] + string(replaced) + name[m[1]:], true
+}
+
 func checkRenameArgs(args []string, kubeItems []Needle) error {
 	if len(args) != 2 {
 		return errors.New("requires exactly 2 args")
@@ -111,5 +323,17 @@ func renameExample() string {
 kubecm rename
 # Renamed the context non-interactively
 kubecm rename <kube-context-name> <new-kube-context-name>
+# Rename every context starting with "old-" to start with "new-"
+kubecm rename 's/^old-/new-/'
+# Move the region suffix of the EKS contexts to the front, without confirmation
+kubecm rename -l 'server=*.eks.amazonaws.com' 's/^(.*)-(us|eu)-(.*)$/\2-\3-\1/' -y
+# Rename one of the contexts in the prod namespace interactively
+kubecm rename -l namespace=prod
+# Name every context after its cluster and namespace, e.g. arn:aws:eks:eu-west-1:123456789012:cluster/prod becomes prod-default
+kubecm rename --all --template '{{.Cluster | short}}-{{.Namespace | default "default"}}'
+# Also shorten the cluster and user names the contexts use
+kubecm rename --all --template '{{.Cluster | short}}' --cluster-template '{{.Name | short}}' --user-template '{{.Name | short}}'
+# Rename the EKS users only
+kubecm rename -l 'server=*.eks.amazonaws.com' --user-template 'eks-{{.Name | short}}'
 `
 }
@@ -0,0 +1,234 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+	"text/template"
+
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// renameFuncs are the functions available to the rename templates.
+var renameFuncs = template.FuncMap{
+	"short":      shortName,
+	"lower":      strings.ToLower,
+	"upper":      strings.ToUpper,
+	"replace":    func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+	"trimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+	"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+	"default": func(def, s string) string {
+		if s == "" {
+			return def
+		}
+		return s
+	},
+}
+
+// contextNameData is the data available to --template.
+type contextNameData struct {
+	Name      string
+	Cluster   string
+	User      string
+	Namespace string
+	Server    string
+}
+
+// clusterNameData is the data available to --cluster-template.
+type clusterNameData struct {
+	Name   string
+	Server string
+}
+
+// userNameData is the data available to --user-template.
+type userNameData struct {
+	Name string
+}
+
+// renameTemplates holds the templates of rename --template, a nil template
+// leaving those names unchanged.
+type renameTemplates struct {
+	context *template.Template
+	cluster *template.Template
+	user    *template.Template
+}
+
+func parseRenameTemplates(context, cluster, user string) (renameTemplates, error) {
+	var tmpls renameTemplates
+	for _, t := range []struct {
+		flag, text string
+		tmpl       **template.Template
+	}{
+		{"--template", context, &tmpls.context},
+		{"--cluster-template", cluster, &tmpls.cluster},
+		{"--user-template", user, &tmpls.user},
+	} {
+		if t.text == "" {
+			continue
+		}
+		tmpl, err := template.New(strings.TrimPrefix(t.flag, "--")).Option("missingkey=error").Funcs(renameFuncs).Parse(t.text)
+		if err != nil {
+			return tmpls, fmt.Errorf("invalid %s: %w", t.flag, err)
+		}
+		*t.tmpl = tmpl
+	}
+	if tmpls.context == nil && tmpls.cluster == nil && tmpls.user == nil {
+		return tmpls, errors.New("one of --template, --cluster-template or --user-template is required")
+	}
+	return tmpls, nil
+}
+
+// templateRename is the rename of a context, cluster or user.
+type templateRename struct {
+	kind, from, to string
+}
+
+// renameWithTemplates renames the contexts of names, and the clusters and
+// users they use, with the templates. References to renamed clusters and
+// users are rewritten in every context. A new name taken by another one is
+// made unique by appending -2, -3, ... Nothing is renamed on error.
+func renameWithTemplates(config *clientcmdapi.Config, names []string, tmpls renameTemplates) ([]templateRename, error) {
+	var clusters, users []string
+	for _, name := range names {
+		ctx := config.Contexts[name]
+		if _, ok := config.Clusters[ctx.Cluster]; ok && !slices.Contains(clusters, ctx.Cluster) {
+			clusters = append(clusters, ctx.Cluster)
+		}
+		if _, ok := config.AuthInfos[ctx.AuthInfo]; ok && !slices.Contains(users, ctx.AuthInfo) {
+			users = append(users, ctx.AuthInfo)
+		}
+	}
+	slices.Sort(clusters)
+	slices.Sort(users)
+
+	var renames []templateRename
+	contextNames, err := uniqueRenames("context", names, slices.Collect(maps.Keys(config.Contexts)), tmpls.context, func(name string) any {
+		ctx := config.Contexts[name]
+		data := contextNameData{Name: name, Cluster: ctx.Cluster, User: ctx.AuthInfo, Namespace: ctx.Namespace}
+		if cluster, ok := config.Clusters[ctx.Cluster]; ok {
+			data.Server = cluster.Server
+		}
+		return data
+	}, &renames)
+	if err != nil {
+		return nil, err
+	}
+	clusterNames, err := uniqueRenames("cluster", clusters, slices.Collect(maps.Keys(config.Clusters)), tmpls.cluster, func(name string) any {
+		return clusterNameData{Name: name, Server: config.Clusters[name].Server}
+	}, &renames)
+	if err != nil {
+		return nil, err
+	}
+	userNames, err := uniqueRenames("user", users, slices.Collect(maps.Keys(config.AuthInfos)), tmpls.user, func(name string) any {
+		return userNameData{Name: name}
+	}, &renames)
+	if err != nil {
+		return nil, err
+	}
+
+	config.Contexts = renameKeys(config.Contexts, contextNames)
+	config.Clusters = renameKeys(config.Clusters, clusterNames)
+	config.AuthInfos = renameKeys(config.AuthInfos, userNames)
+	if to, ok := contextNames[config.CurrentContext]; ok {
+		config.CurrentContext = to
+	}
+	for _, ctx := range config.Contexts {
+		if to, ok := clusterNames[ctx.Cluster]; ok {
+			ctx.Cluster = to
+		}
+		if to, ok := userNames[ctx.AuthInfo]; ok {
+			ctx.AuthInfo = to
+		}
+	}
+	return renames, nil
+}
+
+// uniqueRenames renders the new names of names with tmpl, appending the
+// changes to renames. Names rendered unchanged are kept, the others are made
+// unique among them and the existing names.
+func uniqueRenames(kind string, names, existing []string, tmpl *template.Template, data func(string) any, renames *[]templateRename) (map[string]string, error) {
+	changed := make(map[string]string)
+	if tmpl == nil {
+		return changed, nil
+	}
+	rendered := make(map[string]string, len(names))
+	for _, name := range names {
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data(name)); err != nil {
+			return nil, fmt.Errorf("rendering the %s name of %q: %w", kind, name, err)
+		}
+		to := strings.TrimSpace(buf.String())
+		if to == "" {
+			return nil, fmt.Errorf("the %s name of %q renders empty", kind, name)
+		}
+		rendered[name] = to
+	}
+
+	taken := make(map[string]bool)
+	for _, name := range existing {
+		if to, ok := rendered[name]; !ok || to == name {
+			taken[name] = true
+		}
+	}
+	for _, name := range names {
+		to := rendered[name]
+		if to == name {
+			continue
+		}
+		unique := to
+		for i := 2; taken[unique]; i++ {
+			unique = fmt.Sprintf("%s-%d", to, i)
+		}
+		taken[unique] = true
+		changed[name] = unique
+		*renames = append(*renames, templateRename{kind: kind, from: name, to: unique})
+	}
+	return changed, nil
+}
+
+// renameKeys returns m with the keys of renames renamed.
+func renameKeys[V any](m map[string]V, renames map[string]string) map[string]V {
+	if len(renames) == 0 {
+		return m
+	}
+	out := make(map[string]V, len(m))
+	for k, v := range m {
+		if to, ok := renames[k]; ok {
+			k = to
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// shortName shortens the names clouds generate for clusters and users:
+// arn:aws:eks:<region>:<account>:cluster/<name> and
+// gke_<project>_<location>_<name> become <name>, and a server URL becomes
+// the first label of its host name.
+func shortName(name string) string {
+	if strings.HasPrefix(name, "arn:") {
+		if i := strings.LastIndex(name, "/"); i >= 0 {
+			return name[i+1:]
+		}
+		return name
+	}
+	if strings.HasPrefix(name, "gke_") {
+		if parts := strings.SplitN(name, "_", 4); len(parts) == 4 {
+			return parts[3]
+		}
+		return name
+	}
+	if u, err := url.Parse(name); err == nil && u.Scheme != "" && u.Hostname() != "" {
+		if net.ParseIP(u.Hostname()) != nil {
+			return u.Hostname()
+		}
+		host, _, _ := strings.Cut(u.Hostname(), ".")
+		return host
+	}
+	return name
+}
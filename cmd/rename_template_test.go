@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"maps"
+	"reflect"
+	"slices"
+	"testing"
+
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+func Test_shortName(t *testing.T) {
+	tests := map[string]string{
+		"arn:aws:eks:eu-west-1:123456789012:cluster/prod": "prod",
+		"gke_my-project_europe-west1-b_staging":           "staging",
+		"https://abc123.gr7.eu-west-1.eks.amazonaws.com":  "abc123",
+		"https://127.0.0.1:6443":                          "127.0.0.1",
+		"kind-dev":                                        "kind-dev",
+		"gke_incomplete":                                  "gke_incomplete",
+	}
+	for name, want := range tests {
+		if got := shortName(name); got != want {
+			t.Errorf("shortName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func Test_parseRenameTemplates(t *testing.T) {
+	if _, err := parseRenameTemplates("", "", ""); err == nil {
+		t.Error("parseRenameTemplates() without templates succeeded")
+	}
+	if _, err := parseRenameTemplates("{{.Name", "", ""); err == nil {
+		t.Error("parseRenameTemplates() with an invalid template succeeded")
+	}
+	if _, err := parseRenameTemplates("", "{{.Name | short}}", ""); err != nil {
+		t.Errorf("parseRenameTemplates() error = %v", err)
+	}
+}
+
+func Test_renameWithTemplates(t *testing.T) {
+	const arn = "arn:aws:eks:eu-west-1:123:cluster/prod"
+	newConfig := func() *clientcmdapi.Config {
+		return &clientcmdapi.Config{
+			Clusters: map[string]*clientcmdapi.Cluster{
+				arn:        {Server: "https://abc.eks.amazonaws.com"},
+				"kind-dev": {Server: "https://127.0.0.1:6443"},
+			},
+			AuthInfos: map[string]*clientcmdapi.AuthInfo{
+				arn:         {Token: "a"},
+				"kind-user": {Token: "b"},
+			},
+			Contexts: map[string]*clientcmdapi.Context{
+				arn:      {Cluster: arn, AuthInfo: arn, Namespace: "default"},
+				"ops":    {Cluster: arn, AuthInfo: arn, Namespace: "default"},
+				"dev":    {Cluster: "kind-dev", AuthInfo: "kind-user"},
+				"prod-x": {Cluster: "kind-dev", AuthInfo: "kind-user"},
+			},
+			CurrentContext: arn,
+		}
+	}
+	parse := func(context, cluster, user string) renameTemplates {
+		tmpls, err := parseRenameTemplates(context, cluster, user)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tmpls
+	}
+
+	t.Run("contexts", func(t *testing.T) {
+		config := newConfig()
+		_, err := renameWithTemplates(config, []string{arn, "dev", "ops"}, parse(`{{.Cluster | short}}-{{.Namespace | default "none"}}`, "", ""))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []string{"kind-dev-none", "prod-default", "prod-default-2", "prod-x"}
+		if got := slices.Sorted(maps.Keys(config.Contexts)); !reflect.DeepEqual(got, want) {
+			t.Errorf("contexts = %v, want %v", got, want)
+		}
+		if config.CurrentContext != "prod-default" {
+			t.Errorf("current context = %q", config.CurrentContext)
+		}
+	})
+
+	t.Run("clusters and users", func(t *testing.T) {
+		config := newConfig()
+		renames, err := renameWithTemplates(config, []string{"ops"}, parse("", "{{.Name | short}}", "{{.Name | short}}-user"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(renames) != 2 {
+			t.Errorf("renames = %v", renames)
+		}
+		if _, ok := config.Clusters["prod"]; !ok || config.Clusters[arn] != nil {
+			t.Errorf("clusters = %v", slices.Sorted(maps.Keys(config.Clusters)))
+		}
+		// the context sharing the cluster and user follows the rename too
+		for _, name := range []string{arn, "ops"} {
+			if ctx := config.Contexts[name]; ctx.Cluster != "prod" || ctx.AuthInfo != "prod-user" {
+				t.Errorf("context %q = %+v", name, ctx)
+			}
+		}
+		if ctx := config.Contexts["dev"]; ctx.Cluster != "kind-dev" || ctx.AuthInfo != "kind-user" {
+			t.Errorf("context dev = %+v", ctx)
+		}
+	})
+
+	t.Run("swap", func(t *testing.T) {
+		config := newConfig()
+		_, err := renameWithTemplates(config, []string{"dev", "ops"}, parse(`{{if eq .Name "dev"}}ops{{else}}dev{{end}}`, "", ""))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if config.Contexts["dev"].Cluster != arn || config.Contexts["ops"].Cluster != "kind-dev" {
+			t.Errorf("contexts = %v", config.Contexts)
+		}
+	})
+
+	t.Run("empty name", func(t *testing.T) {
+		config := newConfig()
+		if _, err := renameWithTemplates(config, []string{"dev"}, parse("{{.Namespace}}", "", "")); err == nil {
+			t.Fatal("renameWithTemplates() with an empty name succeeded")
+		}
+		if len(config.Contexts) != 4 || config.Contexts["dev"] == nil {
+			t.Errorf("contexts changed on error: %v", config.Contexts)
+		}
+	})
+}
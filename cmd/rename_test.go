@@ -3,7 +3,9 @@ package cmd
 import (
 	"errors"
 	"fmt"
+	"maps"
 	"reflect"
+	"slices"
 	"testing"
 
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
@@ -123,3 +125,97 @@ func Test_checkRenameArgs(t *testing.T) {
 		})
 	}
 }
+
+func Test_parseSubstitution(t *testing.T) {
+	tests := []struct {
+		expr    string
+		in      string
+		want    string
+		matched bool
+		wantErr bool
+	}{
+		{expr: "s/^old-/new-/", in: "old-cluster", want: "new-cluster", matched: true},
+		{expr: "s/^old-/new-/", in: "my-old-cluster", want: "my-old-cluster"},
+		{expr: `s/^(\w+)-(\w+)$/\2-\1/`, in: "dev-eu", want: "eu-dev", matched: true},
+		{expr: `s/^(\w+)-(\w+)$/\2_\1/`, in: "dev-eu", want: "eu_dev", matched: true},
+		{expr: "s/x/$foo/", in: "a-x", want: "a-$foo", matched: true},
+		{expr: `s/^(\w+)$/$1-${1}-\1/`, in: "dev", want: "$1-${1}-dev", matched: true},
+		{expr: "s/-/_/", in: "a-b-c", want: "a_b-c", matched: true},
+		{expr: "s/-/_/g", in: "a-b-c", want: "a_b_c", matched: true},
+		{expr: "s|arn:aws:eks:.*/||", in: "arn:aws:eks:eu-west-1:123:cluster/prod", want: "prod", matched: true},
+		{expr: `s/\//-/g`, in: "team/app", want: "team-app", matched: true},
+		{expr: "s/a/b", wantErr: true},
+		{expr: "context1", wantErr: true},
+		{expr: "sxaxbx", wantErr: true},
+		{expr: "s/(/b/", wantErr: true},
+		{expr: "s/a/b/i", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			sub, err := parseSubstitution(tt.expr)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseSubstitution() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			got, matched := sub.apply(tt.in)
+			if got != tt.want || matched != tt.matched {
+				t.Errorf("apply(%q) = %q, %v, want %q, %v", tt.in, got, matched, tt.want, tt.matched)
+			}
+		})
+	}
+}
+
+func Test_renameContexts(t *testing.T) {
+	newConfig := func() *clientcmdapi.Config {
+		return &clientcmdapi.Config{
+			Contexts: map[string]*clientcmdapi.Context{
+				"a-dev":  {Cluster: "dev"},
+				"a-prod": {Cluster: "prod"},
+				"b-dev":  {Cluster: "other"},
+			},
+			CurrentContext: "a-dev",
+		}
+	}
+	tests := []struct {
+		name        string
+		expr        string
+		names       []string
+		want        []string
+		wantCurrent string
+		wantErr     bool
+	}{
+		{"prefix", "s/^a-/x-/", []string{"a-dev", "a-prod", "b-dev"}, []string{"b-dev", "x-dev", "x-prod"}, "x-dev", false},
+		{"only selected", "s/^a-/x-/", []string{"a-prod"}, []string{"a-dev", "b-dev", "x-prod"}, "a-dev", false},
+		{"swap", `s/^([ab])-dev$/\1X/`, []string{"a-dev", "b-dev"}, []string{"a-prod", "aX", "bX"}, "aX", false},
+		{"existing name", "s/^a-/b-/", []string{"a-dev"}, nil, "", true},
+		{"same new name", "s/^[ab]-dev$/dev/", []string{"a-dev", "b-dev"}, nil, "", true},
+		{"empty name", "s/.*//", []string{"a-dev"}, nil, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub, err := parseSubstitution(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			config := newConfig()
+			_, err = renameContexts(config, tt.names, sub)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("renameContexts() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if len(config.Contexts) != 3 || config.CurrentContext != "a-dev" {
+					t.Errorf("renameContexts() changed the config on error: %v", config.Contexts)
+				}
+				return
+			}
+			if got := slices.Sorted(maps.Keys(config.Contexts)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("renameContexts() contexts = %v, want %v", got, tt.want)
+			}
+			if config.CurrentContext != tt.wantCurrent {
+				t.Errorf("renameContexts() current context = %q, want %q", config.CurrentContext, tt.wantCurrent)
+			}
+		})
+	}
+}
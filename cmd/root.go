@@ -39,6 +39,8 @@ var (
 	macNotify    bool
 	silenceTable bool
 	cfgCreate    bool
+	// nonInteractive makes commands fail instead of prompting for input.
+	nonInteractive bool
 )
 
 // Cli cmd struct
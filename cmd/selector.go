@@ -0,0 +1,222 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"github.com/sunny0826/kubecm/pkg/metadata"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// selectorFields are the context attributes a selector term can match.
+var selectorFields = []string{"name", "cluster", "user", "namespace", "server", "tier", "tag", "description"}
+
+const selectorUsage = "select contexts by name glob or /regex/, or by field=glob or field~regex with field one of: name, cluster, user, namespace, server, tier, tag, description; repeat to require all of them"
+
+// contextSelector selects contexts by their name or by the cluster, user,
+// namespace and server they use. A context is selected when it matches all
+// terms, so an empty selector selects every context.
+type contextSelector []selectorTerm
+
+// selectorTerm matches one field of a context against a regular expression,
+// globs being translated into one.
+type selectorTerm struct {
+	field string
+	re    *regexp.Regexp
+}
+
+// addSelectorFlag adds the --selector flag shared by the commands selecting
+// contexts.
+func addSelectorFlag(flags *pflag.FlagSet) {
+	flags.StringArrayP("selector", "l", nil, selectorUsage)
+}
+
+// selectorFlag parses the --selector flag.
+func selectorFlag(flags *pflag.FlagSet) (contextSelector, error) {
+	terms, _ := flags.GetStringArray("selector")
+	return parseSelector(terms)
+}
+
+func parseSelector(terms []string) (contextSelector, error) {
+	var sel contextSelector
+	for _, term := range terms {
+		t, err := parseSelectorTerm(term)
+		if err != nil {
+			return nil, err
+		}
+		sel = append(sel, t)
+	}
+	return sel, nil
+}
+
+// parseSelectorTerm parses one of
+//
+//	dev-*            name glob
+//	/^(dev|test)-/   name regex
+//	cluster=*-eks    field glob
+//	server~:6443$    field regex
+func parseSelectorTerm(term string) (selectorTerm, error) {
+	if term == "" {
+		return selectorTerm{}, errors.New("selector cannot be empty")
+	}
+	if len(term) > 1 && strings.HasPrefix(term, "/") && strings.HasSuffix(term, "/") {
+		return newRegexTerm("name", term[1:len(term)-1])
+	}
+	if i := strings.IndexAny(term, "=~"); i > 0 && slices.Contains(selectorFields, term[:i]) {
+		if term[i] == '~' {
+			return newRegexTerm(term[:i], term[i+1:])
+		}
+		return selectorTerm{field: term[:i], re: globRegexp(term[i+1:])}, nil
+	}
+	return selectorTerm{field: "name", re: globRegexp(term)}, nil
+}
+
+// matchTerm returns the term matching context names against pattern in one
+// of the modes of delete range and export --match: prefix, suffix, contains
+// or regex.
+func matchTerm(pattern, mode string) (selectorTerm, error) {
+	if pattern == "" {
+		return selectorTerm{}, errors.New("pattern cannot be empty")
+	}
+	quoted := regexp.QuoteMeta(pattern)
+	switch mode {
+	case "prefix":
+		return selectorTerm{field: "name", re: regexp.MustCompile("^" + quoted)}, nil
+	case "suffix":
+		return selectorTerm{field: "name", re: regexp.MustCompile(quoted + "$")}, nil
+	case "contains":
+		return selectorTerm{field: "name", re: regexp.MustCompile(quoted)}, nil
+	case "regex":
+		return newRegexTerm("name", pattern)
+	}
+	return selectorTerm{}, fmt.Errorf("invalid match mode: %s, must be one of: prefix, suffix, contains, regex", mode)
+}
+
+func newRegexTerm(field, pattern string) (selectorTerm, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return selectorTerm{}, fmt.Errorf("invalid regex pattern %q: %w", pattern, err)
+	}
+	return selectorTerm{field: field, re: re}, nil
+}
+
+// globRegexp translates a glob, where * matches any characters including
+// "/", ? a single character and [...] a character class, into an anchored
+// regular expression.
+func globRegexp(glob string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		case '[':
+			if end := strings.IndexByte(glob[i+1:], ']'); end > 0 {
+				class := glob[i+1 : i+1+end]
+				if class[0] == '!' {
+					class = "^" + class[1:]
+				}
+				b.WriteString("[" + class + "]")
+				i += end + 1
+				continue
+			}
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	re, err := regexp.Compile(b.String())
+	if err != nil {
+		// an invalid character class is matched literally
+		return regexp.MustCompile("^" + regexp.QuoteMeta(glob) + "$")
+	}
+	return re
+}
+
+// matches reports whether the context name of config is selected.
+func (s contextSelector) matches(config *clientcmdapi.Config, name string) bool {
+	ctx, ok := config.Contexts[name]
+	if !ok {
+		return false
+	}
+	for _, t := range s {
+		if !slices.ContainsFunc(t.values(config, name, ctx), t.re.MatchString) {
+			return false
+		}
+	}
+	return true
+}
+
+// selectContexts returns the sorted names of the selected contexts.
+func (s contextSelector) selectContexts(config *clientcmdapi.Config) []string {
+	var names []string
+	for name := range config.Contexts {
+		if s.matches(config, name) {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// values returns the values of the field of a context, a term matching any
+// of them. A context without tags has a single empty tag.
+func (t selectorTerm) values(config *clientcmdapi.Config, name string, ctx *clientcmdapi.Context) []string {
+	switch t.field {
+	case "cluster":
+		return []string{ctx.Cluster}
+	case "user":
+		return []string{ctx.AuthInfo}
+	case "namespace":
+		return []string{ctx.Namespace}
+	case "server":
+		if cluster, ok := config.Clusters[ctx.Cluster]; ok {
+			return []string{cluster.Server}
+		}
+		return []string{""}
+	case "tier":
+		return []string{metadata.Lookup(ctx).Tier}
+	case "description":
+		return []string{metadata.Lookup(ctx).Description}
+	case "tag":
+		if tags := metadata.Lookup(ctx).Tags; len(tags) > 0 {
+			return tags
+		}
+		return []string{""}
+	default:
+		return []string{name}
+	}
+}
+
+// contextNeedles returns the selected contexts as select items sorted by
+// name, the current context being marked.
+func contextNeedles(config *clientcmdapi.Config, sel contextSelector) []Needle {
+	var kubeItems []Needle
+	for key, obj := range config.Contexts {
+		if !sel.matches(config, key) {
+			continue
+		}
+		m := metadata.Lookup(obj)
+		item := Needle{
+			Name:        key,
+			Cluster:     obj.Cluster,
+			User:        obj.AuthInfo,
+			Tier:        m.Tier,
+			Tags:        strings.Join(m.Tags, ","),
+			Description: m.Description,
+		}
+		if key == config.CurrentContext {
+			item.Center = "(*)"
+		}
+		kubeItems = append(kubeItems, item)
+	}
+	slices.SortFunc(kubeItems, compareKubeItems)
+	return kubeItems
+}
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+var selectorTestConfig = clientcmdapi.Config{
+	Clusters: map[string]*clientcmdapi.Cluster{
+		"arn:aws:eks:eu-west-1:123:cluster/prod": {Server: "https://ABC.gr7.eu-west-1.eks.amazonaws.com"},
+		"kind-dev":                               {Server: "https://127.0.0.1:6443"},
+	},
+	AuthInfos: map[string]*clientcmdapi.AuthInfo{
+		"eks-user":  {Token: "a"},
+		"kind-user": {Token: "b"},
+	},
+	Contexts: map[string]*clientcmdapi.Context{
+		"prod-eu":   {Cluster: "arn:aws:eks:eu-west-1:123:cluster/prod", AuthInfo: "eks-user", Namespace: "prod"},
+		"prod-ops":  {Cluster: "arn:aws:eks:eu-west-1:123:cluster/prod", AuthInfo: "eks-user", Namespace: "kube-system"},
+		"dev-kind":  {Cluster: "kind-dev", AuthInfo: "kind-user"},
+		"test-kind": {Cluster: "kind-dev", AuthInfo: "kind-user", Namespace: "test"},
+	},
+	CurrentContext: "dev-kind",
+}
+
+func Test_contextSelector(t *testing.T) {
+	tests := []struct {
+		name  string
+		terms []string
+		want  []string
+	}{
+		{"all", nil, []string{"dev-kind", "prod-eu", "prod-ops", "test-kind"}},
+		{"name glob", []string{"prod-*"}, []string{"prod-eu", "prod-ops"}},
+		{"exact name", []string{"prod-eu"}, []string{"prod-eu"}},
+		{"single character", []string{"prod-e?"}, []string{"prod-eu"}},
+		{"character class", []string{"[dt]*-kind"}, []string{"dev-kind", "test-kind"}},
+		{"negated class", []string{"[!d]*-kind"}, []string{"test-kind"}},
+		{"name regex", []string{"/^(dev|test)-/"}, []string{"dev-kind", "test-kind"}},
+		{"cluster glob across slashes", []string{"cluster=arn:aws:eks:*/prod"}, []string{"prod-eu", "prod-ops"}},
+		{"server glob", []string{"server=*.eks.amazonaws.com"}, []string{"prod-eu", "prod-ops"}},
+		{"server regex", []string{"server~:6443$"}, []string{"dev-kind", "test-kind"}},
+		{"empty namespace", []string{"namespace="}, []string{"dev-kind"}},
+		{"all terms", []string{"user=kind-*", "namespace=test"}, []string{"test-kind"}},
+		{"name field", []string{"name~ops"}, []string{"prod-ops"}},
+		{"no match", []string{"staging-*"}, nil},
+		{"unknown field is a name", []string{"team=a"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sel, err := parseSelector(tt.terms)
+			if err != nil {
+				t.Fatalf("parseSelector() error = %v", err)
+			}
+			if got := sel.selectContexts(&selectorTestConfig); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("selectContexts() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseSelectorErrors(t *testing.T) {
+	for _, terms := range [][]string{{""}, {"/(/"}, {"server~["}} {
+		if _, err := parseSelector(terms); err == nil {
+			t.Errorf("parseSelector(%q) expected an error", terms)
+		}
+	}
+}
+
+func Test_contextNeedles(t *testing.T) {
+	sel, _ := parseSelector([]string{"*-kind"})
+	got := contextNeedles(&selectorTestConfig, sel)
+	want := []Needle{
+		{Name: "dev-kind", Cluster: "kind-dev", User: "kind-user", Center: "(*)"},
+		{Name: "test-kind", Cluster: "kind-dev", User: "kind-user"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("contextNeedles() = %v, want %v", got, want)
+	}
+}
+
+func Test_matchTerm(t *testing.T) {
+	// delete range combines its pattern with the --selector terms
+	term, err := matchTerm("prod-", "prefix")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sel, err := parseSelector([]string{"namespace=prod"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := append(contextSelector{term}, sel...).selectContexts(&selectorTestConfig); !reflect.DeepEqual(got, []string{"prod-eu"}) {
+		t.Errorf("selectContexts() = %v", got)
+	}
+
+	// patterns are literal outside the regex mode
+	term, _ = matchTerm("-kind.", "contains")
+	if got := (contextSelector{term}).selectContexts(&selectorTestConfig); got != nil {
+		t.Errorf("selectContexts() of a literal pattern = %v", got)
+	}
+	if _, err := matchTerm("dev", "glob"); err == nil {
+		t.Error("matchTerm() accepted an invalid mode")
+	}
+}
@@ -3,7 +3,6 @@ package cmd
 import (
 	"errors"
 	"fmt"
-	"slices"
 
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 
@@ -36,10 +35,18 @@ Switch Kube Context interactively
 		},
 		Example: switchExample(),
 	}
+	addSelectorFlag(sc.command.Flags())
 	sc.AddCommands(&DocsCommand{})
 }
 
 func (sc *SwitchCommand) runSwitch(command *cobra.Command, args []string) error {
+	sel, err := selectorFlag(command.Flags())
+	if err != nil {
+		return err
+	}
+	if len(sel) > 0 && len(args) > 0 {
+		return errors.New("a context name cannot be used with --selector")
+	}
 	kubeconfig, err := SelectKubeconfigFile("Select the kubeconfig file to switch context from")
 	if err != nil {
 		return err
@@ -50,7 +57,7 @@ func (sc *SwitchCommand) runSwitch(command *cobra.Command, args []string) error
 	}
 	switch len(args) {
 	case 0:
-		config, err = handleOperation(config)
+		config, err = handleOperation(config, sel)
 		if err != nil {
 			return err
 		}
@@ -76,17 +83,19 @@ func handleQuickSwitch(config *clientcmdapi.Config, name string) (*clientcmdapi.
 	return config, nil
 }
 
-func handleOperation(config *clientcmdapi.Config) (*clientcmdapi.Config, error) {
-	var kubeItems []Needle
-	current := config.CurrentContext
-	for key, obj := range config.Contexts {
-		if key != current {
-			kubeItems = append(kubeItems, Needle{Name: key, Cluster: obj.Cluster, User: obj.AuthInfo})
-		} else {
-			kubeItems = append([]Needle{{Name: key, Cluster: obj.Cluster, User: obj.AuthInfo, Center: "(*)"}}, kubeItems...)
+// handleOperation switches to the context selected by sel, asking which one
+// when several are.
+func handleOperation(config *clientcmdapi.Config, sel contextSelector) (*clientcmdapi.Config, error) {
+	kubeItems := contextNeedles(config, sel)
+	if len(sel) > 0 {
+		switch len(kubeItems) {
+		case 0:
+			return config, errors.New("no contexts matched the selector")
+		case 1:
+			config.CurrentContext = kubeItems[0].Name
+			return config, nil
 		}
 	}
-	slices.SortFunc(kubeItems, compareKubeItems)
 	// exit option
 	kubeItems, err := ExitOption(kubeItems)
 	if err != nil {
@@ -106,5 +115,7 @@ func switchExample() string {
 kubecm switch
 # Quick switch Kube Context
 kubecm switch dev
+# Switch to the context using the prod namespace of the cluster, choosing when there are several
+kubecm switch -l cluster=prod-cluster -l namespace=prod
 `
 }
@@ -55,3 +55,18 @@ func Test_handleQuickSwitch(t *testing.T) {
 		})
 	}
 }
+
+func Test_handleOperationSelector(t *testing.T) {
+	sel, _ := parseSelector([]string{"namespace=test"})
+	config, err := handleOperation(selectorTestConfig.DeepCopy(), sel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.CurrentContext != "test-kind" {
+		t.Errorf("handleOperation() current context = %q, want test-kind", config.CurrentContext)
+	}
+	sel, _ = parseSelector([]string{"staging-*"})
+	if _, err := handleOperation(selectorTestConfig.DeepCopy(), sel); err == nil {
+		t.Error("handleOperation() with no selected context succeeded")
+	}
+}
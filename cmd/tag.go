@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/sunny0826/kubecm/pkg/metadata"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// TagCommand tag command struct
+type TagCommand struct {
+	BaseCommand
+}
+
+// Init TagCommand
+func (tc *TagCommand) Init() {
+	tc.command = &cobra.Command{
+		Use:   "tag [CONTEXT] [TAG...]",
+		Short: "Describe contexts with tags, a description and an environment tier",
+		Long: `Describe contexts with tags, a description and an environment tier.
+The metadata is kept in the kubecm.io/metadata extension of each context, so it
+stays in the kubeconfig file with the context. It is shown by list and the
+context selection, and matched by the tag=, tier= and description= terms of
+--selector.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return tc.runTag(cmd, args)
+		},
+		Example: tagExample(),
+	}
+	tc.command.Flags().String("tier", "", "environment tier, one of: dev, staging, prod, or empty to remove it")
+	tc.command.Flags().String("description", "", "description of the contexts, or empty to remove it")
+	tc.command.Flags().StringSlice("remove", nil, "tags to remove")
+	tc.command.Flags().Bool("clear", false, "remove all the metadata of the contexts")
+	addSelectorFlag(tc.command.Flags())
+	tc.AddCommands(&DocsCommand{})
+}
+
+// metadataChange holds the changes tag makes to the metadata of contexts.
+type metadataChange struct {
+	add         []string
+	remove      []string
+	tier        *string
+	description *string
+	clear       bool
+}
+
+func (c metadataChange) isEmpty() bool {
+	return len(c.add) == 0 && len(c.remove) == 0 && c.tier == nil && c.description == nil && !c.clear
+}
+
+func (c metadataChange) apply(m metadata.Metadata) metadata.Metadata {
+	if c.clear {
+		m = metadata.Metadata{}
+	}
+	if c.tier != nil {
+		m.Tier = *c.tier
+	}
+	if c.description != nil {
+		m.Description = *c.description
+	}
+	m.RemoveTags(c.remove...)
+	m.AddTags(c.add...)
+	return m
+}
+
+func (tc *TagCommand) runTag(cmd *cobra.Command, args []string) error {
+	sel, err := selectorFlag(cmd.Flags())
+	if err != nil {
+		return err
+	}
+	change := metadataChange{}
+	change.remove, _ = cmd.Flags().GetStringSlice("remove")
+	change.clear, _ = cmd.Flags().GetBool("clear")
+	if cmd.Flags().Changed("tier") {
+		tier, _ := cmd.Flags().GetString("tier")
+		if err := metadata.ValidateTier(tier); err != nil {
+			return err
+		}
+		change.tier = &tier
+	}
+	if cmd.Flags().Changed("description") {
+		description, _ := cmd.Flags().GetString("description")
+		change.description = &description
+	}
+
+	kubeconfig, err := SelectKubeconfigFile("Select the kubeconfig file to tag contexts from")
+	if err != nil {
+		return err
+	}
+	config, err := clientcmd.LoadFromFile(kubeconfig)
+	if err != nil {
+		return err
+	}
+	var names []string
+	switch {
+	case len(sel) > 0:
+		names = sel.selectContexts(config)
+		if len(names) == 0 {
+			return errors.New("no contexts matched the selector")
+		}
+		change.add = args
+	case len(args) > 0:
+		if _, ok := config.Contexts[args[0]]; !ok {
+			return fmt.Errorf("cannot find context named 「%s」", args[0])
+		}
+		names = []string{args[0]}
+		change.add = args[1:]
+	case change.isEmpty():
+		// list the metadata of every context
+		names = sel.selectContexts(config)
+	default:
+		kubeItems, err := ExitOption(contextNeedles(config, nil))
+		if err != nil {
+			return err
+		}
+		num := SelectUI(kubeItems, "Select The Kube Context To Tag")
+		names = []string{kubeItems[num].Name}
+	}
+	for _, tag := range change.add {
+		if err := metadata.ValidateTag(tag); err != nil {
+			return err
+		}
+	}
+
+	if !change.isEmpty() {
+		for _, name := range names {
+			ctx := config.Contexts[name]
+			m, err := metadata.Get(ctx)
+			if err != nil {
+				return fmt.Errorf("context 「%s」: %w", name, err)
+			}
+			if err := metadata.Set(ctx, change.apply(m)); err != nil {
+				return err
+			}
+		}
+		if err := UpdateConfigFile(kubeconfig, config); err != nil {
+			return err
+		}
+	}
+	printMetadataTable(config, names)
+	return nil
+}
+
+func printMetadataTable(config *clientcmdapi.Config, names []string) {
+	var table [][]string
+	for _, name := range names {
+		m := metadata.Lookup(config.Contexts[name])
+		table = append(table, []string{name, m.Tier, strings.Join(m.Tags, ","), m.Description})
+	}
+	printRegistryTable([]string{"CONTEXT", "TIER", "TAGS", "DESCRIPTION"}, table)
+}
+
+func tagExample() string {
+	return `
+# List the metadata of the contexts
+kubecm tag
+# Tag a context
+kubecm tag prod-eu team-a payments
+# Describe a context and set its environment tier
+kubecm tag prod-eu --tier prod --description "EU production, on call: #payments-oncall"
+# Remove a tag
+kubecm tag prod-eu --remove payments
+# Set the tier of all the contexts of the EKS clusters
+kubecm tag -l 'server=*.eks.amazonaws.com' --tier prod
+# Tag a context selected interactively
+kubecm tag --tier dev
+# Use the metadata to select contexts
+kubecm switch -l tier=prod -l tag=team-a
+kubecm ls -l tier=dev
+`
+}
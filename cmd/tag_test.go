@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sunny0826/kubecm/pkg/metadata"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+func Test_metadataChange(t *testing.T) {
+	prod, empty := metadata.TierProd, ""
+	m := metadata.Metadata{Tier: metadata.TierDev, Description: "old", Tags: []string{"a", "b"}}
+
+	got := metadataChange{add: []string{"c"}, remove: []string{"a"}, tier: &prod}.apply(m)
+	want := metadata.Metadata{Tier: metadata.TierProd, Description: "old", Tags: []string{"b", "c"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("apply() = %+v, want %+v", got, want)
+	}
+	if got := (metadataChange{description: &empty}).apply(m); got.Description != "" || got.Tier != metadata.TierDev {
+		t.Errorf("apply() removing the description = %+v", got)
+	}
+	if got := (metadataChange{clear: true, add: []string{"x"}}).apply(m); !reflect.DeepEqual(got, metadata.Metadata{Tags: []string{"x"}}) {
+		t.Errorf("apply() clearing = %+v", got)
+	}
+	if !(metadataChange{}).isEmpty() || (metadataChange{tier: &empty}).isEmpty() {
+		t.Error("isEmpty() is wrong")
+	}
+}
+
+// taggedTestConfig returns a copy of selectorTestConfig with context metadata.
+func taggedTestConfig(t *testing.T) *clientcmdapi.Config {
+	t.Helper()
+	config := selectorTestConfig.DeepCopy()
+	for name, m := range map[string]metadata.Metadata{
+		"prod-eu":  {Tier: metadata.TierProd, Tags: []string{"team-a"}, Description: "EU production"},
+		"prod-ops": {Tier: metadata.TierProd, Tags: []string{"team-b"}},
+		"dev-kind": {Tier: metadata.TierDev, Tags: []string{"team-a"}},
+	} {
+		if err := metadata.Set(config.Contexts[name], m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return config
+}
+
+func Test_metadataSelector(t *testing.T) {
+	config := taggedTestConfig(t)
+	tests := []struct {
+		name  string
+		terms []string
+		want  []string
+	}{
+		{"tier", []string{"tier=prod"}, []string{"prod-eu", "prod-ops"}},
+		{"tag", []string{"tag=team-a"}, []string{"dev-kind", "prod-eu"}},
+		{"tier and tag", []string{"tier=prod", "tag=team-a"}, []string{"prod-eu"}},
+		{"untagged", []string{"tag="}, []string{"test-kind"}},
+		{"description", []string{"description~(?i)eu"}, []string{"prod-eu"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sel, err := parseSelector(tt.terms)
+			if err != nil {
+				t.Fatalf("parseSelector() error = %v", err)
+			}
+			if got := sel.selectContexts(config); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("selectContexts() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_metadataFollowsContext(t *testing.T) {
+	config, err := renameComplete("prod-west", "prod-eu", taggedTestConfig(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := metadata.Lookup(config.Contexts["prod-west"]).Tags; !reflect.DeepEqual(got, []string{"team-a"}) {
+		t.Errorf("tags of the renamed context = %v", got)
+	}
+	if err := deleteContext([]string{"prod-ops"}, config); err != nil {
+		t.Fatal(err)
+	}
+	// a new context taking the name of a deleted one starts without metadata
+	config.Contexts["prod-ops"] = &clientcmdapi.Context{Cluster: "kind-dev", AuthInfo: "kind-user"}
+	sel, _ := parseSelector([]string{"tier=prod"})
+	if got := sel.selectContexts(config); !reflect.DeepEqual(got, []string{"prod-west"}) {
+		t.Errorf("selectContexts() = %v", got)
+	}
+}
+
+func Test_hasTierOrTag(t *testing.T) {
+	item := Needle{Name: "prod-eu", Tier: metadata.TierProd, Tags: "team-a,payments"}
+	for label, want := range map[string]bool{
+		"prod":     true,
+		"team-a":   true,
+		"payments": true,
+		"d":        false,
+		"team":     false,
+		",":        false,
+		"":         false,
+	} {
+		if got := hasTierOrTag(item, label); got != want {
+			t.Errorf("hasTierOrTag(%q) = %v, want %v", label, got, want)
+		}
+	}
+	if hasTierOrTag(Needle{Name: "dev"}, "") {
+		t.Error("hasTierOrTag() matched a context without metadata")
+	}
+}
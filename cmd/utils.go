@@ -12,6 +12,7 @@ import (
 	"os/user"
 	"path/filepath"
 	r "runtime"
+	"slices"
 	"sort"
 	"strings"
 	"time"
@@ -24,6 +25,7 @@ import (
 	ct "github.com/daviddengcn/go-colortext"
 	"github.com/imdario/mergo"
 	"github.com/manifoldco/promptui"
+	"github.com/sunny0826/kubecm/pkg/metadata"
 	kubecmVersion "github.com/sunny0826/kubecm/version"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -40,6 +42,10 @@ type Needle struct {
 	Cluster string
 	User    string
 	Center  string
+	// Tier, Tags and Description are the metadata set by kubecm tag
+	Tier        string
+	Tags        string
+	Description string
 }
 
 type KubeconfigFiles struct {
@@ -121,6 +127,8 @@ func HashSufString(data string) string {
 type PrintOption struct {
 	ShortServer bool
 	NoServer    bool
+	// Metadata adds the tier and tags columns of the contexts
+	Metadata bool
 }
 
 // PrintTable print table
@@ -162,6 +170,10 @@ func PrintTable(w io.Writer, config *clientcmdapi.Config, option *PrintOption) e
 			conTmp = append(conTmp, server)
 		}
 		conTmp = append(conTmp, namespace)
+		if option.Metadata {
+			m := metadata.Lookup(ctx[k])
+			conTmp = append(conTmp, m.Tier, strings.Join(m.Tags, ","))
+		}
 		table = append(table, conTmp)
 	}
 
@@ -172,6 +184,9 @@ func PrintTable(w io.Writer, config *clientcmdapi.Config, option *PrintOption) e
 			headers = append(headers, "SERVER")
 		}
 		headers = append(headers, "Namespace")
+		if option.Metadata {
+			headers = append(headers, "TIER", "TAGS")
+		}
 		tabulate.SetHeaders(headers)
 		// Turn On String Wrapping
 		tabulate.SetWrapStrings(true)
@@ -232,7 +247,16 @@ func selectUIRunner(kubeItems []Needle, label string, runner SelectRunner) (int,
 --------- Info ----------
 {{ "Name:" | faint }}	{{ .Name }}
 {{ "Cluster:" | faint }}	{{ .Cluster }}
-{{ "User:" | faint }}	{{ .User }}`,
+{{ "User:" | faint }}	{{ .User }}
+{{- with .Tier }}
+{{ "Tier:" | faint }}	{{ . }}
+{{- end }}
+{{- with .Tags }}
+{{ "Tags:" | faint }}	{{ . }}
+{{- end }}
+{{- with .Description }}
+{{ "Description:" | faint }}	{{ . }}
+{{- end }}`,
 	}
 	searcher := func(input string, index int) bool {
 		pepper := kubeItems[index]
@@ -241,7 +265,11 @@ func selectUIRunner(kubeItems []Needle, label string, runner SelectRunner) (int,
 		if input == "q" && name == "<exit>" {
 			return true
 		}
-		return fuzzy.Match(input, name)
+		if fuzzy.Match(input, name) {
+			return true
+		}
+		// typing a tier or a tag also finds the contexts having it
+		return hasTierOrTag(pepper, input)
 	}
 	prompt := promptui.Select{
 		Label:     label,
@@ -263,6 +291,17 @@ func selectUIRunner(kubeItems []Needle, label string, runner SelectRunner) (int,
 	return i, err
 }
 
+// hasTierOrTag reports whether the tier or one of the tags of a context is
+// label, ignoring case.
+func hasTierOrTag(item Needle, label string) bool {
+	if label == "" {
+		return false
+	}
+	return slices.ContainsFunc(append(strings.Split(item.Tags, ","), item.Tier), func(s string) bool {
+		return strings.EqualFold(s, label)
+	})
+}
+
 // SelectKubeconfigFile displays a file selection UI and returns the full path of the selected kubeconfig file.
 func SelectKubeconfigFile(label string) (string, error) {
 	var kubeItems []KubeconfigFiles
@@ -276,6 +315,9 @@ func SelectKubeconfigFile(label string) (string, error) {
 	if len(kubeconfigFiles) == 1 {
 		return kubeconfigFiles[0], nil
 	}
+	if nonInteractive {
+		return "", fmt.Errorf("--config lists %d kubeconfig files, pass a single one in non-interactive mode", len(kubeconfigFiles))
+	}
 	// exit option
 	kubeItems, err := ExitOptionKubefiles(kubeItems)
 	if err != nil {
@@ -350,6 +392,21 @@ func PromptUI(label string, name string) string {
 	return result
 }
 
+// PassphraseUI asks for a passphrase without echoing it
+func PassphraseUI(label string) (string, error) {
+	prompt := promptui.Prompt{
+		Label: label,
+		Mask:  '*',
+		Validate: func(input string) error {
+			if input == "" {
+				return errors.New("passphrase must not be empty")
+			}
+			return nil
+		},
+	}
+	return promptUIWithRunner(&prompt)
+}
+
 // promptUIWithRunner
 func promptUIWithRunner(runner PromptRunner) (string, error) {
 	result, err := runner.Run()
@@ -360,6 +417,16 @@ func promptUIWithRunner(runner PromptRunner) (string, error) {
 	return result, nil
 }
 
+// isInteractive reports whether prompts can be shown: not with
+// --non-interactive, nor when stdin is not a terminal, as in scripts.
+func isInteractive() bool {
+	if nonInteractive {
+		return false
+	}
+	fi, err := os.Stdin.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
 // BoolUI output bool ui
 func BoolUI(label string) string {
 	templates := &promptui.SelectTemplates{
@@ -207,14 +207,14 @@ func testSetNilMapsToEmpties(curr reflect.Value) {
 
 func TestExitOption(t *testing.T) {
 	gotNeedles := []Needle{
-		{"test1", "test2", "any", "*"},
-		{"test", "test2", "any", ""},
+		{Name: "test1", Cluster: "test2", User: "any", Center: "*"},
+		{Name: "test", Cluster: "test2", User: "any"},
 	}
 	u, _ := user.Current()
 	wantNeedles := []Needle{
-		{"test1", "test2", "any", "*"},
-		{"test", "test2", "any", ""},
-		{"<Exit>", "exit the kubecm", u.Username, ""},
+		{Name: "test1", Cluster: "test2", User: "any", Center: "*"},
+		{Name: "test", Cluster: "test2", User: "any"},
+		{Name: "<Exit>", Cluster: "exit the kubecm", User: u.Username},
 	}
 	type args struct {
 		kubeItems []Needle
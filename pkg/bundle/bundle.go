@@ -0,0 +1,136 @@
+// Package bundle encrypts kubeconfig files with age, so that they can be
+// shared as bundles and decrypted again when they are added.
+package bundle
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/sunny0826/kubecm/pkg/configdir"
+)
+
+// IdentityFile is the name of the default age identity file in the kubecm
+// config directory.
+const IdentityFile = "identity.txt"
+
+// binaryHeader starts the unarmored age format.
+const binaryHeader = "age-encryption.org/v1"
+
+// DefaultIdentityFile returns ~/.kubecm/identity.txt
+func DefaultIdentityFile() (string, error) {
+	dir, err := configdir.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, IdentityFile), nil
+}
+
+// IsEncrypted reports whether data is an age file, armored or not.
+func IsEncrypted(data []byte) bool {
+	data = bytes.TrimLeft(data, " \t\r\n")
+	return bytes.HasPrefix(data, []byte(armor.Header)) || bytes.HasPrefix(data, []byte(binaryHeader))
+}
+
+// Encrypt encrypts data to the age recipients (age1... public keys) or, when
+// there are none, with passphrase. The result is ASCII armored so that it
+// can be pasted into a chat or a ticket.
+func Encrypt(data []byte, recipients []string, passphrase string) ([]byte, error) {
+	var rcpts []age.Recipient
+	for _, r := range recipients {
+		rcpt, err := age.ParseX25519Recipient(r)
+		if err != nil {
+			return nil, fmt.Errorf("invalid recipient %q: %w", r, err)
+		}
+		rcpts = append(rcpts, rcpt)
+	}
+	if len(rcpts) == 0 {
+		if passphrase == "" {
+			return nil, errors.New("a recipient or a passphrase is required")
+		}
+		rcpt, err := age.NewScryptRecipient(passphrase)
+		if err != nil {
+			return nil, err
+		}
+		rcpts = append(rcpts, rcpt)
+	}
+
+	var buf bytes.Buffer
+	armored := armor.NewWriter(&buf)
+	w, err := age.Encrypt(armored, rcpts...)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	if err := armored.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// LoadIdentities reads the age identities (AGE-SECRET-KEY-1... lines) of an
+// identity file as written by age-keygen.
+func LoadIdentities(path string) ([]age.Identity, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	identities, err := age.ParseIdentities(f)
+	if err != nil {
+		return nil, fmt.Errorf("reading identity file %s: %w", path, err)
+	}
+	return identities, nil
+}
+
+// Decrypt decrypts an armored or binary age file with identities. For a
+// passphrase encrypted file passphrase is called, only then, to ask for it.
+func Decrypt(data []byte, identities []age.Identity, passphrase func() (string, error)) ([]byte, error) {
+	if passphrase != nil {
+		identities = append(identities, &passphraseIdentity{passphrase: passphrase})
+	}
+	if len(identities) == 0 {
+		return nil, errors.New("no identity to decrypt the bundle with")
+	}
+	var src io.Reader = bytes.NewReader(data)
+	trimmed := bytes.TrimLeft(data, " \t\r\n")
+	if bytes.HasPrefix(trimmed, []byte(armor.Header)) {
+		src = armor.NewReader(bytes.NewReader(trimmed))
+	}
+	r, err := age.Decrypt(src, identities...)
+	if err != nil {
+		return nil, fmt.Errorf("decrypting bundle: %w", err)
+	}
+	return io.ReadAll(r)
+}
+
+// passphraseIdentity is a scrypt identity asking for its passphrase only
+// when the file was encrypted with one.
+type passphraseIdentity struct {
+	passphrase func() (string, error)
+}
+
+func (p *passphraseIdentity) Unwrap(stanzas []*age.Stanza) ([]byte, error) {
+	if len(stanzas) != 1 || stanzas[0].Type != "scrypt" {
+		return nil, age.ErrIncorrectIdentity
+	}
+	passphrase, err := p.passphrase()
+	if err != nil {
+		return nil, err
+	}
+	identity, err := age.NewScryptIdentity(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return identity.Unwrap(stanzas)
+}
@@ -0,0 +1,127 @@
+package bundle
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+)
+
+var kubeconfig = []byte("apiVersion: v1\nkind: Config\n")
+
+func TestEncryptRecipients(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := Encrypt(kubeconfig, []string{identity.Recipient().String()}, "")
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	if !IsEncrypted(data) {
+		t.Fatalf("IsEncrypted() = false for %q", data)
+	}
+	got, err := Decrypt(data, []age.Identity{identity}, nil)
+	if err != nil {
+		t.Fatalf("Decrypt() error = %v", err)
+	}
+	if string(got) != string(kubeconfig) {
+		t.Errorf("Decrypt() = %q, want %q", got, kubeconfig)
+	}
+
+	other, _ := age.GenerateX25519Identity()
+	if _, err := Decrypt(data, []age.Identity{other}, nil); err == nil {
+		t.Error("Decrypt() with another identity succeeded")
+	}
+}
+
+func TestEncryptPassphrase(t *testing.T) {
+	data, err := Encrypt(kubeconfig, nil, "secret")
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	asked := 0
+	got, err := Decrypt(data, nil, func() (string, error) {
+		asked++
+		return "secret", nil
+	})
+	if err != nil {
+		t.Fatalf("Decrypt() error = %v", err)
+	}
+	if string(got) != string(kubeconfig) || asked != 1 {
+		t.Errorf("Decrypt() = %q after %d prompts", got, asked)
+	}
+	if _, err := Decrypt(data, nil, func() (string, error) { return "wrong", nil }); err == nil {
+		t.Error("Decrypt() with a wrong passphrase succeeded")
+	}
+}
+
+func TestDecryptDoesNotAskForRecipientBundles(t *testing.T) {
+	identity, _ := age.GenerateX25519Identity()
+	data, err := Encrypt(kubeconfig, []string{identity.Recipient().String()}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	askErr := errors.New("asked for a passphrase")
+	if _, err := Decrypt(data, []age.Identity{identity}, func() (string, error) { return "", askErr }); err != nil {
+		t.Errorf("Decrypt() error = %v", err)
+	}
+}
+
+func TestEncryptErrors(t *testing.T) {
+	if _, err := Encrypt(kubeconfig, nil, ""); err == nil {
+		t.Error("Encrypt() without recipient or passphrase succeeded")
+	}
+	if _, err := Encrypt(kubeconfig, []string{"age1invalid"}, ""); err == nil {
+		t.Error("Encrypt() with an invalid recipient succeeded")
+	}
+}
+
+func TestIsEncrypted(t *testing.T) {
+	tests := []struct {
+		data string
+		want bool
+	}{
+		{"-----BEGIN AGE ENCRYPTED FILE-----\nYWdl\n", true},
+		{"\nage-encryption.org/v1\n-> X25519 abc\n", true},
+		{string(kubeconfig), false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := IsEncrypted([]byte(tt.data)); got != tt.want {
+			t.Errorf("IsEncrypted(%q) = %v, want %v", tt.data, got, tt.want)
+		}
+	}
+}
+
+func TestLoadIdentities(t *testing.T) {
+	identity, _ := age.GenerateX25519Identity()
+	path := filepath.Join(t.TempDir(), IdentityFile)
+	content := "# created: 2024-01-01\n# public key: " + identity.Recipient().String() + "\n" + identity.String() + "\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	identities, err := LoadIdentities(path)
+	if err != nil {
+		t.Fatalf("LoadIdentities() error = %v", err)
+	}
+	if len(identities) != 1 {
+		t.Errorf("LoadIdentities() returned %d identities", len(identities))
+	}
+	if _, err := LoadIdentities(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("LoadIdentities() of a missing file succeeded")
+	}
+}
+
+func TestDefaultIdentityFile(t *testing.T) {
+	t.Setenv("KUBECM_HOME", "/tmp/kubecm-home")
+	got, err := DefaultIdentityFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join("/tmp/kubecm-home", IdentityFile); got != want {
+		t.Errorf("DefaultIdentityFile() = %q, want %q", got, want)
+	}
+}
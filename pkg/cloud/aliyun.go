@@ -1,28 +1,140 @@
 package cloud
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"time"
 
 	ack "github.com/alibabacloud-go/cs-20151215/v2/client"
 	openapi "github.com/alibabacloud-go/darabonba-openapi/client"
 	"github.com/alibabacloud-go/tea/tea"
+	credential "github.com/aliyun/credentials-go/credentials"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 )
 
+var aliCloudSpec = ProviderSpec{
+	Name:     "AlibabaCloud",
+	Key:      "alicloud",
+	Aliases:  []string{"alibabacloud", "alicloud", "aliyun", "ack"},
+	HomePage: "https://cs.console.aliyun.com",
+	Service:  "ACK",
+	AuthModes: []AuthMode{
+		{
+			Name: "Access Key",
+			Credentials: []CredentialSource{
+				{Env: "ACCESS_KEY_ID", Prompt: "AlibabaCloud Access Key ID"},
+				{Env: "ACCESS_KEY_SECRET", Prompt: "AlibabaCloud Access Key Secret"},
+			},
+		},
+		{
+			Name: "STS Token",
+			Credentials: []CredentialSource{
+				{Env: "ACCESS_KEY_ID", Prompt: "AlibabaCloud STS Access Key ID"},
+				{Env: "ACCESS_KEY_SECRET", Prompt: "AlibabaCloud STS Access Key Secret"},
+				{Env: "ALIBABA_CLOUD_SECURITY_TOKEN", Prompt: "AlibabaCloud STS Security Token"},
+			},
+		},
+		{
+			Name: "RAM Role",
+			Credentials: []CredentialSource{
+				{Env: "ACCESS_KEY_ID", Prompt: "AlibabaCloud Access Key ID"},
+				{Env: "ACCESS_KEY_SECRET", Prompt: "AlibabaCloud Access Key Secret"},
+				{Env: "ALIBABA_CLOUD_ROLE_ARN", Prompt: "AlibabaCloud RAM Role ARN"},
+				{Env: "ALIBABA_CLOUD_ROLE_SESSION_NAME", Optional: true},
+			},
+		},
+	},
+	RegionEnv:                    []string{"ALIBABA_CLOUD_REGION_ID"},
+	SupportsPrivateEndpoint:      true,
+	SupportsTemporaryCredentials: true,
+	// ACK accepts temporary kubeconfigs of 15 to 4320 minutes
+	MinTemporaryDuration: 15 * time.Minute,
+	MaxTemporaryDuration: 4320 * time.Minute,
+	New: func(cfg ProviderConfig) (Provider, error) {
+		return &AliCloud{
+			AuthMode:          AliCloudAuth(cfg.AuthMode),
+			AccessKeyID:       cfg.Credentials["ACCESS_KEY_ID"],
+			AccessKeySecret:   cfg.Credentials["ACCESS_KEY_SECRET"],
+			SecurityToken:     cfg.Credentials["ALIBABA_CLOUD_SECURITY_TOKEN"],
+			RoleARN:           cfg.Credentials["ALIBABA_CLOUD_ROLE_ARN"],
+			RoleSessionName:   cfg.Credentials["ALIBABA_CLOUD_ROLE_SESSION_NAME"],
+			RegionID:          cfg.Region,
+			PrivateEndpoint:   cfg.PrivateEndpoint,
+			TemporaryDuration: cfg.TemporaryDuration,
+		}, nil
+	},
+}
+
+// AliCloudAuth authentication mode for Alibaba Cloud
+type AliCloudAuth int
+
+const (
+	// AliCloudAuthAccessKey uses an AccessKey pair
+	AliCloudAuthAccessKey AliCloudAuth = iota
+	// AliCloudAuthSTSToken uses a temporary AccessKey pair and its STS token
+	AliCloudAuthSTSToken
+	// AliCloudAuthRAMRole assumes a RAM role with an AccessKey pair
+	AliCloudAuthRAMRole
+)
+
+// aliDefaultRegion is the region of the API endpoint when none is set. The
+// clusters of all regions are listed from any endpoint.
+const aliDefaultRegion = "cn-hongkong"
+
 // AliCloud struct of alibaba cloud
 type AliCloud struct {
+	AuthMode        AliCloudAuth
 	AccessKeyID     string
 	AccessKeySecret string
+	SecurityToken   string // for AliCloudAuthSTSToken
+	RoleARN         string // for AliCloudAuthRAMRole
+	RoleSessionName string // for AliCloudAuthRAMRole, defaults to kubecm
+	// RegionID restricts listing to the clusters of a region.
+	RegionID string
+	// PrivateEndpoint fetches kubeconfigs using the intranet API server
+	// address, for access from within the VPC.
+	PrivateEndpoint bool
+	// TemporaryDuration fetches kubeconfigs whose credentials expire after
+	// it, rounded down to minutes; zero fetches long-lived ones.
+	TemporaryDuration time.Duration
 }
 
-// getClient get aliyun openapi client
-func getClient(accessKeyID, accessKeySecret string) (*ack.Client, error) {
+// getClient get aliyun openapi client for the API endpoint of a region
+func (a *AliCloud) getClient(regionID string) (*ack.Client, error) {
+	if regionID == "" {
+		regionID = aliDefaultRegion
+	}
 	config := &openapi.Config{
-		AccessKeyId:     &accessKeyID,
-		AccessKeySecret: &accessKeySecret,
-		RegionId:        tea.String("cn-hongkong"),
+		AccessKeyId:     tea.String(a.AccessKeyID),
+		AccessKeySecret: tea.String(a.AccessKeySecret),
+		RegionId:        tea.String(regionID),
+	}
+	switch a.AuthMode {
+	case AliCloudAuthAccessKey:
+	case AliCloudAuthSTSToken:
+		config.SecurityToken = tea.String(a.SecurityToken)
+	case AliCloudAuthRAMRole:
+		sessionName := a.RoleSessionName
+		if sessionName == "" {
+			sessionName = "kubecm"
+		}
+		cred, err := credential.NewCredential(&credential.Config{
+			Type:            tea.String("ram_role_arn"),
+			AccessKeyId:     tea.String(a.AccessKeyID),
+			AccessKeySecret: tea.String(a.AccessKeySecret),
+			RoleArn:         tea.String(a.RoleARN),
+			RoleSessionName: tea.String(sessionName),
+		})
+		if err != nil {
+			return nil, err
+		}
+		config.Credential = cred
+	default:
+		return nil, fmt.Errorf("invalid AlibabaCloud auth mode: %d", a.AuthMode)
 	}
-	result, err := ack.NewClient(config)
-	return result, err
+	return ack.NewClient(config)
 }
 
 // GetRegionID get region id of ack cluster
@@ -33,7 +145,7 @@ func (a *AliCloud) GetRegionID() ([]string, error) {
 
 // ListCluster list cluster info
 func (a *AliCloud) ListCluster() (clusters []ClusterInfo, err error) {
-	client, err := getClient(a.AccessKeyID, a.AccessKeySecret)
+	client, err := a.getClient(a.RegionID)
 	if err != nil {
 		return nil, err
 	}
@@ -44,27 +156,99 @@ func (a *AliCloud) ListCluster() (clusters []ClusterInfo, err error) {
 	}
 	var clusterList []ClusterInfo
 	for _, info := range v1.Body.Clusters {
+		if a.RegionID != "" && tea.StringValue(info.RegionId) != a.RegionID {
+			continue
+		}
 		clusterList = append(clusterList, ClusterInfo{
 			Name:       *info.Name,
 			ID:         *info.ClusterId,
 			RegionID:   *info.RegionId,
 			K8sVersion: *info.CurrentVersion,
 			ConsoleURL: fmt.Sprintf("https://cs.console.aliyun.com/#/k8s/cluster/%s/v2/info/overview", *info.ClusterId),
+			Tags:       aliTags(info.Tags),
+			Status:     tea.StringValue(info.State),
+			NodeCount:  int(tea.Int64Value(info.Size)),
+			Endpoint:   aliEndpoint(tea.StringValue(info.MasterUrl)),
+			CreatedAt:  parseCreated(tea.StringValue(info.Created)),
 		})
 	}
 	return clusterList, err
 }
 
+func aliTags(tags []*ack.Tag) map[string]string {
+	if len(tags) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(tags))
+	for _, tag := range tags {
+		out[tea.StringValue(tag.Key)] = tea.StringValue(tag.Value)
+	}
+	return out
+}
+
+// aliEndpoint tells the endpoint visibility from the master_url JSON of
+// an ACK cluster.
+func aliEndpoint(masterURL string) string {
+	var urls struct {
+		Public   string `json:"api_server_endpoint"`
+		Intranet string `json:"intranet_api_server_endpoint"`
+	}
+	if err := json.Unmarshal([]byte(masterURL), &urls); err != nil {
+		return ""
+	}
+	switch {
+	case urls.Public != "":
+		return EndpointPublic
+	case urls.Intranet != "":
+		return EndpointPrivate
+	}
+	return ""
+}
+
 // GetKubeConfig get kubeConfig file
 func (a *AliCloud) GetKubeConfig(clusterID string) (string, error) {
-	client, err := getClient(a.AccessKeyID, a.AccessKeySecret)
+	return a.getKubeConfig(clusterID, a.RegionID)
+}
+
+func (a *AliCloud) getKubeConfig(clusterID, regionID string) (string, error) {
+	client, err := a.getClient(regionID)
 	if err != nil {
 		return "", err
 	}
 	describeClusterUserKubeconfigRequest := &ack.DescribeClusterUserKubeconfigRequest{}
+	if a.PrivateEndpoint {
+		describeClusterUserKubeconfigRequest.PrivateIpAddress = tea.Bool(true)
+	}
+	if a.TemporaryDuration > 0 {
+		describeClusterUserKubeconfigRequest.TemporaryDurationMinutes = tea.Int64(int64(a.TemporaryDuration / time.Minute))
+	}
 	res, err := client.DescribeClusterUserKubeconfig(tea.String(clusterID), describeClusterUserKubeconfigRequest)
 	if err != nil {
 		return "", err
 	}
 	return *(res.Body.Config), err
 }
+
+// ListClusters implements Provider.
+func (a *AliCloud) ListClusters(ctx context.Context) ([]ClusterInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return a.ListCluster()
+}
+
+// GetKubeconfig implements Provider.
+func (a *AliCloud) GetKubeconfig(ctx context.Context, cluster ClusterInfo) (*clientcmdapi.Config, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	regionID := cluster.RegionID
+	if regionID == "" {
+		regionID = a.RegionID
+	}
+	kubeconfig, err := a.getKubeConfig(cluster.ID, regionID)
+	if err != nil {
+		return nil, err
+	}
+	return clientcmd.Load([]byte(kubeconfig))
+}
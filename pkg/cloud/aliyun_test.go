@@ -0,0 +1,33 @@
+package cloud
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAliCloud_getClient(t *testing.T) {
+	for _, a := range []*AliCloud{
+		{AuthMode: AliCloudAuthAccessKey, AccessKeyID: "id", AccessKeySecret: "secret"},
+		{AuthMode: AliCloudAuthSTSToken, AccessKeyID: "id", AccessKeySecret: "secret", SecurityToken: "token"},
+		{AuthMode: AliCloudAuthRAMRole, AccessKeyID: "id", AccessKeySecret: "secret", RoleARN: "acs:ram::1:role/ack"},
+	} {
+		client, err := a.getClient("cn-hangzhou")
+		require.NoError(t, err)
+		assert.Equal(t, "cn-hangzhou", *client.RegionId)
+	}
+
+	client, err := (&AliCloud{}).getClient("")
+	require.NoError(t, err)
+	assert.Equal(t, aliDefaultRegion, *client.RegionId)
+
+	_, err = (&AliCloud{AuthMode: AliCloudAuth(9)}).getClient("")
+	assert.EqualError(t, err, "invalid AlibabaCloud auth mode: 9")
+}
+
+func TestAliEndpoint(t *testing.T) {
+	assert.Equal(t, EndpointPublic, aliEndpoint(`{"api_server_endpoint":"https://1.2.3.4:6443","intranet_api_server_endpoint":"https://10.0.0.1:6443"}`))
+	assert.Equal(t, EndpointPrivate, aliEndpoint(`{"api_server_endpoint":"","intranet_api_server_endpoint":"https://10.0.0.1:6443"}`))
+	assert.Equal(t, "", aliEndpoint(""))
+}
@@ -3,8 +3,10 @@ package cloud
 import (
 	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"sort"
+	"sync"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
@@ -24,6 +26,61 @@ const (
 	AWSAuthStaticCredentials
 )
 
+// Commands generating EKS tokens in the exec config of a kubeconfig.
+const (
+	AWSTokenCommandCLI              = "aws"
+	AWSTokenCommandIAMAuthenticator = "aws-iam-authenticator"
+)
+
+var awsSpec = ProviderSpec{
+	Name:     "AWS",
+	Key:      "aws",
+	Aliases:  []string{"aws", "eks"},
+	HomePage: "https://console.aws.amazon.com/eks/home",
+	Service:  "EKS",
+	AuthModes: []AuthMode{
+		{
+			Name: "Default (Credential Chain)",
+			Credentials: []CredentialSource{
+				{Env: "AWS_PROFILE", Optional: true},
+			},
+		},
+		{
+			Name: "Static Credentials",
+			Credentials: []CredentialSource{
+				{Env: "AWS_ACCESS_KEY_ID", Prompt: "AWS Access Key ID"},
+				{Env: "AWS_SECRET_ACCESS_KEY", Prompt: "AWS Access Key Secret"},
+			},
+		},
+	},
+	NeedsRegion:   true,
+	RegionEnv:     []string{"AWS_REGION", "AWS_DEFAULT_REGION"},
+	MultiRegion:   true,
+	MultiProfile:  true,
+	TokenCommands: []string{AWSTokenCommandCLI, AWSTokenCommandIAMAuthenticator},
+	Note:          "please install the AWS CLI (or aws-iam-authenticator) before normal use.",
+	Regions: func(ctx context.Context, cfg ProviderConfig) ([]string, error) {
+		return GetRegionID()
+	},
+	New: func(cfg ProviderConfig) (Provider, error) {
+		return &AWS{
+			AuthMode:        AWSAuth(cfg.AuthMode),
+			Profile:         cfg.Credentials["AWS_PROFILE"],
+			AccessKeyID:     cfg.Credentials["AWS_ACCESS_KEY_ID"],
+			AccessKeySecret: cfg.Credentials["AWS_SECRET_ACCESS_KEY"],
+			RegionID:        cfg.Region,
+			Regions:         cfg.Regions,
+			Profiles:        cfg.Profiles,
+			RoleARN:         cfg.RoleARN,
+			TokenCommand:    cfg.TokenCommand,
+			ExecEnv:         cfg.ExecEnv,
+		}, nil
+	},
+}
+
+// awsConcurrency bounds the number of profile/region pairs searched at once.
+const awsConcurrency = 8
+
 // AWS struct of aws cloud
 type AWS struct {
 	AuthMode        AWSAuth
@@ -31,12 +88,54 @@ type AWS struct {
 	AccessKeyID     string // for AWSAuthStaticCredentials
 	AccessKeySecret string // for AWSAuthStaticCredentials
 	RegionID        string
+	Regions         []string // ListClusters searches these regions when set
+	Profiles        []string // ListClusters searches with these profiles when set
+
+	// RoleARN is a role the token command assumes.
+	RoleARN string
+	// TokenCommand is AWSTokenCommandCLI (the default) or
+	// AWSTokenCommandIAMAuthenticator.
+	TokenCommand string
+	// ExecEnv is extra environment of the token command, e.g.
+	// AWS_STS_REGIONAL_ENDPOINTS=regional.
+	ExecEnv map[string]string
 
-	cfg *aws.Config // cached config
+	mu         sync.Mutex  // guards cfg and identities, GetKubeconfig runs concurrently
+	cfg        *aws.Config // cached config
+	identities *identityCache
+}
+
+// identityCache caches the account of the caller per profile, so that it is
+// looked up once however many regions are searched.
+type identityCache struct {
+	mu       sync.Mutex
+	accounts map[string]*identity
+}
+
+type identity struct {
+	once    sync.Once
+	account string
+	err     error
+}
+
+func (c *identityCache) get(key string) *identity {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.accounts == nil {
+		c.accounts = make(map[string]*identity)
+	}
+	id, ok := c.accounts[key]
+	if !ok {
+		id = &identity{}
+		c.accounts[key] = id
+	}
+	return id
 }
 
 // getAWSConfig returns an AWS config, caching after first call.
-func (a *AWS) getAWSConfig() (aws.Config, error) {
+func (a *AWS) getAWSConfig(ctx context.Context) (aws.Config, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
 	if a.cfg != nil {
 		return *a.cfg, nil
 	}
@@ -46,7 +145,6 @@ func (a *AWS) getAWSConfig() (aws.Config, error) {
 		err error
 	)
 
-	ctx := context.Background()
 	opts := []func(*config.LoadOptions) error{}
 
 	if a.RegionID != "" {
@@ -123,42 +221,156 @@ func GetRegionID() ([]string, error) {
 
 // ListCluster lists EKS clusters in the configured region.
 func (a *AWS) ListCluster() ([]ClusterInfo, error) {
-	cfg, err := a.getAWSConfig()
-	if err != nil {
-		return nil, err
+	return a.ListClusters(context.Background())
+}
+
+// ListClusters implements Provider. When Regions or Profiles are set, every
+// profile/region pair is searched concurrently; clusters of the pairs that
+// succeeded are returned along with the errors of the others.
+func (a *AWS) ListClusters(ctx context.Context) ([]ClusterInfo, error) {
+	if len(a.Regions) == 0 && len(a.Profiles) == 0 {
+		return a.listRegion(ctx)
 	}
 
-	ctx := context.Background()
-	svc := eks.NewFromConfig(cfg)
-	input := &eks.ListClustersInput{}
+	profiles := a.Profiles
+	if len(profiles) == 0 {
+		profiles = []string{a.Profile}
+	}
+	regions := a.Regions
+	if len(regions) == 0 {
+		regions = []string{a.RegionID}
+	}
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		clusters []ClusterInfo
+		errs     []error
+	)
+	sem := make(chan struct{}, awsConcurrency)
+	for _, profile := range profiles {
+		for _, region := range regions {
+			wg.Add(1)
+			go func(scoped *AWS) {
+				defer wg.Done()
+				sem <- struct{}{}
+				defer func() { <-sem }()
+
+				found, err := scoped.listRegion(ctx)
+				mu.Lock()
+				defer mu.Unlock()
+				if err != nil {
+					errs = append(errs, fmt.Errorf("%s: %w", scoped.scopeName(), err))
+					return
+				}
+				clusters = append(clusters, found...)
+			}(a.scoped(profile, region))
+		}
+	}
+	wg.Wait()
+
+	sort.Slice(clusters, func(i, j int) bool {
+		if clusters[i].Account != clusters[j].Account {
+			return clusters[i].Account < clusters[j].Account
+		}
+		if clusters[i].RegionID != clusters[j].RegionID {
+			return clusters[i].RegionID < clusters[j].RegionID
+		}
+		return clusters[i].Name < clusters[j].Name
+	})
+	return clusters, errors.Join(errs...)
+}
+
+// scoped returns a copy of a for one profile and region, sharing the
+// identity cache.
+func (a *AWS) scoped(profile, region string) *AWS {
+	return &AWS{
+		AuthMode:        a.AuthMode,
+		Profile:         profile,
+		AccessKeyID:     a.AccessKeyID,
+		AccessKeySecret: a.AccessKeySecret,
+		RegionID:        region,
+		RoleARN:         a.RoleARN,
+		TokenCommand:    a.TokenCommand,
+		ExecEnv:         a.ExecEnv,
+		identities:      a.identityCache(),
+	}
+}
+
+// identityCache returns the identity cache of a, creating it on first use.
+func (a *AWS) identityCache() *identityCache {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.identities == nil {
+		a.identities = &identityCache{}
+	}
+	return a.identities
+}
+
+// scopeName names the profile/region pair in errors.
+func (a *AWS) scopeName() string {
+	if a.Profile == "" {
+		return a.RegionID
+	}
+	return a.Profile + "/" + a.RegionID
+}
 
-	result, err := svc.ListClusters(ctx, input)
+// listRegion lists the EKS clusters of the configured region.
+func (a *AWS) listRegion(ctx context.Context) ([]ClusterInfo, error) {
+	cfg, err := a.getAWSConfig(ctx)
 	if err != nil {
 		return nil, err
 	}
 
+	svc := eks.NewFromConfig(cfg)
 	var clusterList []ClusterInfo
-	for _, clusterName := range result.Clusters {
-		clusterInfo, err := a.getClusterInfo(clusterName)
+	paginator := eks.NewListClustersPaginator(svc, &eks.ListClustersInput{})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
 		if err != nil {
 			return nil, err
 		}
-		clusterList = append(clusterList, clusterInfo)
+		for _, clusterName := range page.Clusters {
+			clusterInfo, err := a.getClusterInfo(ctx, clusterName)
+			if err != nil {
+				return nil, err
+			}
+			clusterList = append(clusterList, clusterInfo)
+		}
 	}
 	return clusterList, nil
 }
 
+// account returns the account of the caller, looked up once per profile.
+func (a *AWS) account(ctx context.Context) (string, error) {
+	key := a.Profile
+	if a.AuthMode == AWSAuthStaticCredentials {
+		key = "static:" + a.AccessKeyID
+	}
+	id := a.identityCache().get(key)
+	id.once.Do(func() {
+		cfg, err := a.getAWSConfig(ctx)
+		if err != nil {
+			id.err = err
+			return
+		}
+		callerIdentity, err := sts.NewFromConfig(cfg).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+		if err != nil {
+			id.err = err
+			return
+		}
+		id.account = aws.ToString(callerIdentity.Account)
+	})
+	return id.account, id.err
+}
+
 // getClusterInfo returns info for a single EKS cluster.
-func (a *AWS) getClusterInfo(clusterName string) (ClusterInfo, error) {
-	cfg, err := a.getAWSConfig()
+func (a *AWS) getClusterInfo(ctx context.Context, clusterName string) (ClusterInfo, error) {
+	cfg, err := a.getAWSConfig(ctx)
 	if err != nil {
 		return ClusterInfo{}, err
 	}
 
-	ctx := context.Background()
-
-	stsSvc := sts.NewFromConfig(cfg)
-	callerIdentity, err := stsSvc.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	account, err := a.account(ctx)
 	if err != nil {
 		return ClusterInfo{}, err
 	}
@@ -171,26 +383,85 @@ func (a *AWS) getClusterInfo(clusterName string) (ClusterInfo, error) {
 		return ClusterInfo{}, err
 	}
 
+	endpoint := EndpointPrivate
+	if vpc := cluster.Cluster.ResourcesVpcConfig; vpc == nil || vpc.EndpointPublicAccess {
+		endpoint = EndpointPublic
+	}
 	return ClusterInfo{
 		ID:         *cluster.Cluster.Name,
-		Account:    *callerIdentity.Account,
+		Account:    account,
 		Name:       *cluster.Cluster.Name,
 		RegionID:   a.RegionID,
 		K8sVersion: *cluster.Cluster.Version,
 		ConsoleURL: fmt.Sprintf("https://%s.console.aws.amazon.com/eks/home?region=%s#/clusters/%s", a.RegionID, a.RegionID, *cluster.Cluster.Name),
+		Profile:    a.Profile,
+		Tags:       cluster.Cluster.Tags,
+		Status:     string(cluster.Cluster.Status),
+		NodeCount:  nodeCount(ctx, eksSvc, clusterName),
+		Endpoint:   endpoint,
+		CreatedAt:  aws.ToTime(cluster.Cluster.CreatedAt),
 	}, nil
 }
 
+// nodeCount sums the desired size of the managed node groups of a cluster.
+// Node groups are informational only, so errors leave the count at what was
+// found so far.
+func nodeCount(ctx context.Context, svc *eks.Client, clusterName string) int {
+	var count int
+	paginator := eks.NewListNodegroupsPaginator(svc, &eks.ListNodegroupsInput{ClusterName: &clusterName})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return count
+		}
+		for _, name := range page.Nodegroups {
+			nodegroup, err := svc.DescribeNodegroup(ctx, &eks.DescribeNodegroupInput{
+				ClusterName:   &clusterName,
+				NodegroupName: aws.String(name),
+			})
+			if err != nil {
+				return count
+			}
+			if scaling := nodegroup.Nodegroup.ScalingConfig; scaling != nil {
+				count += int(aws.ToInt32(scaling.DesiredSize))
+			}
+		}
+	}
+	return count
+}
+
 // GetKubeConfigObj returns a kubeconfig object for the given EKS cluster.
-// If a Profile is set, --profile is added to the exec args so the generated
+// If a Profile is set, it is passed to the token command so the generated
 // kubeconfig works without needing AWS_PROFILE in the environment.
 func (a *AWS) GetKubeConfigObj(clusterID string) (*clientcmdapi.Config, error) {
-	cfg, err := a.getAWSConfig()
+	return a.getKubeConfigObj(context.Background(), clusterID)
+}
+
+// GetKubeconfig implements Provider. Clusters found in another region or
+// with another profile are fetched from there.
+func (a *AWS) GetKubeconfig(ctx context.Context, cluster ClusterInfo) (*clientcmdapi.Config, error) {
+	profile, region := a.Profile, a.RegionID
+	if cluster.Profile != "" {
+		profile = cluster.Profile
+	}
+	if cluster.RegionID != "" {
+		region = cluster.RegionID
+	}
+	if profile != a.Profile || region != a.RegionID {
+		return a.scoped(profile, region).getKubeConfigObj(ctx, cluster.ID)
+	}
+	return a.getKubeConfigObj(ctx, cluster.ID)
+}
+
+func (a *AWS) getKubeConfigObj(ctx context.Context, clusterID string) (*clientcmdapi.Config, error) {
+	if a.TokenCommand != "" && a.TokenCommand != AWSTokenCommandCLI && a.TokenCommand != AWSTokenCommandIAMAuthenticator {
+		return nil, fmt.Errorf("unsupported token command %q", a.TokenCommand)
+	}
+	cfg, err := a.getAWSConfig(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	ctx := context.Background()
 	svc := eks.NewFromConfig(cfg)
 	cluster, err := svc.DescribeCluster(ctx, &eks.DescribeClusterInput{
 		Name: &clusterID,
@@ -204,20 +475,6 @@ func (a *AWS) GetKubeConfigObj(clusterID string) (*clientcmdapi.Config, error) {
 		return nil, err
 	}
 
-	execArgs := []string{
-		"eks",
-		"get-token",
-		"--cluster-name",
-		*cluster.Cluster.Name,
-		"--region",
-		a.RegionID,
-		"--output",
-		"json",
-	}
-	if a.Profile != "" {
-		execArgs = append(execArgs, "--profile", a.Profile)
-	}
-
 	kubeconfig := &clientcmdapi.Config{
 		Clusters: map[string]*clientcmdapi.Cluster{
 			*cluster.Cluster.Name: {
@@ -227,11 +484,7 @@ func (a *AWS) GetKubeConfigObj(clusterID string) (*clientcmdapi.Config, error) {
 		},
 		AuthInfos: map[string]*clientcmdapi.AuthInfo{
 			*cluster.Cluster.Name: {
-				Exec: &clientcmdapi.ExecConfig{
-					APIVersion: "client.authentication.k8s.io/v1beta1",
-					Command:    "aws",
-					Args:       execArgs,
-				},
+				Exec: a.execConfig(*cluster.Cluster.Name),
 			},
 		},
 		Contexts: map[string]*clientcmdapi.Context{
@@ -245,3 +498,54 @@ func (a *AWS) GetKubeConfigObj(clusterID string) (*clientcmdapi.Config, error) {
 
 	return kubeconfig, nil
 }
+
+// execConfig returns the exec config generating tokens for a cluster.
+func (a *AWS) execConfig(clusterName string) *clientcmdapi.ExecConfig {
+	env := make(map[string]string, len(a.ExecEnv)+1)
+	for k, v := range a.ExecEnv {
+		env[k] = v
+	}
+
+	exec := &clientcmdapi.ExecConfig{
+		APIVersion: "client.authentication.k8s.io/v1beta1",
+	}
+	if a.TokenCommand == AWSTokenCommandIAMAuthenticator {
+		// aws-iam-authenticator has no --profile flag
+		if a.Profile != "" {
+			env["AWS_PROFILE"] = a.Profile
+		}
+		exec.Command = AWSTokenCommandIAMAuthenticator
+		exec.Args = []string{"token", "-i", clusterName}
+		if a.RoleARN != "" {
+			exec.Args = append(exec.Args, "-r", a.RoleARN)
+		}
+	} else {
+		exec.Command = AWSTokenCommandCLI
+		exec.Args = []string{
+			"eks",
+			"get-token",
+			"--cluster-name",
+			clusterName,
+			"--region",
+			a.RegionID,
+			"--output",
+			"json",
+		}
+		if a.RoleARN != "" {
+			exec.Args = append(exec.Args, "--role-arn", a.RoleARN)
+		}
+		if a.Profile != "" {
+			exec.Args = append(exec.Args, "--profile", a.Profile)
+		}
+	}
+
+	names := make([]string, 0, len(env))
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		exec.Env = append(exec.Env, clientcmdapi.ExecEnvVar{Name: name, Value: env[name]})
+	}
+	return exec
+}
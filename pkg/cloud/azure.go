@@ -3,14 +3,59 @@ package cloud
 import (
 	"context"
 	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/subscription/armsubscription"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerservice/armcontainerservice/v4"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 )
 
+var azureSpec = ProviderSpec{
+	Name:     "Azure",
+	Key:      "azure",
+	Aliases:  []string{"azure", "aks"},
+	HomePage: "https://portal.azure.com",
+	Service:  "AKS",
+	AuthModes: []AuthMode{
+		{
+			Name: "Default (SDK Auth)",
+			Credentials: []CredentialSource{
+				{Env: "AZURE_TENANT_ID", Prompt: "Azure Tenant ID", Optional: true},
+			},
+		},
+		{
+			Name: "Service Principal",
+			Credentials: []CredentialSource{
+				{Env: "AZURE_TENANT_ID", Prompt: "Azure Tenant ID"},
+				{Env: "AZURE_CLIENT_ID", Prompt: "Azure Client ID"},
+				{Env: "AZURE_CLIENT_SECRET", Prompt: "Azure Client Secret"},
+				{Env: "AZURE_OBJECT_ID", Prompt: "Azure Object ID", Optional: true},
+			},
+		},
+	},
+	SupportsAdmin: true,
+	LoginModes:    AzureLoginModes,
+	New: func(cfg ProviderConfig) (Provider, error) {
+		return &Azure{
+			AuthMode:       AzureAuth(cfg.AuthMode),
+			TenantID:       cfg.Credentials["AZURE_TENANT_ID"],
+			ClientID:       cfg.Credentials["AZURE_CLIENT_ID"],
+			ClientSecret:   cfg.Credentials["AZURE_CLIENT_SECRET"],
+			ObjectID:       cfg.Credentials["AZURE_OBJECT_ID"],
+			SubscriptionID: os.Getenv("AZURE_SUBSCRIPTION_ID"),
+			Admin:          cfg.Admin,
+			LoginMode:      cfg.LoginMode,
+		}, nil
+	},
+}
+
 // Azure struct of azure cloud
 type Azure struct {
 	AuthMode       AzureAuth
@@ -19,7 +64,10 @@ type Azure struct {
 	SubscriptionID string
 	TenantID       string
 	ObjectID       string
+	Admin          bool   // GetKubeconfig returns admin credentials
+	LoginMode      string // GetKubeconfig converts credentials to this kubelogin mode
 
+	mu     sync.Mutex // guards client, GetKubeconfig runs concurrently
 	client azcore.TokenCredential
 }
 type AzureAuth int
@@ -35,6 +83,8 @@ const (
 )
 
 func (a *Azure) getAzureClient() (azcore.TokenCredential, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
 	if a.client != nil {
 		return a.client, nil
 	}
@@ -62,6 +112,10 @@ func (a *Azure) getAzureClient() (azcore.TokenCredential, error) {
 
 // ListSubscriptions list subscriptions
 func (a *Azure) ListSubscriptions() (subscription []AzureSubscription, err error) {
+	return a.listSubscriptions(context.Background())
+}
+
+func (a *Azure) listSubscriptions(ctx context.Context) ([]AzureSubscription, error) {
 	client, err := a.getAzureClient()
 	if err != nil {
 		return nil, err
@@ -77,7 +131,7 @@ func (a *Azure) ListSubscriptions() (subscription []AzureSubscription, err error
 	pager := subscriptionClient.NewListPager(nil)
 
 	for pager.More() {
-		page, err := pager.NextPage(context.Background())
+		page, err := pager.NextPage(ctx)
 		if err != nil {
 			return nil, err
 		}
@@ -94,6 +148,33 @@ func (a *Azure) ListSubscriptions() (subscription []AzureSubscription, err error
 
 // ListCluster list cluster info
 func (a *Azure) ListCluster(subscription AzureSubscription) (clusters []ClusterInfo, err error) {
+	return a.listCluster(context.Background(), subscription)
+}
+
+// ListClusters implements Provider. It lists the clusters of every
+// subscription, or only of SubscriptionID when it is set.
+func (a *Azure) ListClusters(ctx context.Context) ([]ClusterInfo, error) {
+	subscriptionList, err := a.listSubscriptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var clusters []ClusterInfo
+	for _, subscription := range subscriptionList {
+		if a.SubscriptionID != "" && a.SubscriptionID != subscription.ID {
+			continue
+		}
+
+		subscriptionClusters, err := a.listCluster(ctx, subscription)
+		if err != nil {
+			return nil, err
+		}
+		clusters = append(clusters, subscriptionClusters...)
+	}
+	return clusters, nil
+}
+
+func (a *Azure) listCluster(ctx context.Context, subscription AzureSubscription) ([]ClusterInfo, error) {
 	client, err := a.getAzureClient()
 	if err != nil {
 		return nil, err
@@ -108,7 +189,7 @@ func (a *Azure) ListCluster(subscription AzureSubscription) (clusters []ClusterI
 
 	pager := aksClient.NewListPager(nil)
 	for pager.More() {
-		page, err := pager.NextPage(context.Background())
+		page, err := pager.NextPage(ctx)
 		if err != nil {
 			return nil, err
 		}
@@ -122,6 +203,11 @@ func (a *Azure) ListCluster(subscription AzureSubscription) (clusters []ClusterI
 				ConsoleURL: "https://portal.azure.com",
 				// Too long to CLI
 				// ConsoleURL: fmt.Sprintf("https://portal.azure.com/#resource%s/overview", *cluster.ID),
+				Tags:      azureTags(cluster.Tags),
+				Status:    azureStatus(cluster.Properties),
+				NodeCount: azureNodeCount(cluster.Properties),
+				Endpoint:  azureEndpoint(cluster.Properties),
+				CreatedAt: azureCreated(cluster.SystemData),
 			})
 		}
 	}
@@ -129,19 +215,121 @@ func (a *Azure) ListCluster(subscription AzureSubscription) (clusters []ClusterI
 	return clusterList, err
 }
 
+// azureStatus reports the power state of a running cluster and the
+// provisioning state otherwise.
+func azureStatus(props *armcontainerservice.ManagedClusterProperties) string {
+	if props == nil {
+		return ""
+	}
+	if props.ProvisioningState != nil && *props.ProvisioningState != "Succeeded" {
+		return *props.ProvisioningState
+	}
+	if props.PowerState != nil && props.PowerState.Code != nil {
+		return string(*props.PowerState.Code)
+	}
+	if props.ProvisioningState != nil {
+		return *props.ProvisioningState
+	}
+	return ""
+}
+
+func azureNodeCount(props *armcontainerservice.ManagedClusterProperties) int {
+	if props == nil {
+		return 0
+	}
+	var count int
+	for _, pool := range props.AgentPoolProfiles {
+		if pool.Count != nil {
+			count += int(*pool.Count)
+		}
+	}
+	return count
+}
+
+func azureEndpoint(props *armcontainerservice.ManagedClusterProperties) string {
+	if props == nil {
+		return ""
+	}
+	if access := props.APIServerAccessProfile; access != nil && access.EnablePrivateCluster != nil && *access.EnablePrivateCluster {
+		return EndpointPrivate
+	}
+	return EndpointPublic
+}
+
+func azureCreated(data *armcontainerservice.SystemData) time.Time {
+	if data == nil || data.CreatedAt == nil {
+		return time.Time{}
+	}
+	return *data.CreatedAt
+}
+
+func azureTags(tags map[string]*string) map[string]string {
+	if len(tags) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(tags))
+	for k, v := range tags {
+		if v != nil {
+			out[k] = *v
+		} else {
+			out[k] = ""
+		}
+	}
+	return out
+}
+
+// GetKubeconfig implements Provider. The cluster ID is the ARM resource ID
+// of the managed cluster.
+func (a *Azure) GetKubeconfig(ctx context.Context, cluster ClusterInfo) (*clientcmdapi.Config, error) {
+	clusterIDParts := strings.Split(cluster.ID, "/")
+	if len(clusterIDParts) != 9 {
+		return nil, fmt.Errorf("invalid id %s", cluster.ID)
+	}
+	subscriptionID := clusterIDParts[2]
+	resourceGroup := clusterIDParts[4]
+	name := clusterIDParts[8]
+
+	var (
+		kubeConfig []byte
+		err        error
+	)
+	if a.Admin {
+		kubeConfig, err = a.getAdminKubeConfig(ctx, subscriptionID, name, resourceGroup)
+	} else {
+		kubeConfig, err = a.getKubeConfig(ctx, subscriptionID, name, resourceGroup)
+	}
+	if err != nil {
+		return nil, err
+	}
+	config, err := clientcmd.Load(kubeConfig)
+	if err != nil {
+		return nil, err
+	}
+	if a.LoginMode != "" {
+		if err := ConvertToKubelogin(config, a.LoginMode); err != nil {
+			return nil, err
+		}
+	}
+	return config, nil
+}
+
 // GetKubeConfig get kubeConfig file
 func (a *Azure) GetKubeConfig(clusterName, resourceGroupName string) ([]byte, error) {
+	return a.getKubeConfig(context.Background(), a.SubscriptionID, clusterName, resourceGroupName)
+}
+
+func (a *Azure) getKubeConfig(ctx context.Context, subscriptionID, clusterName, resourceGroupName string) ([]byte, error) {
 	client, err := a.getAzureClient()
 	if err != nil {
 		return nil, err
 	}
 
-	aksClient, err := armcontainerservice.NewManagedClustersClient(a.SubscriptionID, client, nil)
+	aksClient, err := armcontainerservice.NewManagedClustersClient(subscriptionID, client, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	res, err := aksClient.ListClusterUserCredentials(context.Background(), resourceGroupName, clusterName, nil)
+	res, err := aksClient.ListClusterUserCredentials(ctx, resourceGroupName, clusterName, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -155,17 +343,21 @@ func (a *Azure) GetKubeConfig(clusterName, resourceGroupName string) ([]byte, er
 
 // GetAdminKubeConfig get kubeConfig file
 func (a *Azure) GetAdminKubeConfig(clusterName, resourceGroupName string) ([]byte, error) {
+	return a.getAdminKubeConfig(context.Background(), a.SubscriptionID, clusterName, resourceGroupName)
+}
+
+func (a *Azure) getAdminKubeConfig(ctx context.Context, subscriptionID, clusterName, resourceGroupName string) ([]byte, error) {
 	client, err := a.getAzureClient()
 	if err != nil {
 		return nil, err
 	}
 
-	aksClient, err := armcontainerservice.NewManagedClustersClient(a.SubscriptionID, client, nil)
+	aksClient, err := armcontainerservice.NewManagedClustersClient(subscriptionID, client, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	res, err := aksClient.ListClusterAdminCredentials(context.Background(), resourceGroupName, clusterName, nil)
+	res, err := aksClient.ListClusterAdminCredentials(ctx, resourceGroupName, clusterName, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,28 @@
+package cloud
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAzure_getAzureClientConcurrent(t *testing.T) {
+	a := &Azure{AuthMode: AuthModeServicePrincipal, TenantID: "tenant", ClientID: "id", ClientSecret: "secret"}
+	clients := make([]azcore.TokenCredential, 8)
+	var wg sync.WaitGroup
+	for i := range clients {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			client, err := a.getAzureClient()
+			assert.NoError(t, err)
+			clients[i] = client
+		}(i)
+	}
+	wg.Wait()
+	for _, client := range clients {
+		assert.Same(t, clients[0], client)
+	}
+}
@@ -1,5 +1,16 @@
 package cloud
 
+import (
+	"strings"
+	"time"
+)
+
+// Endpoint visibility of a cluster's API server.
+const (
+	EndpointPublic  = "public"
+	EndpointPrivate = "private"
+)
+
 // Cluster interface of cloud k8s cluster
 type Cluster interface {
 	GetRegionID() ([]string, error)
@@ -9,10 +20,50 @@ type Cluster interface {
 
 // ClusterInfo ack cluster info
 type ClusterInfo struct {
-	Name       string
-	Account    string
-	ID         string
-	RegionID   string
-	K8sVersion string
-	ConsoleURL string
+	Name       string            `json:"name"`
+	Account    string            `json:"account,omitempty"`
+	ID         string            `json:"id"`
+	RegionID   string            `json:"region,omitempty"`
+	K8sVersion string            `json:"version,omitempty"`
+	ConsoleURL string            `json:"consoleUrl,omitempty"`
+	Profile    string            `json:"profile,omitempty"` // credential profile the cluster was discovered with
+	Tags       map[string]string `json:"tags,omitempty"`
+	Status     string            `json:"status,omitempty"` // as reported by the provider, e.g. ACTIVE or running
+	NodeCount  int               `json:"nodeCount"`
+	Endpoint   string            `json:"endpoint,omitempty"` // EndpointPublic, EndpointPrivate or empty if unknown
+	CreatedAt  time.Time         `json:"createdAt,omitzero"` // zero if unknown
+}
+
+// Matches reports whether the cluster matches every filter. The status and
+// endpoint keys match Status and Endpoint case-insensitively, other keys
+// match tags.
+func (c ClusterInfo) Matches(filters map[string]string) bool {
+	for k, v := range filters {
+		switch k {
+		case "status":
+			if !strings.EqualFold(c.Status, v) {
+				return false
+			}
+		case "endpoint":
+			if !strings.EqualFold(c.Endpoint, v) {
+				return false
+			}
+		default:
+			if got, ok := c.Tags[k]; !ok || got != v {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// parseCreated parses the creation time formats used by the providers,
+// returning the zero time for anything else.
+func parseCreated(s string) time.Time {
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
 }
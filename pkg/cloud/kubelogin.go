@@ -0,0 +1,114 @@
+package cloud
+
+import (
+	"fmt"
+	"slices"
+
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// Login modes of kubelogin, the exec credential plugin for AKS clusters
+// using Entra ID authentication.
+const (
+	AzureLoginDeviceCode       = "devicecode"
+	AzureLoginAzureCLI         = "azurecli"
+	AzureLoginWorkloadIdentity = "workloadidentity"
+	AzureLoginSPN              = "spn"
+)
+
+// AzureLoginModes lists the supported kubelogin login modes.
+var AzureLoginModes = []string{
+	AzureLoginDeviceCode,
+	AzureLoginAzureCLI,
+	AzureLoginWorkloadIdentity,
+	AzureLoginSPN,
+}
+
+const defaultAzureEnvironment = "AzurePublicCloud"
+
+// azureLogin holds the Entra ID settings of an AKS user.
+type azureLogin struct {
+	serverID    string
+	clientID    string
+	tenantID    string
+	environment string
+}
+
+// ConvertToKubelogin rewrites the users of config that authenticate with
+// Entra ID, either through the deprecated azure auth provider or through
+// kubelogin, into kubelogin exec configurations using mode. Other users,
+// such as the client certificates of admin credentials, are left unchanged.
+func ConvertToKubelogin(config *clientcmdapi.Config, mode string) error {
+	if !slices.Contains(AzureLoginModes, mode) {
+		return fmt.Errorf("unsupported azure login mode %q", mode)
+	}
+	for name, authInfo := range config.AuthInfos {
+		login, ok := azureLoginOf(authInfo)
+		if !ok {
+			continue
+		}
+		if login.serverID == "" {
+			return fmt.Errorf("user %q has no Entra ID server id", name)
+		}
+		authInfo.AuthProvider = nil
+		authInfo.Exec = &clientcmdapi.ExecConfig{
+			APIVersion:      "client.authentication.k8s.io/v1beta1",
+			Command:         "kubelogin",
+			Args:            kubeloginArgs(login, mode),
+			InteractiveMode: clientcmdapi.IfAvailableExecInteractiveMode,
+			InstallHint:     "kubelogin is required, see https://azure.github.io/kubelogin/install.html",
+		}
+	}
+	return nil
+}
+
+// azureLoginOf extracts the Entra ID settings of an azure auth provider or a
+// kubelogin exec configuration.
+func azureLoginOf(authInfo *clientcmdapi.AuthInfo) (azureLogin, bool) {
+	if authInfo == nil {
+		return azureLogin{}, false
+	}
+	if provider := authInfo.AuthProvider; provider != nil && provider.Name == "azure" {
+		return azureLogin{
+			serverID:    provider.Config["apiserver-id"],
+			clientID:    provider.Config["client-id"],
+			tenantID:    provider.Config["tenant-id"],
+			environment: provider.Config["environment"],
+		}, true
+	}
+	if exec := authInfo.Exec; exec != nil && exec.Command == "kubelogin" {
+		var login azureLogin
+		flags := map[string]*string{
+			"--server-id":   &login.serverID,
+			"--client-id":   &login.clientID,
+			"--tenant-id":   &login.tenantID,
+			"--environment": &login.environment,
+		}
+		for i := 0; i < len(exec.Args)-1; i++ {
+			if value, ok := flags[exec.Args[i]]; ok {
+				*value = exec.Args[i+1]
+				i++
+			}
+		}
+		return login, true
+	}
+	return azureLogin{}, false
+}
+
+// kubeloginArgs returns the get-token arguments of a login mode, passing
+// only what the mode needs: azurecli and workloadidentity take the client
+// and tenant from the CLI session or the environment.
+func kubeloginArgs(login azureLogin, mode string) []string {
+	environment := login.environment
+	if environment == "" {
+		environment = defaultAzureEnvironment
+	}
+	args := []string{"get-token", "--login", mode, "--server-id", login.serverID}
+	switch mode {
+	case AzureLoginDeviceCode:
+		args = append(args, "--environment", environment, "--client-id", login.clientID, "--tenant-id", login.tenantID)
+	case AzureLoginSPN:
+		args = append(args, "--environment", environment, "--tenant-id", login.tenantID)
+	}
+	return args
+}
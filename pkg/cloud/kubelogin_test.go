@@ -0,0 +1,58 @@
+package cloud
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+func azureConfig() *clientcmdapi.Config {
+	config := clientcmdapi.NewConfig()
+	config.AuthInfos["legacy"] = &clientcmdapi.AuthInfo{AuthProvider: &clientcmdapi.AuthProviderConfig{
+		Name: "azure",
+		Config: map[string]string{
+			"apiserver-id": "6dae42f8-4368-4678-94ff-3960e28e3630",
+			"client-id":    "80faf920-1908-4b52-b5ef-a8e7bedfc67a",
+			"tenant-id":    "tenant",
+			"environment":  "AzureUSGovernmentCloud",
+			"config-mode":  "1",
+		},
+	}}
+	config.AuthInfos["exec"] = &clientcmdapi.AuthInfo{Exec: &clientcmdapi.ExecConfig{
+		Command: "kubelogin",
+		Args: []string{"get-token", "--login", "devicecode", "--environment", "AzurePublicCloud",
+			"--server-id", "server", "--client-id", "client", "--tenant-id", "tenant"},
+	}}
+	config.AuthInfos["admin"] = &clientcmdapi.AuthInfo{ClientCertificateData: []byte("cert")}
+	return config
+}
+
+func TestConvertToKubelogin(t *testing.T) {
+	config := azureConfig()
+	require.NoError(t, ConvertToKubelogin(config, AzureLoginDeviceCode))
+
+	legacy := config.AuthInfos["legacy"]
+	assert.Nil(t, legacy.AuthProvider)
+	assert.Equal(t, "kubelogin", legacy.Exec.Command)
+	assert.Equal(t, []string{"get-token", "--login", "devicecode", "--server-id", "6dae42f8-4368-4678-94ff-3960e28e3630",
+		"--environment", "AzureUSGovernmentCloud", "--client-id", "80faf920-1908-4b52-b5ef-a8e7bedfc67a", "--tenant-id", "tenant"}, legacy.Exec.Args)
+	assert.Nil(t, config.AuthInfos["admin"].Exec)
+
+	config = azureConfig()
+	require.NoError(t, ConvertToKubelogin(config, AzureLoginAzureCLI))
+	assert.Equal(t, []string{"get-token", "--login", "azurecli", "--server-id", "server"}, config.AuthInfos["exec"].Exec.Args)
+
+	config = azureConfig()
+	require.NoError(t, ConvertToKubelogin(config, AzureLoginSPN))
+	assert.Equal(t, []string{"get-token", "--login", "spn", "--server-id", "server", "--environment", "AzurePublicCloud", "--tenant-id", "tenant"}, config.AuthInfos["exec"].Exec.Args)
+}
+
+func TestConvertToKubelogin_Errors(t *testing.T) {
+	assert.EqualError(t, ConvertToKubelogin(azureConfig(), "browser"), `unsupported azure login mode "browser"`)
+
+	config := clientcmdapi.NewConfig()
+	config.AuthInfos["user"] = &clientcmdapi.AuthInfo{AuthProvider: &clientcmdapi.AuthProviderConfig{Name: "azure", Config: map[string]string{}}}
+	assert.EqualError(t, ConvertToKubelogin(config, AzureLoginWorkloadIdentity), `user "user" has no Entra ID server id`)
+}
@@ -0,0 +1,157 @@
+package cloud
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// Provider is a managed Kubernetes service clusters can be imported from.
+type Provider interface {
+	// ListClusters lists the clusters visible with the configured credentials.
+	ListClusters(ctx context.Context) ([]ClusterInfo, error)
+	// GetKubeconfig returns the kubeconfig of one of the listed clusters.
+	GetKubeconfig(ctx context.Context, cluster ClusterInfo) (*clientcmdapi.Config, error)
+}
+
+// CredentialSource is a provider input read from an environment variable.
+type CredentialSource struct {
+	Env      string // environment variable holding the value
+	Prompt   string // label used when asking for it, empty to never ask
+	Optional bool   // may be left empty
+}
+
+// AuthMode is one way of authenticating against a provider.
+type AuthMode struct {
+	Name        string
+	Credentials []CredentialSource
+}
+
+// ProviderConfig is the resolved input a provider is built from.
+type ProviderConfig struct {
+	AuthMode    int               // index into ProviderSpec.AuthModes
+	Credentials map[string]string // keyed by CredentialSource.Env
+	Region      string
+	Regions     []string // search these regions instead of Region
+	Profiles    []string // search with each of these credential profiles
+	Admin       bool     // fetch admin rather than user credentials
+	LoginMode   string   // kubelogin login mode of the fetched credentials
+	// RoleARN, TokenCommand and ExecEnv shape the exec config of the
+	// fetched credentials.
+	RoleARN      string
+	TokenCommand string
+	ExecEnv      map[string]string
+	// PrivateEndpoint fetches credentials using the private API server
+	// address.
+	PrivateEndpoint bool
+	// TemporaryDuration fetches credentials expiring after it.
+	TemporaryDuration time.Duration
+	// CAFile is a PEM bundle verifying the provider API server, unless
+	// InsecureSkipTLSVerify is set.
+	CAFile                string
+	InsecureSkipTLSVerify bool
+	// ClusterEndpoint selects the contexts kept from generated kubeconfigs.
+	ClusterEndpoint string
+}
+
+// ProviderSpec describes a registered provider.
+type ProviderSpec struct {
+	Name     string // display name, e.g. AlibabaCloud
+	Key      string // short name used in context names, e.g. alicloud
+	Aliases  []string
+	HomePage string
+	Service  string
+	// AuthModes lists the ways to authenticate, the first being the default.
+	AuthModes []AuthMode
+	// NeedsRegion is set when clusters are listed per region.
+	NeedsRegion bool
+	// RegionEnv lists environment variables holding a default region.
+	RegionEnv []string
+	// MultiRegion is set when clusters can be searched in several regions.
+	MultiRegion bool
+	// MultiProfile is set when clusters can be searched with several
+	// credential profiles.
+	MultiProfile bool
+	// SupportsAdmin is set when admin credentials can be requested.
+	SupportsAdmin bool
+	// LoginModes lists the kubelogin login modes credentials can be
+	// converted to.
+	LoginModes []string
+	// TokenCommands lists the commands the exec config can generate tokens
+	// with, the first being the default. Providers listing any also accept
+	// a role to assume and extra exec environment.
+	TokenCommands []string
+	// SupportsPrivateEndpoint is set when kubeconfigs can use the private
+	// API server address.
+	SupportsPrivateEndpoint bool
+	// SupportsTemporaryCredentials is set when kubeconfigs with expiring
+	// credentials can be requested.
+	SupportsTemporaryCredentials bool
+	// MinTemporaryDuration and MaxTemporaryDuration bound the lifetime of
+	// temporary credentials when the provider limits it.
+	MinTemporaryDuration time.Duration
+	MaxTemporaryDuration time.Duration
+	// SupportsTLSOptions is set when the provider API server can be
+	// verified with a custom CA bundle.
+	SupportsTLSOptions bool
+	// ClusterEndpoints lists the endpoints generated kubeconfigs can be
+	// narrowed to, the first being the default.
+	ClusterEndpoints []string
+	// Note is printed after a kubeconfig has been added.
+	Note string
+	// Regions lists the regions to choose from.
+	Regions func(ctx context.Context, cfg ProviderConfig) ([]string, error)
+	// New builds the provider.
+	New func(cfg ProviderConfig) (Provider, error)
+}
+
+var providers = []ProviderSpec{
+	aliCloudSpec,
+	tencentSpec,
+	rancherSpec,
+	awsSpec,
+	azureSpec,
+}
+
+// RegisterProvider adds a provider to the registry.
+func RegisterProvider(spec ProviderSpec) error {
+	for _, alias := range spec.Aliases {
+		if _, ok := LookupProvider(alias); ok {
+			return fmt.Errorf("provider alias %q is already registered", alias)
+		}
+	}
+	providers = append(providers, spec)
+	return nil
+}
+
+// Providers returns the registered providers in registration order.
+func Providers() []ProviderSpec {
+	out := make([]ProviderSpec, len(providers))
+	copy(out, providers)
+	return out
+}
+
+// LookupProvider finds a registered provider by one of its aliases.
+func LookupProvider(alias string) (ProviderSpec, bool) {
+	alias = strings.ToLower(alias)
+	for _, spec := range providers {
+		for _, a := range spec.Aliases {
+			if a == alias {
+				return spec, true
+			}
+		}
+	}
+	return ProviderSpec{}, false
+}
+
+// ProviderAliases returns the aliases of all registered providers.
+func ProviderAliases() []string {
+	var aliases []string
+	for _, spec := range providers {
+		aliases = append(aliases, spec.Aliases...)
+	}
+	return aliases
+}
@@ -0,0 +1,119 @@
+package cloud
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+func TestLookupProvider(t *testing.T) {
+	for alias, want := range map[string]string{
+		"ack":     "AlibabaCloud",
+		"aliyun":  "AlibabaCloud",
+		"TKE":     "TencentCloud",
+		"rancher": "Rancher",
+		"eks":     "AWS",
+		"aks":     "Azure",
+	} {
+		spec, ok := LookupProvider(alias)
+		assert.True(t, ok, alias)
+		assert.Equal(t, want, spec.Name, alias)
+	}
+
+	_, ok := LookupProvider("gke")
+	assert.False(t, ok)
+	assert.Contains(t, ProviderAliases(), "alibabacloud")
+}
+
+func TestProvidersAreComplete(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, spec := range Providers() {
+		assert.NotEmpty(t, spec.Key, spec.Name)
+		assert.NotEmpty(t, spec.AuthModes, spec.Name)
+		assert.NotNil(t, spec.New, spec.Name)
+		if spec.NeedsRegion {
+			assert.NotNil(t, spec.Regions, spec.Name)
+		}
+		assert.False(t, seen[spec.Key], "duplicate key %s", spec.Key)
+		seen[spec.Key] = true
+	}
+}
+
+type staticProvider struct{}
+
+func (staticProvider) ListClusters(ctx context.Context) ([]ClusterInfo, error) {
+	return []ClusterInfo{{ID: "1", Name: "one"}}, nil
+}
+
+func (staticProvider) GetKubeconfig(ctx context.Context, cluster ClusterInfo) (*clientcmdapi.Config, error) {
+	return clientcmdapi.NewConfig(), nil
+}
+
+func TestRegisterProvider(t *testing.T) {
+	saved := providers
+	defer func() { providers = saved }()
+
+	spec := ProviderSpec{
+		Name:    "Fake",
+		Key:     "fake",
+		Aliases: []string{"fake"},
+		New: func(cfg ProviderConfig) (Provider, error) {
+			return staticProvider{}, nil
+		},
+	}
+	require.NoError(t, RegisterProvider(spec))
+	got, ok := LookupProvider("fake")
+	require.True(t, ok)
+	p, err := got.New(ProviderConfig{})
+	require.NoError(t, err)
+	clusters, err := p.ListClusters(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "one", clusters[0].Name)
+
+	assert.Error(t, RegisterProvider(ProviderSpec{Name: "Dup", Aliases: []string{"eks"}}))
+}
+
+func TestProviderSpecNew(t *testing.T) {
+	spec, _ := LookupProvider("aws")
+	p, err := spec.New(ProviderConfig{
+		AuthMode:    1,
+		Credentials: map[string]string{"AWS_ACCESS_KEY_ID": "id", "AWS_SECRET_ACCESS_KEY": "secret"},
+		Region:      "eu-west-1",
+	})
+	require.NoError(t, err)
+	a := p.(*AWS)
+	assert.Equal(t, AWSAuthStaticCredentials, a.AuthMode)
+	assert.Equal(t, "id", a.AccessKeyID)
+	assert.Equal(t, "eu-west-1", a.RegionID)
+
+	spec, _ = LookupProvider("azure")
+	p, err = spec.New(ProviderConfig{Admin: true})
+	require.NoError(t, err)
+	_, err = p.GetKubeconfig(context.Background(), ClusterInfo{ID: "not-an-arm-id"})
+	assert.EqualError(t, err, "invalid id not-an-arm-id")
+}
+
+func TestClusterInfoMatches(t *testing.T) {
+	cluster := ClusterInfo{Tags: map[string]string{"env": "prod", "team": "payments"}}
+	assert.True(t, cluster.Matches(nil))
+	assert.True(t, cluster.Matches(map[string]string{"env": "prod"}))
+	assert.True(t, cluster.Matches(map[string]string{"env": "prod", "team": "payments"}))
+	assert.False(t, cluster.Matches(map[string]string{"env": "dev"}))
+	assert.False(t, cluster.Matches(map[string]string{"owner": ""}))
+	assert.False(t, ClusterInfo{}.Matches(map[string]string{"env": "prod"}))
+
+	cluster.Status = "ACTIVE"
+	cluster.Endpoint = EndpointPrivate
+	assert.True(t, cluster.Matches(map[string]string{"status": "active", "endpoint": "private"}))
+	assert.False(t, cluster.Matches(map[string]string{"endpoint": EndpointPublic}))
+}
+
+func TestParseCreated(t *testing.T) {
+	assert.Equal(t, time.Date(2020, 8, 20, 2, 51, 29, 0, time.UTC), parseCreated("2020-08-20T10:51:29+08:00").UTC())
+	assert.Equal(t, time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC), parseCreated("2021-01-02 03:04:05"))
+	assert.True(t, parseCreated("").IsZero())
+}
@@ -1,35 +1,108 @@
 package cloud
 
 import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/rancher/norman/clientbase"
 	managementClient "github.com/rancher/rancher/pkg/client/generated/management/v3"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 )
 
+var rancherSpec = ProviderSpec{
+	Name:     "Rancher",
+	Key:      "rancher",
+	Aliases:  []string{"rancher"},
+	HomePage: "https://rancher.com",
+	Service:  "Rancher",
+	AuthModes: []AuthMode{{
+		Name: "API Key",
+		Credentials: []CredentialSource{
+			{Env: "RANCHER_SERVER_URL", Prompt: "Rancher API serverURL"},
+			{Env: "RANCHER_API_KEY", Prompt: "Rancher API key"},
+		},
+	}},
+	SupportsTemporaryCredentials: true,
+	SupportsTLSOptions:           true,
+	ClusterEndpoints:             RancherEndpoints,
+	New: func(cfg ProviderConfig) (Provider, error) {
+		return &Rancher{
+			ServerURL:             cfg.Credentials["RANCHER_SERVER_URL"],
+			APIKey:                cfg.Credentials["RANCHER_API_KEY"],
+			CAFile:                cfg.CAFile,
+			InsecureSkipTLSVerify: cfg.InsecureSkipTLSVerify,
+			Endpoint:              cfg.ClusterEndpoint,
+			TokenTTL:              cfg.TemporaryDuration,
+		}, nil
+	},
+}
+
+// Endpoints of the contexts in kubeconfigs generated by Rancher.
+const (
+	// RancherEndpointAll keeps every context.
+	RancherEndpointAll = "all"
+	// RancherEndpointProxy keeps the context proxied through the Rancher
+	// server.
+	RancherEndpointProxy = "proxy"
+	// RancherEndpointACE keeps the contexts of the authorized cluster
+	// endpoint, reaching the cluster directly.
+	RancherEndpointACE = "ace"
+)
+
+// RancherEndpoints lists the supported endpoints, the first being the
+// default.
+var RancherEndpoints = []string{
+	RancherEndpointAll,
+	RancherEndpointProxy,
+	RancherEndpointACE,
+}
+
 // Rancher struct of rancher
 type Rancher struct {
 	ServerURL string
 	APIKey    string
+	// CAFile is a PEM bundle verifying the Rancher server, whose
+	// certificate is not verified at all with InsecureSkipTLSVerify.
+	CAFile                string
+	InsecureSkipTLSVerify bool
+	// Endpoint is one of RancherEndpoints, empty for all.
+	Endpoint string
+	// TokenTTL, when set, replaces the token of generated kubeconfigs with
+	// one scoped to the cluster and expiring after it.
+	TokenTTL time.Duration
 }
 
-// getRancherClient get rancher client
-func getRancherClient(serverURL, apiKey string) (*managementClient.Client, error) {
+// getClient get rancher client
+func (r *Rancher) getClient() (*managementClient.Client, error) {
+	serverURL := r.ServerURL
 	if !strings.HasSuffix(serverURL, "/v3") {
 		serverURL += "/v3"
 	}
 
 	options := &clientbase.ClientOpts{
 		URL:      serverURL,
-		TokenKey: apiKey,
-		Insecure: true,
+		TokenKey: r.APIKey,
+		Insecure: r.InsecureSkipTLSVerify,
 	}
-
-	result, err := managementClient.NewClient(options)
-	if err != nil {
-		return nil, err
+	if r.CAFile != "" && !r.InsecureSkipTLSVerify {
+		caCerts, err := os.ReadFile(r.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading CA bundle: %w", err)
+		}
+		if !x509.NewCertPool().AppendCertsFromPEM(caCerts) {
+			return nil, fmt.Errorf("no PEM certificates found in %s", r.CAFile)
+		}
+		options.CACerts = string(caCerts)
 	}
-	return result, err
+
+	return managementClient.NewClient(options)
 }
 
 // GetRegionID get region id of rancher cluster
@@ -40,7 +113,7 @@ func (r *Rancher) GetRegionID() ([]string, error) {
 
 // ListCluster list cluster info
 func (r *Rancher) ListCluster() (clusters []ClusterInfo, err error) {
-	client, err := getRancherClient(r.ServerURL, r.APIKey)
+	client, err := r.getClient()
 	if err != nil {
 		return nil, err
 	}
@@ -55,6 +128,10 @@ func (r *Rancher) ListCluster() (clusters []ClusterInfo, err error) {
 			ID:         info.ID,
 			RegionID:   "",
 			K8sVersion: info.Version.GitVersion,
+			Tags:       info.Labels,
+			Status:     info.State,
+			NodeCount:  int(info.NodeCount),
+			CreatedAt:  parseCreated(info.Created),
 		})
 	}
 	return clusterList, err
@@ -62,11 +139,14 @@ func (r *Rancher) ListCluster() (clusters []ClusterInfo, err error) {
 
 // GetKubeConfig get kubeConfig file
 func (r *Rancher) GetKubeConfig(clusterID string) (string, error) {
-	client, err := getRancherClient(r.ServerURL, r.APIKey)
+	client, err := r.getClient()
 	if err != nil {
 		return "", err
 	}
+	return generateRancherKubeconfig(client, clusterID)
+}
 
+func generateRancherKubeconfig(client *managementClient.Client, clusterID string) (string, error) {
 	cluster, err := client.Cluster.ByID(clusterID)
 	if err != nil {
 		return "", err
@@ -78,3 +158,99 @@ func (r *Rancher) GetKubeConfig(clusterID string) (string, error) {
 	}
 	return config.Config, nil
 }
+
+// ListClusters implements Provider.
+func (r *Rancher) ListClusters(ctx context.Context) ([]ClusterInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return r.ListCluster()
+}
+
+// GetKubeconfig implements Provider.
+func (r *Rancher) GetKubeconfig(ctx context.Context, cluster ClusterInfo) (*clientcmdapi.Config, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	client, err := r.getClient()
+	if err != nil {
+		return nil, err
+	}
+	kubeconfig, err := generateRancherKubeconfig(client, cluster.ID)
+	if err != nil {
+		return nil, err
+	}
+	config, err := clientcmd.Load([]byte(kubeconfig))
+	if err != nil {
+		return nil, err
+	}
+	if err := selectRancherEndpoint(config, r.Endpoint); err != nil {
+		return nil, fmt.Errorf("cluster %s: %w", cluster.Name, err)
+	}
+	if r.TokenTTL > 0 {
+		token, err := client.Token.Create(&managementClient.Token{
+			ClusterID:   cluster.ID,
+			TTLMillis:   r.TokenTTL.Milliseconds(),
+			Description: "kubecm: " + cluster.Name,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("creating scoped token: %w", err)
+		}
+		setRancherToken(config, token.Token)
+	}
+	return config, nil
+}
+
+// selectRancherEndpoint narrows a generated kubeconfig to the contexts of
+// endpoint. The proxy context reaches the cluster through the Rancher
+// server's /k8s/clusters/ path; any other context is an authorized cluster
+// endpoint.
+func selectRancherEndpoint(config *clientcmdapi.Config, endpoint string) error {
+	if endpoint == "" || endpoint == RancherEndpointAll {
+		return nil
+	}
+	if !slices.Contains(RancherEndpoints, endpoint) {
+		return fmt.Errorf("unsupported rancher endpoint %q", endpoint)
+	}
+	var kept []string
+	for name, context := range config.Contexts {
+		cluster := config.Clusters[context.Cluster]
+		proxied := cluster != nil && strings.Contains(cluster.Server, "/k8s/clusters/")
+		if proxied == (endpoint == RancherEndpointProxy) {
+			kept = append(kept, name)
+			continue
+		}
+		delete(config.Contexts, name)
+	}
+	if len(kept) == 0 {
+		return fmt.Errorf("generated kubeconfig has no %s context", endpoint)
+	}
+	sort.Strings(kept)
+	if _, ok := config.Contexts[config.CurrentContext]; !ok {
+		config.CurrentContext = kept[0]
+	}
+
+	clusters, users := map[string]bool{}, map[string]bool{}
+	for _, context := range config.Contexts {
+		clusters[context.Cluster] = true
+		users[context.AuthInfo] = true
+	}
+	for name := range config.Clusters {
+		if !clusters[name] {
+			delete(config.Clusters, name)
+		}
+	}
+	for name := range config.AuthInfos {
+		if !users[name] {
+			delete(config.AuthInfos, name)
+		}
+	}
+	return nil
+}
+
+// setRancherToken makes every user of config authenticate with token.
+func setRancherToken(config *clientcmdapi.Config, token string) {
+	for _, authInfo := range config.AuthInfos {
+		authInfo.Token = token
+	}
+}
@@ -0,0 +1,72 @@
+package cloud
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// rancherKubeconfig mirrors a kubeconfig generated by Rancher for a cluster
+// with an authorized cluster endpoint.
+func rancherKubeconfig() *clientcmdapi.Config {
+	return &clientcmdapi.Config{
+		Clusters: map[string]*clientcmdapi.Cluster{
+			"prod":      {Server: "https://rancher.example.com/k8s/clusters/c-abc12"},
+			"prod-fqdn": {Server: "https://prod.example.com:6443"},
+		},
+		AuthInfos: map[string]*clientcmdapi.AuthInfo{
+			"prod": {Token: "kubeconfig-u-1:secret"},
+		},
+		Contexts: map[string]*clientcmdapi.Context{
+			"prod":      {Cluster: "prod", AuthInfo: "prod"},
+			"prod-fqdn": {Cluster: "prod-fqdn", AuthInfo: "prod"},
+		},
+		CurrentContext: "prod",
+	}
+}
+
+func TestSelectRancherEndpoint(t *testing.T) {
+	config := rancherKubeconfig()
+	require.NoError(t, selectRancherEndpoint(config, RancherEndpointAll))
+	assert.Len(t, config.Contexts, 2)
+
+	config = rancherKubeconfig()
+	require.NoError(t, selectRancherEndpoint(config, RancherEndpointProxy))
+	assert.Equal(t, []string{"prod"}, slices.Sorted(maps.Keys(config.Contexts)))
+	assert.Equal(t, []string{"prod"}, slices.Sorted(maps.Keys(config.Clusters)))
+	assert.Equal(t, "prod", config.CurrentContext)
+
+	config = rancherKubeconfig()
+	require.NoError(t, selectRancherEndpoint(config, RancherEndpointACE))
+	assert.Equal(t, []string{"prod-fqdn"}, slices.Sorted(maps.Keys(config.Contexts)))
+	assert.Equal(t, []string{"prod-fqdn"}, slices.Sorted(maps.Keys(config.Clusters)))
+	assert.Equal(t, []string{"prod"}, slices.Sorted(maps.Keys(config.AuthInfos)))
+	assert.Equal(t, "prod-fqdn", config.CurrentContext)
+
+	config = rancherKubeconfig()
+	delete(config.Contexts, "prod-fqdn")
+	assert.EqualError(t, selectRancherEndpoint(config, RancherEndpointACE), "generated kubeconfig has no ace context")
+}
+
+func TestSetRancherToken(t *testing.T) {
+	config := rancherKubeconfig()
+	setRancherToken(config, "token-x:scoped")
+	assert.Equal(t, "token-x:scoped", config.AuthInfos["prod"].Token)
+}
+
+func TestRancher_getClientCAFile(t *testing.T) {
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, []byte("not a certificate"), 0o600))
+
+	_, err := (&Rancher{ServerURL: "https://rancher.example.com", CAFile: caFile}).getClient()
+	assert.EqualError(t, err, "no PEM certificates found in "+caFile)
+
+	_, err = (&Rancher{ServerURL: "https://rancher.example.com", CAFile: filepath.Join(t.TempDir(), "missing.pem")}).getClient()
+	assert.ErrorContains(t, err, "reading CA bundle")
+}
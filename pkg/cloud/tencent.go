@@ -1,90 +1,277 @@
 package cloud
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
-	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/errors"
 	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/profile"
 	tke "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/tke/v20180525"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 )
 
+var tencentSpec = ProviderSpec{
+	Name:     "TencentCloud",
+	Key:      "tencent",
+	Aliases:  []string{"tencentcloud", "tencent", "tke"},
+	HomePage: "https://console.cloud.tencent.com/tke",
+	Service:  "TKE",
+	AuthModes: []AuthMode{
+		{
+			Name: "API Secret",
+			Credentials: []CredentialSource{
+				{Env: "TENCENTCLOUD_SECRET_ID", Prompt: "TencentCloud API secretId"},
+				{Env: "TENCENTCLOUD_SECRET_KEY", Prompt: "TencentCloud API secretKey"},
+			},
+		},
+		{
+			Name: "tccli Credentials",
+			Credentials: []CredentialSource{
+				{Env: "TCCLI_PROFILE", Optional: true},
+			},
+		},
+	},
+	NeedsRegion:             true,
+	RegionEnv:               []string{"TENCENTCLOUD_REGION"},
+	SupportsPrivateEndpoint: true,
+	Regions: func(ctx context.Context, cfg ProviderConfig) ([]string, error) {
+		t, err := newTencentCloud(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return t.getRegionID(ctx)
+	},
+	New: func(cfg ProviderConfig) (Provider, error) {
+		return newTencentCloud(cfg)
+	},
+}
+
+// Auth modes of tencentSpec.
+const (
+	tencentAuthSecret = iota
+	tencentAuthTCCLI
+)
+
+func newTencentCloud(cfg ProviderConfig) (*TencentCloud, error) {
+	t := &TencentCloud{
+		SecretID:        cfg.Credentials["TENCENTCLOUD_SECRET_ID"],
+		SecretKey:       cfg.Credentials["TENCENTCLOUD_SECRET_KEY"],
+		RegionID:        cfg.Region,
+		PrivateEndpoint: cfg.PrivateEndpoint,
+	}
+	if cfg.AuthMode == tencentAuthTCCLI {
+		cred, err := loadTCCLICredential(cfg.Credentials["TCCLI_PROFILE"], time.Now())
+		if err != nil {
+			return nil, err
+		}
+		t.SecretID, t.SecretKey, t.Token = cred.SecretID, cred.SecretKey, cred.Token
+	}
+	return t, nil
+}
+
 // TencentCloud struct of tencent cloud
 type TencentCloud struct {
 	SecretID  string
 	SecretKey string
+	Token     string // session token of temporary credentials
 	RegionID  string
+	// PrivateEndpoint fetches kubeconfigs using the intranet API server
+	// address.
+	PrivateEndpoint bool
+}
+
+// tccliCredential is a ~/.tccli/<profile>.credential file written by
+// tccli configure or tccli auth login.
+type tccliCredential struct {
+	SecretID  string `json:"secretId"`
+	SecretKey string `json:"secretKey"`
+	Token     string `json:"token"`
+	ExpiresAt int64  `json:"expiresAt"` // unix seconds, zero for permanent keys
 }
 
-// getTenClient get tencent openapi client
-func getTenClient(SecretID, SecretKey, RegionID string) (*tke.Client, error) {
-	credential := common.NewCredential(
-		SecretID,
-		SecretKey,
-	)
+// loadTCCLICredential reads the credentials of a tccli profile, "default"
+// if empty, failing when temporary ones have expired.
+func loadTCCLICredential(profileName string, now time.Time) (tccliCredential, error) {
+	if profileName == "" {
+		profileName = "default"
+	}
+	var cred tccliCredential
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return cred, err
+	}
+	file := filepath.Join(home, ".tccli", profileName+".credential")
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return cred, fmt.Errorf("reading tccli credentials: %w", err)
+	}
+	if err := json.Unmarshal(data, &cred); err != nil {
+		return cred, fmt.Errorf("parsing %s: %w", file, err)
+	}
+	if cred.SecretID == "" || cred.SecretKey == "" {
+		return cred, fmt.Errorf("%s has no secretId or secretKey", file)
+	}
+	if cred.ExpiresAt != 0 && now.Unix() >= cred.ExpiresAt {
+		return cred, fmt.Errorf("tccli credentials of profile %q expired at %s, run tccli auth login again", profileName, time.Unix(cred.ExpiresAt, 0).Format(time.RFC3339))
+	}
+	return cred, nil
+}
+
+// getClient get tencent openapi client
+func (t *TencentCloud) getClient(regionID string) (*tke.Client, error) {
+	credential := common.NewTokenCredential(t.SecretID, t.SecretKey, t.Token)
 	cpf := profile.NewClientProfile()
 	cpf.HttpProfile.Endpoint = "tke.tencentcloudapi.com"
-	client, err := tke.NewClient(credential, RegionID, cpf)
-	return client, err
+	return tke.NewClient(credential, regionID, cpf)
 }
 
 // GetRegionID get region id of tke cluster
 func (t *TencentCloud) GetRegionID() ([]string, error) {
-	client, err := getTenClient(t.SecretID, t.SecretKey, "")
+	return t.getRegionID(context.Background())
+}
+
+func (t *TencentCloud) getRegionID(ctx context.Context) ([]string, error) {
+	client, err := t.getClient("")
 	if err != nil {
 		return nil, err
 	}
 	request := tke.NewDescribeRegionsRequest()
-	response, err := client.DescribeRegions(request)
-	if _, ok := err.(*errors.TencentCloudSDKError); ok {
-		fmt.Printf("An API error has returned: %s", err)
+	response, err := client.DescribeRegionsWithContext(ctx, request)
+	if err != nil {
 		return nil, err
 	}
 	var regionList []string
 	for _, region := range response.Response.RegionInstanceSet {
-		regionList = append(regionList, *region.RegionName)
+		regionList = append(regionList, tencentString(region.RegionName))
 	}
-	fmt.Printf("%s", regionList)
 	return regionList, nil
 }
 
 // ListCluster list tke cluster info
 func (t *TencentCloud) ListCluster() (clusters []ClusterInfo, err error) {
-	client, err := getTenClient(t.SecretID, t.SecretKey, t.RegionID)
+	return t.ListClusters(context.Background())
+}
+
+// ListClusters implements Provider.
+func (t *TencentCloud) ListClusters(ctx context.Context) ([]ClusterInfo, error) {
+	client, err := t.getClient(t.RegionID)
 	if err != nil {
 		return nil, err
 	}
+	var clusterList []ClusterInfo
 	request := tke.NewDescribeClustersRequest()
-	response, err := client.DescribeClusters(request)
-	if _, ok := err.(*errors.TencentCloudSDKError); ok {
-		fmt.Printf("An API error has returned: %s", err)
-		return nil, err
+	request.Limit = common.Int64Ptr(tencentPageSize)
+	for offset := int64(0); ; {
+		request.Offset = common.Int64Ptr(offset)
+		response, err := client.DescribeClustersWithContext(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+		for _, cluster := range response.Response.Clusters {
+			var nodeCount int
+			if cluster.ClusterNodeNum != nil {
+				nodeCount = int(*cluster.ClusterNodeNum)
+			}
+			id := tencentString(cluster.ClusterId)
+			clusterList = append(clusterList, ClusterInfo{
+				Name:       tencentString(cluster.ClusterName),
+				ID:         id,
+				RegionID:   t.RegionID,
+				K8sVersion: tencentString(cluster.ClusterVersion),
+				ConsoleURL: fmt.Sprintf("https://console.cloud.tencent.com/tke2/cluster/sub/list/basic/info?clusterId=%s", id),
+				Tags:       tencentTags(cluster.TagSpecification),
+				Status:     tencentString(cluster.ClusterStatus),
+				NodeCount:  nodeCount,
+				Endpoint:   tencentEndpoint(ctx, client, id),
+				CreatedAt:  parseCreated(tencentString(cluster.CreatedTime)),
+			})
+		}
+		offset += int64(len(response.Response.Clusters))
+		if len(response.Response.Clusters) == 0 || response.Response.TotalCount == nil || offset >= *response.Response.TotalCount {
+			break
+		}
 	}
-	var clusterList []ClusterInfo
-	for _, cluster := range response.Response.Clusters {
-		clusterList = append(clusterList, ClusterInfo{
-			Name:       *cluster.ClusterName,
-			ID:         *cluster.ClusterId,
-			RegionID:   t.RegionID,
-			K8sVersion: *cluster.ClusterVersion,
-			ConsoleURL: fmt.Sprintf("https://console.cloud.tencent.com/tke2/cluster/sub/list/basic/info?clusterId=%s", *cluster.ClusterId),
-		})
+	return clusterList, nil
+}
+
+// tencentPageSize is the number of clusters asked for per DescribeClusters
+// call, the maximum the API accepts.
+const tencentPageSize = 100
+
+// tencentEndpoint tells the endpoint visibility of a TKE cluster, empty if
+// its endpoints cannot be described.
+func tencentEndpoint(ctx context.Context, client *tke.Client, clusterID string) string {
+	request := tke.NewDescribeClusterEndpointsRequest()
+	request.ClusterId = common.StringPtr(clusterID)
+	response, err := client.DescribeClusterEndpointsWithContext(ctx, request)
+	if err != nil {
+		return ""
+	}
+	switch {
+	case tencentString(response.Response.ClusterExternalEndpoint) != "":
+		return EndpointPublic
+	case tencentString(response.Response.ClusterIntranetEndpoint) != "":
+		return EndpointPrivate
+	}
+	return ""
+}
+
+func tencentString(s *string) string {
+	if s == nil {
+		return ""
 	}
-	return clusterList, err
+	return *s
+}
+
+func tencentTags(specs []*tke.TagSpecification) map[string]string {
+	out := make(map[string]string)
+	for _, spec := range specs {
+		for _, tag := range spec.Tags {
+			if tag.Key == nil {
+				continue
+			}
+			out[*tag.Key] = tencentString(tag.Value)
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
 }
 
 // GetKubeConfig get tke kubeConfig file
 func (t *TencentCloud) GetKubeConfig(clusterID string) (string, error) {
-	client, err := getTenClient(t.SecretID, t.SecretKey, t.RegionID)
+	return t.getKubeConfig(context.Background(), clusterID)
+}
+
+// GetKubeconfig implements Provider.
+func (t *TencentCloud) GetKubeconfig(ctx context.Context, cluster ClusterInfo) (*clientcmdapi.Config, error) {
+	kubeconfig, err := t.getKubeConfig(ctx, cluster.ID)
+	if err != nil {
+		return nil, err
+	}
+	return clientcmd.Load([]byte(kubeconfig))
+}
+
+func (t *TencentCloud) getKubeConfig(ctx context.Context, clusterID string) (string, error) {
+	client, err := t.getClient(t.RegionID)
 	if err != nil {
 		return "", err
 	}
 	request := tke.NewDescribeClusterKubeconfigRequest()
 	request.ClusterId = common.StringPtr(clusterID)
-	response, err := client.DescribeClusterKubeconfig(request)
-	if _, ok := err.(*errors.TencentCloudSDKError); ok {
-		fmt.Printf("An API error has returned: %s", err)
+	request.IsExtranet = common.BoolPtr(!t.PrivateEndpoint)
+	response, err := client.DescribeClusterKubeconfigWithContext(ctx, request)
+	if err != nil {
 		return "", err
 	}
-	return *(response.Response.Kubeconfig), err
+	if response.Response.Kubeconfig == nil {
+		return "", fmt.Errorf("no kubeconfig returned for cluster %s", clusterID)
+	}
+	return *response.Response.Kubeconfig, nil
 }
@@ -0,0 +1,51 @@
+package cloud
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTCCLICredential(t *testing.T, profile, content string) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	dir := filepath.Join(home, ".tccli")
+	require.NoError(t, os.MkdirAll(dir, 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, profile+".credential"), []byte(content), 0o600))
+}
+
+func TestLoadTCCLICredential(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	writeTCCLICredential(t, "default", `{"secretId":"id","secretKey":"key"}`)
+	cred, err := loadTCCLICredential("", now)
+	require.NoError(t, err)
+	assert.Equal(t, tccliCredential{SecretID: "id", SecretKey: "key"}, cred)
+
+	writeTCCLICredential(t, "dev", `{"secretId":"id","secretKey":"key","token":"tok","expiresAt":1700000600}`)
+	cred, err = loadTCCLICredential("dev", now)
+	require.NoError(t, err)
+	assert.Equal(t, "tok", cred.Token)
+
+	_, err = loadTCCLICredential("dev", now.Add(time.Hour))
+	assert.ErrorContains(t, err, `tccli credentials of profile "dev" expired`)
+
+	_, err = loadTCCLICredential("missing", now)
+	assert.ErrorContains(t, err, "reading tccli credentials")
+
+	writeTCCLICredential(t, "empty", `{"token":"tok"}`)
+	_, err = loadTCCLICredential("empty", now)
+	assert.ErrorContains(t, err, "has no secretId or secretKey")
+}
+
+func TestNewTencentCloud(t *testing.T) {
+	writeTCCLICredential(t, "default", `{"secretId":"id","secretKey":"key","token":"tok"}`)
+	tc, err := newTencentCloud(ProviderConfig{AuthMode: tencentAuthTCCLI, Region: "ap-guangzhou", PrivateEndpoint: true})
+	require.NoError(t, err)
+	assert.Equal(t, &TencentCloud{SecretID: "id", SecretKey: "key", Token: "tok", RegionID: "ap-guangzhou", PrivateEndpoint: true}, tc)
+}
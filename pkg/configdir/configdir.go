@@ -0,0 +1,48 @@
+// Package configdir locates the kubecm configuration directory, shared by
+// the registries and the export bundle identity.
+package configdir
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"runtime"
+)
+
+const kubecmDir = ".kubecm"
+
+// Dir returns the kubecm configuration directory.
+// If KUBECM_HOME is set, it is used directly. Otherwise defaults to ~/.kubecm/.
+func Dir() (string, error) {
+	if dir := os.Getenv("KUBECM_HOME"); dir != "" {
+		return dir, nil
+	}
+	home, err := homeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, kubecmDir), nil
+}
+
+func homeDir() (string, error) {
+	u, err := user.Current()
+	if err == nil {
+		return u.HomeDir, nil
+	}
+	if runtime.GOOS == "windows" {
+		drive := os.Getenv("HOMEDRIVE")
+		path := os.Getenv("HOMEPATH")
+		if drive != "" && path != "" {
+			return drive + path, nil
+		}
+		if home := os.Getenv("USERPROFILE"); home != "" {
+			return home, nil
+		}
+		return "", fmt.Errorf("cannot determine home directory")
+	}
+	if home := os.Getenv("HOME"); home != "" {
+		return home, nil
+	}
+	return "", fmt.Errorf("cannot determine home directory")
+}
@@ -0,0 +1,131 @@
+// Package metadata keeps what kubeconfig contexts have no field for: their
+// description, tags and environment tier. It is stored in an extension of
+// each context, so it stays in the kubeconfig file with the context and goes
+// along with its renames, merges and deletions.
+package metadata
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// Extension is the name of the context extension holding the metadata.
+const Extension = "kubecm.io/metadata"
+
+// Environment tiers of a context.
+const (
+	TierDev     = "dev"
+	TierStaging = "staging"
+	TierProd    = "prod"
+)
+
+// Tiers lists the supported environment tiers.
+var Tiers = []string{TierDev, TierStaging, TierProd}
+
+// Metadata describes a context.
+type Metadata struct {
+	Tier        string   `json:"tier,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+}
+
+// Get returns the metadata of a context, empty when it has none.
+func Get(ctx *clientcmdapi.Context) (Metadata, error) {
+	var m Metadata
+	if ctx == nil {
+		return m, nil
+	}
+	ext, ok := ctx.Extensions[Extension]
+	if !ok || ext == nil {
+		return m, nil
+	}
+	raw, ok := ext.(*runtime.Unknown)
+	if !ok {
+		return m, fmt.Errorf("unexpected %s extension of type %T", Extension, ext)
+	}
+	if err := json.Unmarshal(raw.Raw, &m); err != nil {
+		return Metadata{}, fmt.Errorf("parsing %s extension: %w", Extension, err)
+	}
+	return m, nil
+}
+
+// Lookup returns the metadata of a context, empty when it has none or it
+// cannot be read.
+func Lookup(ctx *clientcmdapi.Context) Metadata {
+	m, _ := Get(ctx)
+	return m
+}
+
+// Set replaces the metadata of a context, removing the extension when m is
+// empty.
+func Set(ctx *clientcmdapi.Context, m Metadata) error {
+	if m.IsEmpty() {
+		delete(ctx.Extensions, Extension)
+		return nil
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("marshaling context metadata: %w", err)
+	}
+	if ctx.Extensions == nil {
+		ctx.Extensions = map[string]runtime.Object{}
+	}
+	ctx.Extensions[Extension] = &runtime.Unknown{Raw: data, ContentType: runtime.ContentTypeJSON}
+	return nil
+}
+
+// HasAny reports whether a context of config has metadata.
+func HasAny(config *clientcmdapi.Config) bool {
+	for _, ctx := range config.Contexts {
+		if !Lookup(ctx).IsEmpty() {
+			return true
+		}
+	}
+	return false
+}
+
+// IsEmpty reports whether m holds nothing.
+func (m Metadata) IsEmpty() bool {
+	return m.Tier == "" && m.Description == "" && len(m.Tags) == 0
+}
+
+// AddTags adds tags, keeping them sorted and unique.
+func (m *Metadata) AddTags(tags ...string) {
+	for _, tag := range tags {
+		if !slices.Contains(m.Tags, tag) {
+			m.Tags = append(m.Tags, tag)
+		}
+	}
+	slices.Sort(m.Tags)
+}
+
+// RemoveTags removes tags.
+func (m *Metadata) RemoveTags(tags ...string) {
+	m.Tags = slices.DeleteFunc(m.Tags, func(tag string) bool {
+		return slices.Contains(tags, tag)
+	})
+	if len(m.Tags) == 0 {
+		m.Tags = nil
+	}
+}
+
+// ValidateTier checks tier is empty or one of Tiers.
+func ValidateTier(tier string) error {
+	if tier != "" && !slices.Contains(Tiers, tier) {
+		return fmt.Errorf("invalid tier %q, expected one of: %s", tier, strings.Join(Tiers, ", "))
+	}
+	return nil
+}
+
+// ValidateTag checks a tag is a non-empty word, such as team-a or team=a.
+func ValidateTag(tag string) error {
+	if tag == "" || strings.ContainsAny(tag, ", \t\n") {
+		return fmt.Errorf("invalid tag %q, tags cannot be empty or contain commas or spaces", tag)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package metadata
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+func TestGetSet(t *testing.T) {
+	ctx := clientcmdapi.NewContext()
+	if m, err := Get(ctx); err != nil || !m.IsEmpty() {
+		t.Fatalf("Get() of a context without metadata = %+v, %v", m, err)
+	}
+	want := Metadata{Tier: TierProd, Description: "EU production", Tags: []string{"team-a"}}
+	if err := Set(ctx, want); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if got, err := Get(ctx); err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("Get() = %+v, %v, want %+v", got, err, want)
+	}
+	if err := Set(ctx, Metadata{}); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if _, ok := ctx.Extensions[Extension]; ok {
+		t.Error("Set() kept empty metadata")
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	config := clientcmdapi.NewConfig()
+	config.Clusters["c"] = &clientcmdapi.Cluster{Server: "https://c"}
+	config.AuthInfos["u"] = clientcmdapi.NewAuthInfo()
+	config.Contexts["prod-eu"] = &clientcmdapi.Context{Cluster: "c", AuthInfo: "u"}
+	config.Contexts["dev"] = &clientcmdapi.Context{Cluster: "c", AuthInfo: "u"}
+	want := Metadata{Tier: TierProd, Tags: []string{"team-a"}}
+	if err := Set(config.Contexts["prod-eu"], want); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "config")
+	if err := clientcmd.WriteToFile(*config, path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := clientcmd.LoadFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := Get(loaded.Contexts["prod-eu"]); err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("Get() after loading = %+v, %v, want %+v", got, err, want)
+	}
+	if !HasAny(loaded) {
+		t.Error("HasAny() = false")
+	}
+	delete(loaded.Contexts, "prod-eu")
+	if HasAny(loaded) {
+		t.Error("HasAny() without the tagged context = true")
+	}
+}
+
+func TestGetInvalid(t *testing.T) {
+	ctx := clientcmdapi.NewContext()
+	ctx.Extensions[Extension] = &runtime.Unknown{Raw: []byte("[")}
+	if _, err := Get(ctx); err == nil {
+		t.Error("Get() of an invalid extension succeeded")
+	}
+	if m := Lookup(ctx); !m.IsEmpty() {
+		t.Errorf("Lookup() of an invalid extension = %+v", m)
+	}
+}
+
+func TestTags(t *testing.T) {
+	var m Metadata
+	m.AddTags("b", "a", "b")
+	if !reflect.DeepEqual(m.Tags, []string{"a", "b"}) {
+		t.Errorf("AddTags() = %v", m.Tags)
+	}
+	m.RemoveTags("a", "b")
+	if m.Tags != nil || !m.IsEmpty() {
+		t.Errorf("RemoveTags() = %v", m.Tags)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, tier := range []string{"", TierDev, TierStaging, TierProd} {
+		if err := ValidateTier(tier); err != nil {
+			t.Errorf("ValidateTier(%q) error = %v", tier, err)
+		}
+	}
+	if err := ValidateTier("qa"); err == nil {
+		t.Error("ValidateTier(qa) succeeded")
+	}
+	for _, tag := range []string{"team-a", "owner=ops"} {
+		if err := ValidateTag(tag); err != nil {
+			t.Errorf("ValidateTag(%q) error = %v", tag, err)
+		}
+	}
+	for _, tag := range []string{"", "a,b", "a b"} {
+		if err := ValidateTag(tag); err == nil {
+			t.Errorf("ValidateTag(%q) succeeded", tag)
+		}
+	}
+}
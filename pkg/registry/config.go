@@ -3,15 +3,15 @@ package registry
 import (
 	"fmt"
 	"os"
-	"os/user"
 	"path/filepath"
-	"runtime"
+	"sort"
+	"strings"
 
+	"github.com/sunny0826/kubecm/pkg/configdir"
 	"gopkg.in/yaml.v3"
 )
 
 const (
-	kubecmDir  = ".kubecm"
 	configFile = "config.yaml"
 	registriesDir = "registries"
 )
@@ -19,14 +19,7 @@ const (
 // ConfigDir returns the kubecm configuration directory.
 // If KUBECM_HOME is set, it is used directly. Otherwise defaults to ~/.kubecm/.
 func ConfigDir() (string, error) {
-	if dir := os.Getenv("KUBECM_HOME"); dir != "" {
-		return dir, nil
-	}
-	home, err := homeDir()
-	if err != nil {
-		return "", err
-	}
-	return filepath.Join(home, kubecmDir), nil
+	return configdir.Dir()
 }
 
 // ConfigFilePath returns ~/.kubecm/config.yaml
@@ -160,6 +153,7 @@ func LoadCluster(repoDir, clusterName string) (*Cluster, error) {
 	if err := yaml.Unmarshal(data, &cl); err != nil {
 		return nil, fmt.Errorf("parsing cluster %q: %w", clusterName, err)
 	}
+	cl.repoDir = repoDir
 	return &cl, nil
 }
 
@@ -182,24 +176,87 @@ func LoadUser(repoDir, userName string) (*User, error) {
 	return &u, nil
 }
 
-func homeDir() (string, error) {
-	u, err := user.Current()
-	if err == nil {
-		return u.HomeDir, nil
+// ListRoles returns the names of all roles in a cloned registry repo.
+func ListRoles(repoDir string) ([]string, error) {
+	return listYAMLNames(filepath.Join(repoDir, "roles"))
+}
+
+// RoleSummary is a short description of a role for listings.
+type RoleSummary struct {
+	Name          string
+	Description   string
+	ContextPrefix string
+	Clusters      int
+}
+
+// ListRoleSummaries loads every role in a cloned registry repo.
+func ListRoleSummaries(repoDir string) ([]RoleSummary, error) {
+	names, err := ListRoles(repoDir)
+	if err != nil {
+		return nil, err
+	}
+	summaries := make([]RoleSummary, 0, len(names))
+	for _, name := range names {
+		role, err := LoadRole(repoDir, name)
+		if err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, RoleSummary{
+			Name:          name,
+			Description:   role.Metadata.Description,
+			ContextPrefix: role.ContextPrefix,
+			Clusters:      len(role.NormalizedContexts()),
+		})
+	}
+	return summaries, nil
+}
+
+// ListClusters returns the names of all clusters in a cloned registry repo,
+// including those in the legacy fragments/ directory.
+func ListClusters(repoDir string) ([]string, error) {
+	names, err := listYAMLNames(filepath.Join(repoDir, "clusters"))
+	if err != nil {
+		return nil, err
+	}
+	legacy, err := listYAMLNames(filepath.Join(repoDir, "fragments"))
+	if err != nil {
+		return nil, err
 	}
-	if runtime.GOOS == "windows" {
-		drive := os.Getenv("HOMEDRIVE")
-		path := os.Getenv("HOMEPATH")
-		if drive != "" && path != "" {
-			return drive + path, nil
+	seen := make(map[string]bool, len(names))
+	for _, n := range names {
+		seen[n] = true
+	}
+	for _, n := range legacy {
+		if !seen[n] {
+			names = append(names, n)
 		}
-		if home := os.Getenv("USERPROFILE"); home != "" {
-			return home, nil
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// ListUsers returns the names of all users in a cloned registry repo.
+func ListUsers(repoDir string) ([]string, error) {
+	return listYAMLNames(filepath.Join(repoDir, "users"))
+}
+
+// listYAMLNames returns the sorted base names of *.yaml files in dir.
+// A missing directory yields an empty list.
+func listYAMLNames(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
 		}
-		return "", fmt.Errorf("cannot determine home directory")
+		return nil, fmt.Errorf("reading %s: %w", dir, err)
 	}
-	if home := os.Getenv("HOME"); home != "" {
-		return home, nil
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".yaml" {
+			continue
+		}
+		names = append(names, strings.TrimSuffix(e.Name(), ".yaml"))
 	}
-	return "", fmt.Errorf("cannot determine home directory")
+	sort.Strings(names)
+	return names, nil
 }
@@ -0,0 +1,206 @@
+package registry
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// Diff actions reported in ContextDiff.
+const (
+	DiffAdd    = "add"
+	DiffRemove = "remove"
+	DiffChange = "change"
+	DiffRename = "rename"
+)
+
+// FieldChange is a single differing field of a context.
+type FieldChange struct {
+	Field string
+	Old   string
+	New   string
+}
+
+// ContextDiff describes how one managed context would change on sync.
+type ContextDiff struct {
+	Name    string // "old -> new" for renames
+	Action  string
+	Changes []FieldChange
+}
+
+// diffFields lists the compared fields in display order.
+var diffFields = []string{
+	"namespace",
+	"server",
+	"certificate-authority",
+	"insecure-skip-tls-verify",
+	"token",
+	"exec.command",
+	"exec.args",
+}
+
+// Diff computes the field-level difference between the current kubeconfig
+// and what Sync would write for the entry. Neither current nor entry is
+// modified. The returned SyncResult carries skips and errors of the
+// simulated sync.
+func Diff(repoDir string, entry *RegistryEntry, current *clientcmdapi.Config) ([]ContextDiff, *SyncResult, error) {
+	desired := current.DeepCopy()
+	simulated := *entry
+
+	result, err := Sync(repoDir, &simulated, desired, false)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	renamedTo := make(map[string]string)
+	renamedFrom := make(map[string]bool)
+	for _, r := range result.Renamed {
+		oldName, newName, _ := strings.Cut(r, " -> ")
+		renamedTo[oldName] = newName
+		renamedFrom[newName] = true
+	}
+
+	names := make(map[string]bool)
+	for _, n := range entry.ManagedContexts {
+		names[n] = true
+	}
+	for _, n := range simulated.ManagedContexts {
+		names[n] = true
+	}
+	sorted := make([]string, 0, len(names))
+	for n := range names {
+		sorted = append(sorted, n)
+	}
+	sort.Strings(sorted)
+
+	var diffs []ContextDiff
+	for _, name := range sorted {
+		if renamedFrom[name] {
+			continue
+		}
+		_, inCurrent := current.Contexts[name]
+		_, inDesired := desired.Contexts[name]
+
+		switch {
+		case renamedTo[name] != "":
+			newName := renamedTo[name]
+			diffs = append(diffs, ContextDiff{
+				Name:    name + " -> " + newName,
+				Action:  DiffRename,
+				Changes: compareFields(contextFields(current, name), contextFields(desired, newName)),
+			})
+		case inCurrent && inDesired:
+			changes := compareFields(contextFields(current, name), contextFields(desired, name))
+			if len(changes) > 0 {
+				diffs = append(diffs, ContextDiff{Name: name, Action: DiffChange, Changes: changes})
+			}
+		case inDesired:
+			diffs = append(diffs, ContextDiff{
+				Name:    name,
+				Action:  DiffAdd,
+				Changes: compareFields(nil, contextFields(desired, name)),
+			})
+		case inCurrent:
+			diffs = append(diffs, ContextDiff{
+				Name:    name,
+				Action:  DiffRemove,
+				Changes: compareFields(contextFields(current, name), nil),
+			})
+		}
+	}
+	return diffs, result, nil
+}
+
+// contextFields flattens the comparable parts of a context, its cluster and
+// its user. Secrets are reduced to fingerprints.
+func contextFields(cfg *clientcmdapi.Config, name string) map[string]string {
+	fields := make(map[string]string)
+	ctx, ok := cfg.Contexts[name]
+	if !ok {
+		return fields
+	}
+	fields["namespace"] = ctx.Namespace
+
+	if cluster, ok := cfg.Clusters[ctx.Cluster]; ok {
+		fields["server"] = cluster.Server
+		if len(cluster.CertificateAuthorityData) > 0 {
+			fields["certificate-authority"] = fingerprint(cluster.CertificateAuthorityData)
+		} else {
+			fields["certificate-authority"] = cluster.CertificateAuthority
+		}
+		if cluster.InsecureSkipTLSVerify {
+			fields["insecure-skip-tls-verify"] = "true"
+		}
+	}
+
+	if user, ok := cfg.AuthInfos[ctx.AuthInfo]; ok {
+		if user.Token != "" {
+			fields["token"] = fingerprint([]byte(user.Token))
+		}
+		if user.Exec != nil {
+			fields["exec.command"] = user.Exec.Command
+			fields["exec.args"] = strings.Join(user.Exec.Args, " ")
+		}
+	}
+	return fields
+}
+
+// compareFields returns the fields whose values differ, in diffFields order.
+func compareFields(before, after map[string]string) []FieldChange {
+	var changes []FieldChange
+	for _, f := range diffFields {
+		if before[f] != after[f] {
+			changes = append(changes, FieldChange{Field: f, Old: before[f], New: after[f]})
+		}
+	}
+	return changes
+}
+
+// fingerprint returns a short sha256 digest suitable for display.
+func fingerprint(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])[:12]
+}
+
+// FormatDiff returns a human-readable rendering of context diffs.
+func FormatDiff(diffs []ContextDiff) string {
+	if len(diffs) == 0 {
+		return "  No differences.\n"
+	}
+
+	var sb strings.Builder
+	for _, d := range diffs {
+		marker := "~"
+		switch d.Action {
+		case DiffAdd:
+			marker = "+"
+		case DiffRemove:
+			marker = "-"
+		case DiffRename:
+			marker = ">"
+		}
+		fmt.Fprintf(&sb, "  %s %s\n", marker, d.Name)
+		for _, c := range d.Changes {
+			switch d.Action {
+			case DiffAdd:
+				fmt.Fprintf(&sb, "      %s: %s\n", c.Field, c.New)
+			case DiffRemove:
+				fmt.Fprintf(&sb, "      %s: %s\n", c.Field, c.Old)
+			default:
+				fmt.Fprintf(&sb, "      %s: %s -> %s\n", c.Field, displayValue(c.Old), displayValue(c.New))
+			}
+		}
+	}
+	return sb.String()
+}
+
+func displayValue(v string) string {
+	if v == "" {
+		return "<none>"
+	}
+	return v
+}
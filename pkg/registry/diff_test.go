@@ -0,0 +1,85 @@
+package registry
+
+import (
+	"strings"
+	"testing"
+
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+func TestDiff(t *testing.T) {
+	repoDir := setupTestRegistry(t)
+	entry := &RegistryEntry{
+		Name: "test",
+		Role: "devops",
+		Variables: map[string]string{
+			"Username": "clark",
+		},
+		ManagedContexts: []string{"test-onprem-dc1", "test-gone"},
+	}
+
+	currentConfig := clientcmdapi.NewConfig()
+	currentConfig.Contexts["test-onprem-dc1"] = &clientcmdapi.Context{
+		Cluster:   "test-onprem-dc1",
+		AuthInfo:  "test-onprem-dc1",
+		Namespace: "team",
+	}
+	currentConfig.Clusters["test-onprem-dc1"] = &clientcmdapi.Cluster{Server: "https://old:6443"}
+	currentConfig.AuthInfos["test-onprem-dc1"] = &clientcmdapi.AuthInfo{Token: "clark-token"}
+	currentConfig.Contexts["test-gone"] = &clientcmdapi.Context{Cluster: "test-gone", AuthInfo: "test-gone"}
+	currentConfig.Clusters["test-gone"] = &clientcmdapi.Cluster{Server: "https://gone:6443"}
+	currentConfig.AuthInfos["test-gone"] = &clientcmdapi.AuthInfo{Token: "x"}
+
+	diffs, _, err := Diff(repoDir, entry, currentConfig)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	byName := make(map[string]ContextDiff)
+	for _, d := range diffs {
+		byName[d.Name] = d
+	}
+
+	changed, ok := byName["test-onprem-dc1"]
+	if !ok || changed.Action != DiffChange {
+		t.Fatalf("expected change for 'test-onprem-dc1', got %+v", diffs)
+	}
+	var fields []string
+	for _, c := range changed.Changes {
+		fields = append(fields, c.Field)
+	}
+	// Token is unchanged; namespace and server are rewritten by sync
+	if strings.Join(fields, ",") != "namespace,server" {
+		t.Errorf("changed fields = %v, want [namespace server]", fields)
+	}
+
+	if d := byName["test-onprem-dc2"]; d.Action != DiffAdd {
+		t.Errorf("expected add for 'test-onprem-dc2', got %+v", d)
+	}
+	if d := byName["test-gone"]; d.Action != DiffRemove {
+		t.Errorf("expected remove for 'test-gone', got %+v", d)
+	}
+
+	// Diff must not touch the inputs
+	if currentConfig.Clusters["test-onprem-dc1"].Server != "https://old:6443" {
+		t.Error("diff should not modify the current config")
+	}
+	if len(entry.ManagedContexts) != 2 || entry.LastSync != nil {
+		t.Error("diff should not modify the registry entry")
+	}
+}
+
+func TestFormatDiff(t *testing.T) {
+	out := FormatDiff([]ContextDiff{
+		{Name: "a", Action: DiffAdd, Changes: []FieldChange{{Field: "server", New: "https://a:6443"}}},
+		{Name: "b", Action: DiffChange, Changes: []FieldChange{{Field: "namespace", Old: "", New: "team"}}},
+	})
+	want := "  + a\n      server: https://a:6443\n  ~ b\n      namespace: <none> -> team\n"
+	if out != want {
+		t.Errorf("FormatDiff() = %q, want %q", out, want)
+	}
+
+	if FormatDiff(nil) != "  No differences.\n" {
+		t.Errorf("expected 'No differences.' for empty diff")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"strings"
 )
 
 // GitClone clones a git repository to destDir.
@@ -29,3 +30,14 @@ func GitPull(repoDir string) error {
 	}
 	return nil
 }
+
+// GitHead returns the commit hash checked out in the given repo directory.
+func GitHead(repoDir string) (string, error) {
+	cmd := exec.Command("git", "rev-parse", "HEAD")
+	cmd.Dir = repoDir
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git rev-parse in %s: %w", repoDir, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
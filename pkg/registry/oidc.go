@@ -0,0 +1,67 @@
+package registry
+
+import (
+	"fmt"
+
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+const defaultOIDCCommand = "kubectl"
+
+// isOIDCOnly reports whether the user only carries OIDC settings, in which
+// case it can be bound to a cluster of any provider.
+func (u *User) isOIDCOnly() bool {
+	return u.OIDC != nil && (u.Provider == "" || u.Provider == "oidc")
+}
+
+// OIDCExecConfig builds the exec configuration that runs
+// `kubectl oidc-login get-token` for the given settings.
+func OIDCExecConfig(o *OIDCUserConfig) (*clientcmdapi.ExecConfig, error) {
+	if o.IssuerURL == "" {
+		return nil, fmt.Errorf("oidc: issuerUrl is required")
+	}
+	if o.ClientID == "" {
+		return nil, fmt.Errorf("oidc: clientId is required")
+	}
+
+	command := o.Command
+	if command == "" {
+		command = defaultOIDCCommand
+	}
+
+	var args []string
+	if command == defaultOIDCCommand {
+		args = append(args, "oidc-login")
+	}
+	args = append(args,
+		"get-token",
+		"--oidc-issuer-url="+o.IssuerURL,
+		"--oidc-client-id="+o.ClientID,
+	)
+	for _, scope := range o.Scopes {
+		args = append(args, "--oidc-extra-scope="+scope)
+	}
+	args = append(args, o.ExtraArgs...)
+
+	return &clientcmdapi.ExecConfig{
+		APIVersion:      "client.authentication.k8s.io/v1beta1",
+		Command:         command,
+		Args:            args,
+		InteractiveMode: clientcmdapi.IfAvailableExecInteractiveMode,
+	}, nil
+}
+
+// applyOIDC replaces the credentials of every user in cfg with the
+// OIDC exec configuration of u.
+func applyOIDC(cfg *clientcmdapi.Config, u *User) (*clientcmdapi.Config, error) {
+	execConfig, err := OIDCExecConfig(u.OIDC)
+	if err != nil {
+		return nil, fmt.Errorf("user %q: %w", u.Metadata.Name, err)
+	}
+	for name := range cfg.AuthInfos {
+		exec := *execConfig
+		exec.Args = append([]string(nil), execConfig.Args...)
+		cfg.AuthInfos[name] = &clientcmdapi.AuthInfo{Exec: &exec}
+	}
+	return cfg, nil
+}
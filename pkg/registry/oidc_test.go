@@ -0,0 +1,139 @@
+package registry
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+func TestOIDCExecConfig(t *testing.T) {
+	exec, err := OIDCExecConfig(&OIDCUserConfig{
+		IssuerURL: "https://dex.example.com",
+		ClientID:  "kubernetes",
+		Scopes:    []string{"email", "groups"},
+		ExtraArgs: []string{"--grant-type=device-code"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if exec.Command != "kubectl" {
+		t.Errorf("command = %q, want %q", exec.Command, "kubectl")
+	}
+	wantArgs := []string{
+		"oidc-login",
+		"get-token",
+		"--oidc-issuer-url=https://dex.example.com",
+		"--oidc-client-id=kubernetes",
+		"--oidc-extra-scope=email",
+		"--oidc-extra-scope=groups",
+		"--grant-type=device-code",
+	}
+	if !reflect.DeepEqual(exec.Args, wantArgs) {
+		t.Errorf("args = %v, want %v", exec.Args, wantArgs)
+	}
+}
+
+func TestOIDCExecConfig_CustomCommand(t *testing.T) {
+	exec, err := OIDCExecConfig(&OIDCUserConfig{
+		IssuerURL: "https://keycloak.example.com/realms/k8s",
+		ClientID:  "kubectl",
+		Command:   "kubelogin",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if exec.Command != "kubelogin" || exec.Args[0] != "get-token" {
+		t.Errorf("unexpected exec: %s %v", exec.Command, exec.Args)
+	}
+}
+
+func TestOIDCExecConfig_MissingFields(t *testing.T) {
+	if _, err := OIDCExecConfig(&OIDCUserConfig{ClientID: "kubernetes"}); err == nil {
+		t.Error("expected error for missing issuerUrl")
+	}
+	if _, err := OIDCExecConfig(&OIDCUserConfig{IssuerURL: "https://dex.example.com"}); err == nil {
+		t.Error("expected error for missing clientId")
+	}
+}
+
+func TestResolveClusterWithUser_OIDC(t *testing.T) {
+	cl := &Cluster{
+		Metadata:   RegistryMetadata{Name: "onprem"},
+		Provider:   "static",
+		Kubeconfig: staticKubeconfig("https://k8s:6443", "static-token"),
+	}
+	user := &User{
+		Metadata: RegistryMetadata{Name: "sso"},
+		Provider: "oidc",
+		OIDC: &OIDCUserConfig{
+			IssuerURL: "https://dex.example.com",
+			ClientID:  "kubernetes",
+		},
+	}
+
+	cfg, err := ResolveClusterWithUser(cl, user)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	authInfo := cfg.AuthInfos["test-cluster"]
+	if authInfo.Token != "" {
+		t.Errorf("static token should be replaced, got %q", authInfo.Token)
+	}
+	if authInfo.Exec == nil || authInfo.Exec.Command != "kubectl" {
+		t.Fatalf("expected kubectl oidc-login exec config, got %+v", authInfo.Exec)
+	}
+	if cfg.Clusters["test-cluster"].Server != "https://k8s:6443" {
+		t.Errorf("server = %q, want %q", cfg.Clusters["test-cluster"].Server, "https://k8s:6443")
+	}
+}
+
+func TestSync_OIDCUser(t *testing.T) {
+	dir := setupTestRegistryWithUsers(t)
+	writeFile(t, filepath.Join(dir, "users", "sso.yaml"), `
+apiVersion: kubecm.io/v1alpha1
+kind: User
+metadata:
+  name: sso
+oidc:
+  issuerUrl: "https://{{ .Issuer }}"
+  clientId: kubernetes
+  scopes:
+    - groups
+`)
+	writeFile(t, filepath.Join(dir, "roles", "sso-role.yaml"), `
+apiVersion: kubecm.io/v1alpha1
+kind: Role
+metadata:
+  name: sso-role
+contexts:
+  - cluster: static-cluster
+    user: sso
+`)
+
+	entry := &RegistryEntry{
+		Name:      "test",
+		Role:      "sso-role",
+		Variables: map[string]string{"Issuer": "dex.example.com"},
+	}
+	currentConfig := clientcmdapi.NewConfig()
+
+	result, err := Sync(dir, entry, currentConfig, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Errors) != 0 {
+		t.Fatalf("unexpected sync errors: %v", result.Errors)
+	}
+
+	exec := currentConfig.AuthInfos["static-cluster"].Exec
+	if exec == nil {
+		t.Fatal("expected exec config for 'static-cluster'")
+	}
+	if exec.Args[2] != "--oidc-issuer-url=https://dex.example.com" {
+		t.Errorf("issuer arg = %q, want templated issuer", exec.Args[2])
+	}
+}
@@ -2,6 +2,8 @@ package registry
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 
 	"github.com/sunny0826/kubecm/pkg/cloud"
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
@@ -14,6 +16,13 @@ func ResolveClusterWithUser(cl *Cluster, user *User) (*clientcmdapi.Config, erro
 	if user == nil {
 		return ResolveCluster(cl)
 	}
+	if user.isOIDCOnly() {
+		cfg, err := ResolveCluster(cl)
+		if err != nil {
+			return nil, err
+		}
+		return applyOIDC(cfg, user)
+	}
 	if user.Provider != cl.Provider {
 		return nil, fmt.Errorf("provider mismatch: cluster %q is %q but user %q is %q",
 			cl.Metadata.Name, cl.Provider, user.Metadata.Name, user.Provider)
@@ -26,6 +35,9 @@ func ResolveClusterWithUser(cl *Cluster, user *User) (*clientcmdapi.Config, erro
 				merged.AWS = &AWSClusterConfig{}
 			}
 			merged.AWS.Profile = user.AWS.Profile
+			if user.AWS.RoleARN != "" {
+				merged.AWS.RoleARN = user.AWS.RoleARN
+			}
 		}
 	case "azure":
 		if user.Azure != nil {
@@ -34,8 +46,51 @@ func ResolveClusterWithUser(cl *Cluster, user *User) (*clientcmdapi.Config, erro
 			}
 			merged.Azure.TenantID = user.Azure.TenantID
 		}
+	case "rancher":
+		if user.Rancher != nil {
+			if merged.Rancher == nil {
+				merged.Rancher = &RancherClusterConfig{}
+			}
+			if user.Rancher.ServerURL != "" {
+				merged.Rancher.ServerURL = user.Rancher.ServerURL
+			}
+			if user.Rancher.APIKeyEnv != "" {
+				merged.Rancher.APIKeyEnv = user.Rancher.APIKeyEnv
+			}
+		}
+	case "alicloud":
+		if user.AliCloud != nil {
+			if merged.AliCloud == nil {
+				merged.AliCloud = &AliCloudClusterConfig{}
+			}
+			if user.AliCloud.AccessKeyIDEnv != "" {
+				merged.AliCloud.AccessKeyIDEnv = user.AliCloud.AccessKeyIDEnv
+			}
+			if user.AliCloud.AccessKeySecretEnv != "" {
+				merged.AliCloud.AccessKeySecretEnv = user.AliCloud.AccessKeySecretEnv
+			}
+		}
+	case "tencent":
+		if user.Tencent != nil {
+			if merged.Tencent == nil {
+				merged.Tencent = &TencentClusterConfig{}
+			}
+			if user.Tencent.SecretIDEnv != "" {
+				merged.Tencent.SecretIDEnv = user.Tencent.SecretIDEnv
+			}
+			if user.Tencent.SecretKeyEnv != "" {
+				merged.Tencent.SecretKeyEnv = user.Tencent.SecretKeyEnv
+			}
+		}
 	}
-	return ResolveCluster(merged)
+	cfg, err := ResolveCluster(merged)
+	if err != nil {
+		return nil, err
+	}
+	if user.OIDC != nil {
+		return applyOIDC(cfg, user)
+	}
+	return cfg, nil
 }
 
 // cloneCluster returns a shallow copy of the cluster with deep-copied
@@ -44,12 +99,30 @@ func cloneCluster(cl *Cluster) *Cluster {
 	c := *cl
 	if cl.AWS != nil {
 		cp := *cl.AWS
+		if cl.AWS.Env != nil {
+			cp.Env = make(map[string]string, len(cl.AWS.Env))
+			for k, v := range cl.AWS.Env {
+				cp.Env[k] = v
+			}
+		}
 		c.AWS = &cp
 	}
 	if cl.Azure != nil {
 		cp := *cl.Azure
 		c.Azure = &cp
 	}
+	if cl.Rancher != nil {
+		cp := *cl.Rancher
+		c.Rancher = &cp
+	}
+	if cl.AliCloud != nil {
+		cp := *cl.AliCloud
+		c.AliCloud = &cp
+	}
+	if cl.Tencent != nil {
+		cp := *cl.Tencent
+		c.Tencent = &cp
+	}
 	return &c
 }
 
@@ -61,6 +134,12 @@ func ResolveCluster(cl *Cluster) (*clientcmdapi.Config, error) {
 		return resolveAWS(cl)
 	case "azure":
 		return resolveAzure(cl)
+	case "rancher":
+		return resolveRancher(cl)
+	case "alicloud":
+		return resolveAliCloud(cl)
+	case "tencent":
+		return resolveTencent(cl)
 	case "static":
 		return resolveStatic(cl)
 	default:
@@ -74,9 +153,12 @@ func resolveAWS(cl *Cluster) (*clientcmdapi.Config, error) {
 	}
 
 	a := cloud.AWS{
-		AuthMode: cloud.AWSAuthDefault,
-		Profile:  cl.AWS.Profile,
-		RegionID: cl.AWS.Region,
+		AuthMode:     cloud.AWSAuthDefault,
+		Profile:      cl.AWS.Profile,
+		RegionID:     cl.AWS.Region,
+		RoleARN:      cl.AWS.RoleARN,
+		TokenCommand: cl.AWS.TokenCommand,
+		ExecEnv:      cl.AWS.Env,
 	}
 
 	cfg, err := a.GetKubeConfigObj(cl.AWS.Cluster)
@@ -109,15 +191,133 @@ func resolveAzure(cl *Cluster) (*clientcmdapi.Config, error) {
 	return cfg, nil
 }
 
+func resolveRancher(cl *Cluster) (*clientcmdapi.Config, error) {
+	if cl.Rancher == nil {
+		return nil, fmt.Errorf("cluster %q: provider is rancher but rancher section is missing", cl.Metadata.Name)
+	}
+
+	serverURL := cl.Rancher.ServerURL
+	if serverURL == "" {
+		serverURL = os.Getenv("RANCHER_SERVER_URL")
+	}
+	if serverURL == "" {
+		return nil, fmt.Errorf("cluster %q: rancher: serverUrl is not set and RANCHER_SERVER_URL is empty", cl.Metadata.Name)
+	}
+	apiKey, err := envCredential(cl.Rancher.APIKeyEnv, "RANCHER_API_KEY")
+	if err != nil {
+		return nil, fmt.Errorf("cluster %q: rancher: %w", cl.Metadata.Name, err)
+	}
+
+	caFile := cl.Rancher.CAFile
+	if caFile != "" && !filepath.IsAbs(caFile) {
+		caFile = filepath.Join(cl.repoDir, filepath.FromSlash(caFile))
+	}
+	r := cloud.Rancher{
+		ServerURL:             serverURL,
+		APIKey:                apiKey,
+		CAFile:                caFile,
+		InsecureSkipTLSVerify: cl.Rancher.InsecureSkipTLSVerify,
+	}
+
+	data, err := r.GetKubeConfig(cl.Rancher.ClusterID)
+	if err != nil {
+		return nil, fmt.Errorf("cluster %q: rancher: %w", cl.Metadata.Name, err)
+	}
+	return loadProviderKubeconfig(cl, "rancher", []byte(data))
+}
+
+func resolveAliCloud(cl *Cluster) (*clientcmdapi.Config, error) {
+	if cl.AliCloud == nil {
+		return nil, fmt.Errorf("cluster %q: provider is alicloud but alicloud section is missing", cl.Metadata.Name)
+	}
+
+	accessKeyID, err := envCredential(cl.AliCloud.AccessKeyIDEnv, "ACCESS_KEY_ID")
+	if err != nil {
+		return nil, fmt.Errorf("cluster %q: alicloud: %w", cl.Metadata.Name, err)
+	}
+	accessKeySecret, err := envCredential(cl.AliCloud.AccessKeySecretEnv, "ACCESS_KEY_SECRET")
+	if err != nil {
+		return nil, fmt.Errorf("cluster %q: alicloud: %w", cl.Metadata.Name, err)
+	}
+
+	a := cloud.AliCloud{
+		AccessKeyID:     accessKeyID,
+		AccessKeySecret: accessKeySecret,
+	}
+
+	data, err := a.GetKubeConfig(cl.AliCloud.ClusterID)
+	if err != nil {
+		return nil, fmt.Errorf("cluster %q: alicloud: %w", cl.Metadata.Name, err)
+	}
+	return loadProviderKubeconfig(cl, "alicloud", []byte(data))
+}
+
+func resolveTencent(cl *Cluster) (*clientcmdapi.Config, error) {
+	if cl.Tencent == nil {
+		return nil, fmt.Errorf("cluster %q: provider is tencent but tencent section is missing", cl.Metadata.Name)
+	}
+
+	secretID, err := envCredential(cl.Tencent.SecretIDEnv, "TENCENTCLOUD_SECRET_ID")
+	if err != nil {
+		return nil, fmt.Errorf("cluster %q: tencent: %w", cl.Metadata.Name, err)
+	}
+	secretKey, err := envCredential(cl.Tencent.SecretKeyEnv, "TENCENTCLOUD_SECRET_KEY")
+	if err != nil {
+		return nil, fmt.Errorf("cluster %q: tencent: %w", cl.Metadata.Name, err)
+	}
+
+	t := cloud.TencentCloud{
+		SecretID:  secretID,
+		SecretKey: secretKey,
+		RegionID:  cl.Tencent.Region,
+	}
+
+	data, err := t.GetKubeConfig(cl.Tencent.ClusterID)
+	if err != nil {
+		return nil, fmt.Errorf("cluster %q: tencent: %w", cl.Metadata.Name, err)
+	}
+	return loadProviderKubeconfig(cl, "tencent", []byte(data))
+}
+
+// envCredential reads a credential from the environment variable name,
+// falling back to def when name is empty. Credentials never come from Git.
+func envCredential(name, def string) (string, error) {
+	if name == "" {
+		name = def
+	}
+	val := os.Getenv(name)
+	if val == "" {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+	return val, nil
+}
+
+// loadProviderKubeconfig parses a kubeconfig returned by a cloud API.
+func loadProviderKubeconfig(cl *Cluster, provider string, data []byte) (*clientcmdapi.Config, error) {
+	cfg, err := clientcmd.Load(data)
+	if err != nil {
+		return nil, fmt.Errorf("cluster %q: parsing %s kubeconfig: %w", cl.Metadata.Name, provider, err)
+	}
+	return cfg, nil
+}
+
 func resolveStatic(cl *Cluster) (*clientcmdapi.Config, error) {
-	if cl.Kubeconfig == "" {
-		return nil, fmt.Errorf("cluster %q: provider is static but kubeconfig is empty", cl.Metadata.Name)
+	data, err := loadStaticKubeconfig(cl)
+	if err != nil {
+		return nil, fmt.Errorf("cluster %q: %w", cl.Metadata.Name, err)
 	}
 
-	cfg, err := clientcmd.Load([]byte(cl.Kubeconfig))
+	cfg, err := clientcmd.Load(data)
 	if err != nil {
 		return nil, fmt.Errorf("cluster %q: parsing static kubeconfig: %w", cl.Metadata.Name, err)
 	}
+
+	if cl.Context != "" {
+		cfg, err = selectContext(cfg, cl.Context)
+		if err != nil {
+			return nil, fmt.Errorf("cluster %q: %w", cl.Metadata.Name, err)
+		}
+	}
 	return cfg, nil
 }
 
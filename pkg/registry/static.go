@@ -0,0 +1,148 @@
+package registry
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// maxKubeconfigSize caps the size of a kubeconfig fetched from kubeconfigURL.
+const maxKubeconfigSize = 10 << 20
+
+// httpClient is used to fetch kubeconfigURL. Tests replace it to trust
+// their own TLS server.
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// loadStaticKubeconfig returns the raw kubeconfig of a static cluster from
+// exactly one of kubeconfig, kubeconfigFile or kubeconfigURL.
+func loadStaticKubeconfig(cl *Cluster) ([]byte, error) {
+	sources := 0
+	for _, s := range []string{cl.Kubeconfig, cl.KubeconfigFile, cl.KubeconfigURL} {
+		if s != "" {
+			sources++
+		}
+	}
+	switch {
+	case sources == 0:
+		return nil, fmt.Errorf("provider is static but kubeconfig is empty")
+	case sources > 1:
+		return nil, fmt.Errorf("only one of kubeconfig, kubeconfigFile or kubeconfigURL may be set")
+	}
+
+	switch {
+	case cl.KubeconfigFile != "":
+		return readKubeconfigFile(cl.repoDir, cl.KubeconfigFile)
+	case cl.KubeconfigURL != "":
+		return fetchKubeconfigURL(cl.KubeconfigURL, cl.Checksum)
+	default:
+		return []byte(cl.Kubeconfig), nil
+	}
+}
+
+// readKubeconfigFile reads a kubeconfig file relative to the registry repo.
+// Paths that would escape the repo, directly or through a symlink, are
+// rejected.
+func readKubeconfigFile(repoDir, name string) ([]byte, error) {
+	if !filepath.IsLocal(filepath.FromSlash(name)) {
+		return nil, fmt.Errorf("kubeconfigFile %q must be a relative path inside the registry", name)
+	}
+	root, err := filepath.EvalSymlinks(repoDir)
+	if err != nil {
+		return nil, fmt.Errorf("resolving registry path: %w", err)
+	}
+	path, err := filepath.EvalSymlinks(filepath.Join(root, filepath.FromSlash(name)))
+	if err != nil {
+		return nil, fmt.Errorf("reading kubeconfigFile: %w", err)
+	}
+	if rel, err := filepath.Rel(root, path); err != nil || !filepath.IsLocal(rel) {
+		return nil, fmt.Errorf("kubeconfigFile %q must be a relative path inside the registry", name)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading kubeconfigFile: %w", err)
+	}
+	return data, nil
+}
+
+// fetchKubeconfigURL downloads a kubeconfig over HTTPS and verifies it
+// against the optional checksum.
+func fetchKubeconfigURL(rawURL, checksum string) ([]byte, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("parsing kubeconfigURL: %w", err)
+	}
+	if u.Scheme != "https" {
+		return nil, fmt.Errorf("kubeconfigURL %q must use https", rawURL)
+	}
+
+	resp, err := httpClient.Get(u.String())
+	if err != nil {
+		return nil, fmt.Errorf("fetching kubeconfigURL: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching kubeconfigURL: unexpected status %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxKubeconfigSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("reading kubeconfigURL: %w", err)
+	}
+	if len(data) > maxKubeconfigSize {
+		return nil, fmt.Errorf("kubeconfigURL: response larger than %d bytes", maxKubeconfigSize)
+	}
+
+	if checksum != "" {
+		if err := verifyChecksum(data, checksum); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// verifyChecksum compares data against a "sha256:<hex>" or bare hex digest.
+func verifyChecksum(data []byte, checksum string) error {
+	want := strings.TrimPrefix(strings.ToLower(strings.TrimSpace(checksum)), "sha256:")
+	sum := sha256.Sum256(data)
+	got := hex.EncodeToString(sum[:])
+	if got != want {
+		return fmt.Errorf("checksum mismatch: got sha256:%s, want sha256:%s", got, want)
+	}
+	return nil
+}
+
+// selectContext returns a config holding only the named context and the
+// cluster and user it references.
+func selectContext(cfg *clientcmdapi.Config, name string) (*clientcmdapi.Config, error) {
+	ctx, ok := cfg.Contexts[name]
+	if !ok {
+		var names []string
+		for n := range cfg.Contexts {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("context %q not found in kubeconfig (available: %s)", name, strings.Join(names, ", "))
+	}
+
+	out := clientcmdapi.NewConfig()
+	out.Contexts[name] = ctx
+	if cluster, ok := cfg.Clusters[ctx.Cluster]; ok {
+		out.Clusters[ctx.Cluster] = cluster
+	}
+	if user, ok := cfg.AuthInfos[ctx.AuthInfo]; ok {
+		out.AuthInfos[ctx.AuthInfo] = user
+	}
+	out.CurrentContext = name
+	return out, nil
+}
@@ -0,0 +1,204 @@
+package registry
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const multiContextKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+  - cluster:
+      server: https://dev.internal:6443
+    name: dev
+  - cluster:
+      server: https://prod.internal:6443
+    name: prod
+contexts:
+  - context:
+      cluster: dev
+      user: dev
+    name: dev
+  - context:
+      cluster: prod
+      user: prod
+    name: prod
+users:
+  - name: dev
+    user:
+      token: dev-token
+  - name: prod
+    user:
+      token: prod-token
+`
+
+func TestResolveCluster_StaticKubeconfigFile(t *testing.T) {
+	repoDir := t.TempDir()
+	os.MkdirAll(filepath.Join(repoDir, "kubeconfigs"), 0o755)
+	writeFile(t, filepath.Join(repoDir, "kubeconfigs", "onprem.yaml"), staticKubeconfig("https://file:6443", "file-token"))
+	os.MkdirAll(filepath.Join(repoDir, "clusters"), 0o755)
+	writeFile(t, filepath.Join(repoDir, "clusters", "onprem.yaml"), `
+apiVersion: kubecm.io/v1alpha1
+kind: Cluster
+metadata:
+  name: onprem
+provider: static
+kubeconfigFile: kubeconfigs/onprem.yaml
+`)
+
+	cl, err := LoadCluster(repoDir, "onprem")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cfg, err := ResolveCluster(cl)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Clusters["test-cluster"].Server != "https://file:6443" {
+		t.Errorf("server = %q, want %q", cfg.Clusters["test-cluster"].Server, "https://file:6443")
+	}
+}
+
+func TestLoadCluster_KubeconfigURL(t *testing.T) {
+	repoDir := t.TempDir()
+	os.MkdirAll(filepath.Join(repoDir, "clusters"), 0o755)
+	writeFile(t, filepath.Join(repoDir, "clusters", "onprem.yaml"), `
+apiVersion: kubecm.io/v1alpha1
+kind: Cluster
+metadata:
+  name: onprem
+provider: static
+kubeconfigURL: https://files.example.com/onprem.yaml
+checksum: sha256:abc
+`)
+
+	cl, err := LoadCluster(repoDir, "onprem")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cl.KubeconfigURL != "https://files.example.com/onprem.yaml" || cl.Checksum != "sha256:abc" {
+		t.Errorf("kubeconfigURL = %q, checksum = %q", cl.KubeconfigURL, cl.Checksum)
+	}
+}
+
+func TestResolveCluster_StaticKubeconfigFileOutsideRepo(t *testing.T) {
+	for _, name := range []string{"../secret.yaml", "/etc/kubeconfig"} {
+		cl := &Cluster{
+			Metadata:       RegistryMetadata{Name: "escape"},
+			Provider:       "static",
+			KubeconfigFile: name,
+			repoDir:        t.TempDir(),
+		}
+		if _, err := ResolveCluster(cl); err == nil {
+			t.Errorf("expected error for kubeconfigFile %q", name)
+		}
+	}
+}
+
+func TestResolveCluster_StaticKubeconfigFileSymlinkOutsideRepo(t *testing.T) {
+	outside := filepath.Join(t.TempDir(), "secret.yaml")
+	writeFile(t, outside, staticKubeconfig("https://secret:6443", "secret-token"))
+	repoDir := t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(repoDir, "link.yaml")); err != nil {
+		t.Skipf("cannot create symlink: %v", err)
+	}
+	cl := &Cluster{
+		Metadata:       RegistryMetadata{Name: "escape"},
+		Provider:       "static",
+		KubeconfigFile: "link.yaml",
+		repoDir:        repoDir,
+	}
+	if _, err := ResolveCluster(cl); err == nil {
+		t.Error("expected error for a kubeconfigFile symlink pointing outside the registry")
+	}
+}
+
+func TestResolveCluster_StaticMultipleSources(t *testing.T) {
+	cl := &Cluster{
+		Metadata:       RegistryMetadata{Name: "both"},
+		Provider:       "static",
+		Kubeconfig:     staticKubeconfig("https://k8s:6443", "tok"),
+		KubeconfigFile: "kubeconfig.yaml",
+	}
+	if _, err := ResolveCluster(cl); err == nil {
+		t.Error("expected error when both kubeconfig and kubeconfigFile are set")
+	}
+}
+
+func TestResolveCluster_StaticSelectContext(t *testing.T) {
+	cl := &Cluster{
+		Metadata:   RegistryMetadata{Name: "multi"},
+		Provider:   "static",
+		Kubeconfig: multiContextKubeconfig,
+		Context:    "prod",
+	}
+
+	cfg, err := ResolveCluster(cl)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.Contexts) != 1 || len(cfg.Clusters) != 1 || len(cfg.AuthInfos) != 1 {
+		t.Fatalf("expected only the selected context, got contexts=%d clusters=%d users=%d",
+			len(cfg.Contexts), len(cfg.Clusters), len(cfg.AuthInfos))
+	}
+	if cfg.AuthInfos["prod"].Token != "prod-token" {
+		t.Errorf("token = %q, want %q", cfg.AuthInfos["prod"].Token, "prod-token")
+	}
+
+	cl.Context = "missing"
+	if _, err := ResolveCluster(cl); err == nil {
+		t.Error("expected error for unknown context")
+	}
+}
+
+func TestResolveCluster_StaticKubeconfigURL(t *testing.T) {
+	body := multiContextKubeconfig
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	orig := httpClient
+	httpClient = srv.Client()
+	defer func() { httpClient = orig }()
+
+	sum := sha256.Sum256([]byte(body))
+
+	tests := []struct {
+		name     string
+		url      string
+		checksum string
+		wantErr  bool
+	}{
+		{"no checksum", srv.URL + "/kubeconfig", "", false},
+		{"valid checksum", srv.URL + "/kubeconfig", "sha256:" + hex.EncodeToString(sum[:]), false},
+		{"bare checksum", srv.URL + "/kubeconfig", hex.EncodeToString(sum[:]), false},
+		{"uppercase checksum", srv.URL + "/kubeconfig", "SHA256:" + strings.ToUpper(hex.EncodeToString(sum[:])), false},
+		{"checksum mismatch", srv.URL + "/kubeconfig", "sha256:deadbeef", true},
+		{"plain http", "http://example.com/kubeconfig", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cl := &Cluster{
+				Metadata:      RegistryMetadata{Name: "remote"},
+				Provider:      "static",
+				KubeconfigURL: tt.url,
+				Checksum:      tt.checksum,
+				Context:       "dev",
+			}
+			cfg, err := ResolveCluster(cl)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ResolveCluster() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && cfg.Clusters["dev"].Server != "https://dev.internal:6443" {
+				t.Errorf("server = %q, want %q", cfg.Clusters["dev"].Server, "https://dev.internal:6443")
+			}
+		})
+	}
+}
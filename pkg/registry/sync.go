@@ -5,16 +5,18 @@ import (
 	"strings"
 	"time"
 
+	"k8s.io/client-go/tools/clientcmd"
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 )
 
 // SyncResult holds the outcome of a sync operation.
 type SyncResult struct {
-	Added    []string
-	Updated  []string
-	Removed  []string
-	Skipped  []string // conflicts: context exists but not managed
-	Errors   []string
+	Added   []string
+	Updated []string
+	Renamed []string // "old -> new": same cluster/user, new context name
+	Removed []string
+	Skipped []string // conflicts: context exists but not managed
+	Errors  []string
 }
 
 // Sync performs a full registry sync for the given entry.
@@ -28,6 +30,8 @@ func Sync(repoDir string, entry *RegistryEntry, currentConfig *clientcmdapi.Conf
 
 	result := &SyncResult{}
 	newContexts := make(map[string]bool)
+	newSources := make(map[string]string)
+	newEndpoints := make(map[string]string)
 	managedSet := make(map[string]bool)
 	for _, ctx := range entry.ManagedContexts {
 		managedSet[ctx] = true
@@ -82,8 +86,16 @@ func Sync(repoDir string, entry *RegistryEntry, currentConfig *clientcmdapi.Conf
 
 		// Merge cluster kubeconfig into current config with prefix
 		mergeClusterConfig(currentConfig, clConfig, role.ContextPrefix, contextName, managedSet, newContexts, result, dryRun)
+		fullName := buildContextName(role.ContextPrefix, contextName, "")
+		newSources[fullName] = contextSource(clusterRef, rc.User)
+		for _, ctx := range clConfig.Contexts {
+			newEndpoints[fullName] = contextEndpoint(clConfig.Clusters[ctx.Cluster], clConfig.AuthInfos[ctx.AuthInfo])
+		}
 	}
 
+	// Carry local state over to contexts that were only renamed
+	renamed := detectRenames(currentConfig, entry, newContexts, newSources, newEndpoints, result, dryRun)
+
 	// Remove stale managed contexts (in managedSet but not in newContexts)
 	for _, ctx := range entry.ManagedContexts {
 		if !newContexts[ctx] {
@@ -91,7 +103,9 @@ func Sync(repoDir string, entry *RegistryEntry, currentConfig *clientcmdapi.Conf
 				if !dryRun {
 					removeContext(currentConfig, ctx)
 				}
-				result.Removed = append(result.Removed, ctx)
+				if !renamed[ctx] {
+					result.Removed = append(result.Removed, ctx)
+				}
 			}
 		}
 	}
@@ -103,6 +117,7 @@ func Sync(repoDir string, entry *RegistryEntry, currentConfig *clientcmdapi.Conf
 			managed = append(managed, ctx)
 		}
 		entry.ManagedContexts = managed
+		entry.ContextSources = newSources
 		now := time.Now().UTC()
 		entry.LastSync = &now
 	}
@@ -166,6 +181,106 @@ func mergeClusterConfig(
 	}
 }
 
+// contextSource identifies where a managed context comes from, independent
+// of the role's prefix or the context's display name.
+func contextSource(clusterRef, user string) string {
+	return clusterRef + "/" + user
+}
+
+// contextEndpoint identifies a context by the cluster and user it connects
+// with. It stands in for the context source of registries synced before the
+// sources were recorded, whose kubeconfig entries are named after the old
+// contexts.
+func contextEndpoint(cluster *clientcmdapi.Cluster, user *clientcmdapi.AuthInfo) string {
+	if cluster == nil || user == nil {
+		return ""
+	}
+	config := clientcmdapi.NewConfig()
+	config.Clusters["cluster"] = cluster
+	config.AuthInfos["user"] = user
+	data, err := clientcmd.Write(*config)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// detectRenames matches stale managed contexts to newly added ones that were
+// generated from the same cluster and user, by their recorded source or,
+// lacking one, by their endpoint. For each match the namespace and
+// current-context are carried over, and the pair is reported as renamed
+// instead of removed + added. It returns the set of old context names.
+func detectRenames(
+	current *clientcmdapi.Config,
+	entry *RegistryEntry,
+	newContexts map[string]bool,
+	newSources map[string]string,
+	newEndpoints map[string]string,
+	result *SyncResult,
+	dryRun bool,
+) map[string]bool {
+	renamed := make(map[string]bool)
+
+	staleBySource := make(map[string]string)
+	staleByEndpoint := make(map[string]string)
+	for _, ctx := range entry.ManagedContexts {
+		if newContexts[ctx] {
+			continue
+		}
+		old, exists := current.Contexts[ctx]
+		if !exists {
+			continue
+		}
+		if src, ok := entry.ContextSources[ctx]; ok {
+			staleBySource[src] = ctx
+			continue
+		}
+		endpoint := contextEndpoint(current.Clusters[old.Cluster], current.AuthInfos[old.AuthInfo])
+		if endpoint == "" {
+			continue
+		}
+		if _, dup := staleByEndpoint[endpoint]; dup {
+			// ambiguous, leave both to be removed
+			staleByEndpoint[endpoint] = ""
+			continue
+		}
+		staleByEndpoint[endpoint] = ctx
+	}
+	if len(staleBySource) == 0 && len(staleByEndpoint) == 0 {
+		return renamed
+	}
+
+	var added []string
+	for _, newName := range result.Added {
+		oldName, ok := staleBySource[newSources[newName]]
+		if !ok {
+			oldName = staleByEndpoint[newEndpoints[newName]]
+			ok = oldName != ""
+		}
+		if !ok || renamed[oldName] {
+			added = append(added, newName)
+			continue
+		}
+		renamed[oldName] = true
+		result.Renamed = append(result.Renamed, oldName+" -> "+newName)
+
+		if dryRun {
+			continue
+		}
+		if ns := current.Contexts[oldName].Namespace; ns != "" {
+			if ctx, ok := current.Contexts[newName]; ok {
+				ctx.Namespace = ns
+			}
+		}
+		if current.CurrentContext == oldName {
+			current.CurrentContext = newName
+		}
+	}
+	result.Added = added
+
+	return renamed
+}
+
 // buildContextName creates the full context name with prefix.
 // Format: <prefix>-<name>, or just <name> if no prefix.
 func buildContextName(prefix, name, _ string) string {
@@ -244,6 +359,12 @@ func FormatSyncResult(r *SyncResult) string {
 			fmt.Fprintf(&sb, "    ~ %s\n", c)
 		}
 	}
+	if len(r.Renamed) > 0 {
+		sb.WriteString("  Renamed:\n")
+		for _, c := range r.Renamed {
+			fmt.Fprintf(&sb, "    > %s\n", c)
+		}
+	}
 	if len(r.Removed) > 0 {
 		sb.WriteString("  Removed:\n")
 		for _, c := range r.Removed {
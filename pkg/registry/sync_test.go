@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"k8s.io/client-go/tools/clientcmd"
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 )
 
@@ -765,10 +766,213 @@ func TestSync_DuplicateClusterNameError(t *testing.T) {
 	}
 }
 
+func TestSync_RenamePrefixCarriesLocalState(t *testing.T) {
+	repoDir := setupTestRegistry(t)
+	entry := &RegistryEntry{
+		Name: "test",
+		Role: "devops",
+		Variables: map[string]string{
+			"Username": "clark",
+		},
+	}
+	currentConfig := clientcmdapi.NewConfig()
+
+	if _, err := Sync(repoDir, entry, currentConfig, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Local state set by the user after the first sync
+	currentConfig.Contexts["test-onprem-dc1"].Namespace = "payments"
+	currentConfig.CurrentContext = "test-onprem-dc1"
+
+	// The role's prefix changes upstream
+	writeFile(t, filepath.Join(repoDir, "roles", "devops.yaml"), `
+apiVersion: kubecm.io/v1alpha1
+kind: Role
+metadata:
+  name: devops
+contextPrefix: "acme"
+fragments:
+  - onprem-dc1
+  - onprem-dc2
+`)
+
+	result, err := Sync(repoDir, entry, currentConfig, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(result.Renamed) != 2 {
+		t.Errorf("expected 2 renamed contexts, got %d: %v", len(result.Renamed), result.Renamed)
+	}
+	if len(result.Added) != 0 || len(result.Removed) != 0 {
+		t.Errorf("expected no added/removed, got added=%v removed=%v", result.Added, result.Removed)
+	}
+
+	if _, ok := currentConfig.Contexts["test-onprem-dc1"]; ok {
+		t.Error("old context should have been removed")
+	}
+	ctx, ok := currentConfig.Contexts["acme-onprem-dc1"]
+	if !ok {
+		t.Fatalf("expected context 'acme-onprem-dc1', got: %v", contextNames(currentConfig))
+	}
+	if ctx.Namespace != "payments" {
+		t.Errorf("namespace = %q, want %q", ctx.Namespace, "payments")
+	}
+	if currentConfig.CurrentContext != "acme-onprem-dc1" {
+		t.Errorf("current-context = %q, want %q", currentConfig.CurrentContext, "acme-onprem-dc1")
+	}
+	if entry.ContextSources["acme-onprem-dc1"] != "onprem-dc1/" {
+		t.Errorf("unexpected context sources: %v", entry.ContextSources)
+	}
+}
+
+func TestSync_RenameLegacyEntry(t *testing.T) {
+	repoDir := setupTestRegistry(t)
+	entry := &RegistryEntry{
+		Name: "test",
+		Role: "devops",
+		Variables: map[string]string{
+			"Username": "clark",
+		},
+	}
+	currentConfig := clientcmdapi.NewConfig()
+
+	if _, err := Sync(repoDir, entry, currentConfig, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// An entry synced before the context sources were recorded, with the
+	// kubeconfig read back from disk
+	entry.ContextSources = nil
+	currentConfig.Contexts["test-onprem-dc1"].Namespace = "payments"
+	currentConfig.CurrentContext = "test-onprem-dc1"
+	kubeconfig := filepath.Join(t.TempDir(), "config")
+	if err := clientcmd.WriteToFile(*currentConfig, kubeconfig); err != nil {
+		t.Fatal(err)
+	}
+	currentConfig, err := clientcmd.LoadFromFile(kubeconfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeFile(t, filepath.Join(repoDir, "roles", "devops.yaml"), `
+apiVersion: kubecm.io/v1alpha1
+kind: Role
+metadata:
+  name: devops
+contextPrefix: "acme"
+fragments:
+  - onprem-dc1
+  - onprem-dc2
+`)
+
+	result, err := Sync(repoDir, entry, currentConfig, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(result.Renamed) != 2 {
+		t.Errorf("expected 2 renamed contexts, got %d: %v", len(result.Renamed), result.Renamed)
+	}
+	if len(result.Added) != 0 || len(result.Removed) != 0 {
+		t.Errorf("expected no added/removed, got added=%v removed=%v", result.Added, result.Removed)
+	}
+	ctx, ok := currentConfig.Contexts["acme-onprem-dc1"]
+	if !ok {
+		t.Fatalf("expected context 'acme-onprem-dc1', got: %v", contextNames(currentConfig))
+	}
+	if ctx.Namespace != "payments" {
+		t.Errorf("namespace = %q, want %q", ctx.Namespace, "payments")
+	}
+	if currentConfig.CurrentContext != "acme-onprem-dc1" {
+		t.Errorf("current-context = %q, want %q", currentConfig.CurrentContext, "acme-onprem-dc1")
+	}
+	if entry.ContextSources["acme-onprem-dc1"] != "onprem-dc1/" {
+		t.Errorf("unexpected context sources: %v", entry.ContextSources)
+	}
+}
+
+func TestSync_RenameRole(t *testing.T) {
+	dir := setupTestRegistryWithUsers(t)
+	entry := &RegistryEntry{
+		Name: "test",
+		Role: "multi-user-role",
+	}
+	currentConfig := clientcmdapi.NewConfig()
+
+	if _, err := Sync(dir, entry, currentConfig, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	currentConfig.Contexts["prod-ro"].Namespace = "monitoring"
+
+	writeFile(t, filepath.Join(dir, "roles", "readers.yaml"), `
+apiVersion: kubecm.io/v1alpha1
+kind: Role
+metadata:
+  name: readers
+contextPrefix: "ro"
+contexts:
+  - cluster: eks-prod
+    user: readonly
+    name: prod
+`)
+	entry.Role = "readers"
+
+	result, err := Sync(dir, entry, currentConfig, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(result.Renamed) != 1 || result.Renamed[0] != "prod-ro -> ro-prod" {
+		t.Errorf("expected rename 'prod-ro -> ro-prod', got %v", result.Renamed)
+	}
+	// prod-admin has no counterpart in the new role
+	if len(result.Removed) != 1 || result.Removed[0] != "prod-admin" {
+		t.Errorf("expected 1 removed context 'prod-admin', got %v", result.Removed)
+	}
+	if ns := currentConfig.Contexts["ro-prod"].Namespace; ns != "monitoring" {
+		t.Errorf("namespace = %q, want %q", ns, "monitoring")
+	}
+}
+
+func TestSync_RenameDryRun(t *testing.T) {
+	dir := setupTestRegistryWithUsers(t)
+	entry := &RegistryEntry{
+		Name:            "test",
+		Role:            "contexts-role",
+		ManagedContexts: []string{"old-eks-prod"},
+		ContextSources: map[string]string{
+			"old-eks-prod": "eks-prod/",
+		},
+	}
+	currentConfig := clientcmdapi.NewConfig()
+	currentConfig.Contexts["old-eks-prod"] = &clientcmdapi.Context{
+		Cluster:   "old-eks-prod",
+		AuthInfo:  "old-eks-prod",
+		Namespace: "team",
+	}
+
+	result, err := Sync(dir, entry, currentConfig, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(result.Renamed) != 1 || result.Renamed[0] != "old-eks-prod -> eks-prod" {
+		t.Errorf("expected rename 'old-eks-prod -> eks-prod', got %v", result.Renamed)
+	}
+	if len(result.Added) != 1 || result.Added[0] != "eks-staging" {
+		t.Errorf("expected 1 added context 'eks-staging', got %v", result.Added)
+	}
+	if _, ok := currentConfig.Contexts["old-eks-prod"]; !ok {
+		t.Error("dry-run should not remove the old context")
+	}
+}
+
 func TestFormatSyncResult(t *testing.T) {
 	r := &SyncResult{
 		Added:   []string{"ctx1"},
 		Updated: []string{"ctx2"},
+		Renamed: []string{"ctx5 -> ctx6"},
 		Removed: []string{"ctx3"},
 		Skipped: []string{"ctx4"},
 		Errors:  []string{"something failed"},
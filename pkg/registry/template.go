@@ -43,6 +43,14 @@ func ResolveClusterTemplates(cl *Cluster, vars map[string]string) error {
 		if cl.AWS.Profile, err = ResolveTemplate(cl.AWS.Profile, vars); err != nil {
 			return fmt.Errorf("aws.profile: %w", err)
 		}
+		if cl.AWS.RoleARN, err = ResolveTemplate(cl.AWS.RoleARN, vars); err != nil {
+			return fmt.Errorf("aws.roleArn: %w", err)
+		}
+		for name, value := range cl.AWS.Env {
+			if cl.AWS.Env[name], err = ResolveTemplate(value, vars); err != nil {
+				return fmt.Errorf("aws.env.%s: %w", name, err)
+			}
+		}
 	}
 
 	if cl.Azure != nil {
@@ -60,11 +68,62 @@ func ResolveClusterTemplates(cl *Cluster, vars map[string]string) error {
 		}
 	}
 
+	if cl.Rancher != nil {
+		if cl.Rancher.ServerURL, err = ResolveTemplate(cl.Rancher.ServerURL, vars); err != nil {
+			return fmt.Errorf("rancher.serverUrl: %w", err)
+		}
+		if cl.Rancher.ClusterID, err = ResolveTemplate(cl.Rancher.ClusterID, vars); err != nil {
+			return fmt.Errorf("rancher.clusterId: %w", err)
+		}
+		if cl.Rancher.APIKeyEnv, err = ResolveTemplate(cl.Rancher.APIKeyEnv, vars); err != nil {
+			return fmt.Errorf("rancher.apiKeyEnv: %w", err)
+		}
+		if cl.Rancher.CAFile, err = ResolveTemplate(cl.Rancher.CAFile, vars); err != nil {
+			return fmt.Errorf("rancher.caFile: %w", err)
+		}
+	}
+
+	if cl.AliCloud != nil {
+		if cl.AliCloud.ClusterID, err = ResolveTemplate(cl.AliCloud.ClusterID, vars); err != nil {
+			return fmt.Errorf("alicloud.clusterId: %w", err)
+		}
+		if cl.AliCloud.AccessKeyIDEnv, err = ResolveTemplate(cl.AliCloud.AccessKeyIDEnv, vars); err != nil {
+			return fmt.Errorf("alicloud.accessKeyIdEnv: %w", err)
+		}
+		if cl.AliCloud.AccessKeySecretEnv, err = ResolveTemplate(cl.AliCloud.AccessKeySecretEnv, vars); err != nil {
+			return fmt.Errorf("alicloud.accessKeySecretEnv: %w", err)
+		}
+	}
+
+	if cl.Tencent != nil {
+		if cl.Tencent.Region, err = ResolveTemplate(cl.Tencent.Region, vars); err != nil {
+			return fmt.Errorf("tencent.region: %w", err)
+		}
+		if cl.Tencent.ClusterID, err = ResolveTemplate(cl.Tencent.ClusterID, vars); err != nil {
+			return fmt.Errorf("tencent.clusterId: %w", err)
+		}
+		if cl.Tencent.SecretIDEnv, err = ResolveTemplate(cl.Tencent.SecretIDEnv, vars); err != nil {
+			return fmt.Errorf("tencent.secretIdEnv: %w", err)
+		}
+		if cl.Tencent.SecretKeyEnv, err = ResolveTemplate(cl.Tencent.SecretKeyEnv, vars); err != nil {
+			return fmt.Errorf("tencent.secretKeyEnv: %w", err)
+		}
+	}
+
 	if cl.Kubeconfig != "" {
 		if cl.Kubeconfig, err = ResolveTemplate(cl.Kubeconfig, vars); err != nil {
 			return fmt.Errorf("kubeconfig: %w", err)
 		}
 	}
+	if cl.KubeconfigFile, err = ResolveTemplate(cl.KubeconfigFile, vars); err != nil {
+		return fmt.Errorf("kubeconfigFile: %w", err)
+	}
+	if cl.KubeconfigURL, err = ResolveTemplate(cl.KubeconfigURL, vars); err != nil {
+		return fmt.Errorf("kubeconfigURL: %w", err)
+	}
+	if cl.Context, err = ResolveTemplate(cl.Context, vars); err != nil {
+		return fmt.Errorf("context: %w", err)
+	}
 
 	return nil
 }
@@ -81,6 +140,9 @@ func ResolveUserTemplates(u *User, vars map[string]string) error {
 		if u.AWS.Profile, err = ResolveTemplate(u.AWS.Profile, vars); err != nil {
 			return fmt.Errorf("aws.profile: %w", err)
 		}
+		if u.AWS.RoleARN, err = ResolveTemplate(u.AWS.RoleARN, vars); err != nil {
+			return fmt.Errorf("aws.roleArn: %w", err)
+		}
 	}
 
 	if u.Azure != nil {
@@ -89,6 +151,52 @@ func ResolveUserTemplates(u *User, vars map[string]string) error {
 		}
 	}
 
+	if u.Rancher != nil {
+		if u.Rancher.ServerURL, err = ResolveTemplate(u.Rancher.ServerURL, vars); err != nil {
+			return fmt.Errorf("rancher.serverUrl: %w", err)
+		}
+		if u.Rancher.APIKeyEnv, err = ResolveTemplate(u.Rancher.APIKeyEnv, vars); err != nil {
+			return fmt.Errorf("rancher.apiKeyEnv: %w", err)
+		}
+	}
+
+	if u.AliCloud != nil {
+		if u.AliCloud.AccessKeyIDEnv, err = ResolveTemplate(u.AliCloud.AccessKeyIDEnv, vars); err != nil {
+			return fmt.Errorf("alicloud.accessKeyIdEnv: %w", err)
+		}
+		if u.AliCloud.AccessKeySecretEnv, err = ResolveTemplate(u.AliCloud.AccessKeySecretEnv, vars); err != nil {
+			return fmt.Errorf("alicloud.accessKeySecretEnv: %w", err)
+		}
+	}
+
+	if u.Tencent != nil {
+		if u.Tencent.SecretIDEnv, err = ResolveTemplate(u.Tencent.SecretIDEnv, vars); err != nil {
+			return fmt.Errorf("tencent.secretIdEnv: %w", err)
+		}
+		if u.Tencent.SecretKeyEnv, err = ResolveTemplate(u.Tencent.SecretKeyEnv, vars); err != nil {
+			return fmt.Errorf("tencent.secretKeyEnv: %w", err)
+		}
+	}
+
+	if u.OIDC != nil {
+		if u.OIDC.IssuerURL, err = ResolveTemplate(u.OIDC.IssuerURL, vars); err != nil {
+			return fmt.Errorf("oidc.issuerUrl: %w", err)
+		}
+		if u.OIDC.ClientID, err = ResolveTemplate(u.OIDC.ClientID, vars); err != nil {
+			return fmt.Errorf("oidc.clientId: %w", err)
+		}
+		for i := range u.OIDC.Scopes {
+			if u.OIDC.Scopes[i], err = ResolveTemplate(u.OIDC.Scopes[i], vars); err != nil {
+				return fmt.Errorf("oidc.scopes[%d]: %w", i, err)
+			}
+		}
+		for i := range u.OIDC.ExtraArgs {
+			if u.OIDC.ExtraArgs[i], err = ResolveTemplate(u.OIDC.ExtraArgs[i], vars); err != nil {
+				return fmt.Errorf("oidc.extraArgs[%d]: %w", i, err)
+			}
+		}
+	}
+
 	return nil
 }
 
@@ -130,17 +130,26 @@ func TestResolveClusterTemplates(t *testing.T) {
 				Region:  "{{ .Region }}",
 				Cluster: "eks-{{ .Env }}",
 				Profile: "{{ .Profile }}",
+				RoleARN: "arn:aws:iam::123456789012:role/{{ .Env }}",
+				Env:     map[string]string{"AWS_STS_REGIONAL_ENDPOINTS": "{{ .Endpoints }}"},
 			},
 		}
 		vars := map[string]string{
-			"Region":  "eu-central-1",
-			"Env":     "prod",
-			"Profile": "admin",
+			"Region":    "eu-central-1",
+			"Env":       "prod",
+			"Profile":   "admin",
+			"Endpoints": "regional",
 		}
 
 		if err := ResolveClusterTemplates(cl, vars); err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
+		if cl.AWS.RoleARN != "arn:aws:iam::123456789012:role/prod" {
+			t.Errorf("roleArn = %q", cl.AWS.RoleARN)
+		}
+		if cl.AWS.Env["AWS_STS_REGIONAL_ENDPOINTS"] != "regional" {
+			t.Errorf("env = %v", cl.AWS.Env)
+		}
 		if cl.AWS.Region != "eu-central-1" {
 			t.Errorf("region = %q, want %q", cl.AWS.Region, "eu-central-1")
 		}
@@ -151,6 +160,53 @@ func TestResolveClusterTemplates(t *testing.T) {
 			t.Errorf("profile = %q, want %q", cl.AWS.Profile, "admin")
 		}
 	})
+
+	t.Run("tencent cluster", func(t *testing.T) {
+		cl := &Cluster{
+			Provider: "tencent",
+			Tencent: &TencentClusterConfig{
+				Region:      "{{ .Region }}",
+				ClusterID:   "cls-{{ .Env }}",
+				SecretIDEnv: "TENCENT_{{ .Env }}_ID",
+			},
+		}
+		vars := map[string]string{
+			"Region": "ap-guangzhou",
+			"Env":    "prod",
+		}
+
+		if err := ResolveClusterTemplates(cl, vars); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if cl.Tencent.Region != "ap-guangzhou" {
+			t.Errorf("region = %q, want %q", cl.Tencent.Region, "ap-guangzhou")
+		}
+		if cl.Tencent.ClusterID != "cls-prod" {
+			t.Errorf("clusterId = %q, want %q", cl.Tencent.ClusterID, "cls-prod")
+		}
+		if cl.Tencent.SecretIDEnv != "TENCENT_prod_ID" {
+			t.Errorf("secretIdEnv = %q, want %q", cl.Tencent.SecretIDEnv, "TENCENT_prod_ID")
+		}
+	})
+
+	t.Run("static external kubeconfig", func(t *testing.T) {
+		cl := &Cluster{
+			Provider:      "static",
+			KubeconfigURL: "https://files.example.com/{{ .Env }}.yaml",
+			Context:       "{{ .Env }}-admin",
+		}
+		vars := map[string]string{"Env": "prod"}
+
+		if err := ResolveClusterTemplates(cl, vars); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if cl.KubeconfigURL != "https://files.example.com/prod.yaml" {
+			t.Errorf("kubeconfigURL = %q, want %q", cl.KubeconfigURL, "https://files.example.com/prod.yaml")
+		}
+		if cl.Context != "prod-admin" {
+			t.Errorf("context = %q, want %q", cl.Context, "prod-admin")
+		}
+	})
 }
 
 func TestResolveUserTemplates(t *testing.T) {
@@ -67,17 +67,22 @@ func (r *Role) NormalizedContexts() []RoleContext {
 
 // User is a users/<name>.yaml file describing authentication credentials.
 type User struct {
-	APIVersion string           `yaml:"apiVersion"`
-	Kind       string           `yaml:"kind"`
-	Metadata   RegistryMetadata `yaml:"metadata"`
-	Provider   string           `yaml:"provider"`
-	AWS        *AWSUserConfig   `yaml:"aws,omitempty"`
-	Azure      *AzureUserConfig `yaml:"azure,omitempty"`
+	APIVersion string              `yaml:"apiVersion"`
+	Kind       string              `yaml:"kind"`
+	Metadata   RegistryMetadata    `yaml:"metadata"`
+	Provider   string              `yaml:"provider"`
+	AWS        *AWSUserConfig      `yaml:"aws,omitempty"`
+	Azure      *AzureUserConfig    `yaml:"azure,omitempty"`
+	Rancher    *RancherUserConfig  `yaml:"rancher,omitempty"`
+	AliCloud   *AliCloudUserConfig `yaml:"alicloud,omitempty"`
+	Tencent    *TencentUserConfig  `yaml:"tencent,omitempty"`
+	OIDC       *OIDCUserConfig     `yaml:"oidc,omitempty"`
 }
 
 // AWSUserConfig holds AWS-specific user settings.
 type AWSUserConfig struct {
 	Profile string `yaml:"profile"`
+	RoleARN string `yaml:"roleArn,omitempty"`
 }
 
 // AzureUserConfig holds Azure-specific user settings.
@@ -85,15 +90,59 @@ type AzureUserConfig struct {
 	TenantID string `yaml:"tenantId,omitempty"`
 }
 
+// RancherUserConfig holds Rancher-specific user settings. The API key
+// itself is read from the environment variable named by APIKeyEnv.
+type RancherUserConfig struct {
+	ServerURL string `yaml:"serverUrl,omitempty"`
+	APIKeyEnv string `yaml:"apiKeyEnv,omitempty"`
+}
+
+// AliCloudUserConfig holds Alibaba Cloud-specific user settings. The keys
+// themselves are read from the named environment variables.
+type AliCloudUserConfig struct {
+	AccessKeyIDEnv     string `yaml:"accessKeyIdEnv,omitempty"`
+	AccessKeySecretEnv string `yaml:"accessKeySecretEnv,omitempty"`
+}
+
+// TencentUserConfig holds Tencent Cloud-specific user settings. The keys
+// themselves are read from the named environment variables.
+type TencentUserConfig struct {
+	SecretIDEnv  string `yaml:"secretIdEnv,omitempty"`
+	SecretKeyEnv string `yaml:"secretKeyEnv,omitempty"`
+}
+
+// OIDCUserConfig configures OIDC login through the kubectl oidc-login
+// (kubelogin) exec plugin. It replaces the credentials of any cluster
+// provider the user is bound to.
+type OIDCUserConfig struct {
+	IssuerURL string   `yaml:"issuerUrl"`
+	ClientID  string   `yaml:"clientId"`
+	Scopes    []string `yaml:"scopes,omitempty"`
+	ExtraArgs []string `yaml:"extraArgs,omitempty"`
+	Command   string   `yaml:"command,omitempty"` // defaults to kubectl
+}
+
 // Cluster is a clusters/<name>.yaml (or fragments/<name>.yaml) file describing one cluster.
 type Cluster struct {
-	APIVersion string           `yaml:"apiVersion"`
-	Kind       string           `yaml:"kind"`
-	Metadata   RegistryMetadata `yaml:"metadata"`
-	Provider   string           `yaml:"provider"` // aws, azure, static
-	AWS        *AWSClusterConfig  `yaml:"aws,omitempty"`
-	Azure      *AzureClusterConfig `yaml:"azure,omitempty"`
-	Kubeconfig string           `yaml:"kubeconfig,omitempty"` // for static provider
+	APIVersion string                 `yaml:"apiVersion"`
+	Kind       string                 `yaml:"kind"`
+	Metadata   RegistryMetadata       `yaml:"metadata"`
+	Provider   string                 `yaml:"provider"` // aws, azure, rancher, alicloud, tencent, static
+	AWS        *AWSClusterConfig      `yaml:"aws,omitempty"`
+	Azure      *AzureClusterConfig    `yaml:"azure,omitempty"`
+	Rancher    *RancherClusterConfig  `yaml:"rancher,omitempty"`
+	AliCloud   *AliCloudClusterConfig `yaml:"alicloud,omitempty"`
+	Tencent    *TencentClusterConfig  `yaml:"tencent,omitempty"`
+	Kubeconfig string                 `yaml:"kubeconfig,omitempty"` // for static provider
+
+	// KubeconfigFile and KubeconfigURL are alternatives to an inline
+	// Kubeconfig for the static provider.
+	KubeconfigFile string `yaml:"kubeconfigFile,omitempty"` // relative to the registry repo
+	KubeconfigURL  string `yaml:"kubeconfigURL,omitempty"`  // https only
+	Checksum       string `yaml:"checksum,omitempty"`       // sha256 of the kubeconfigURL body
+	Context        string `yaml:"context,omitempty"`        // select one context from the kubeconfig
+
+	repoDir string // set by LoadCluster, used to resolve KubeconfigFile
 }
 
 // AWSClusterConfig holds AWS EKS cluster reference.
@@ -101,6 +150,10 @@ type AWSClusterConfig struct {
 	Region  string `yaml:"region"`
 	Cluster string `yaml:"cluster"`
 	Profile string `yaml:"profile,omitempty"`
+	RoleARN string `yaml:"roleArn,omitempty"` // role assumed by the token command
+	// TokenCommand is aws (default) or aws-iam-authenticator.
+	TokenCommand string            `yaml:"tokenCommand,omitempty"`
+	Env          map[string]string `yaml:"env,omitempty"` // extra environment of the token command
 }
 
 // AzureClusterConfig holds Azure AKS cluster reference.
@@ -111,6 +164,35 @@ type AzureClusterConfig struct {
 	TenantID       string `yaml:"tenantId,omitempty"`
 }
 
+// RancherClusterConfig holds a Rancher-managed cluster reference.
+// ServerURL and APIKeyEnv default to RANCHER_SERVER_URL and RANCHER_API_KEY.
+// CAFile verifies a Rancher server using a private CA; a relative path is
+// relative to the registry repo.
+type RancherClusterConfig struct {
+	ServerURL             string `yaml:"serverUrl,omitempty"`
+	ClusterID             string `yaml:"clusterId"`
+	APIKeyEnv             string `yaml:"apiKeyEnv,omitempty"`
+	CAFile                string `yaml:"caFile,omitempty"`
+	InsecureSkipTLSVerify bool   `yaml:"insecureSkipTlsVerify,omitempty"`
+}
+
+// AliCloudClusterConfig holds an Alibaba Cloud ACK cluster reference.
+// Credentials default to ACCESS_KEY_ID and ACCESS_KEY_SECRET.
+type AliCloudClusterConfig struct {
+	ClusterID          string `yaml:"clusterId"`
+	AccessKeyIDEnv     string `yaml:"accessKeyIdEnv,omitempty"`
+	AccessKeySecretEnv string `yaml:"accessKeySecretEnv,omitempty"`
+}
+
+// TencentClusterConfig holds a Tencent Cloud TKE cluster reference.
+// Credentials default to TENCENTCLOUD_SECRET_ID and TENCENTCLOUD_SECRET_KEY.
+type TencentClusterConfig struct {
+	Region       string `yaml:"region"`
+	ClusterID    string `yaml:"clusterId"`
+	SecretIDEnv  string `yaml:"secretIdEnv,omitempty"`
+	SecretKeyEnv string `yaml:"secretKeyEnv,omitempty"`
+}
+
 // Deprecated aliases for backward compatibility with external code.
 type Fragment = Cluster
 type AWSFragment = AWSClusterConfig
@@ -118,9 +200,9 @@ type AzureFragment = AzureClusterConfig
 
 // KubecmConfig is the local ~/.kubecm/config.yaml state file.
 type KubecmConfig struct {
-	APIVersion string           `yaml:"apiVersion"`
-	Kind       string           `yaml:"kind"`
-	Registries []RegistryEntry  `yaml:"registries"`
+	APIVersion string          `yaml:"apiVersion"`
+	Kind       string          `yaml:"kind"`
+	Registries []RegistryEntry `yaml:"registries"`
 }
 
 // RegistryEntry tracks one configured registry.
@@ -132,4 +214,7 @@ type RegistryEntry struct {
 	Variables       map[string]string `yaml:"variables,omitempty"`
 	LastSync        *time.Time        `yaml:"lastSync,omitempty"`
 	ManagedContexts []string          `yaml:"managedContexts,omitempty"`
+	// ContextSources maps each managed context to the cluster/user it was
+	// generated from, so renames can be told apart from removals.
+	ContextSources map[string]string `yaml:"contextSources,omitempty"`
 }
@@ -583,3 +583,108 @@ func TestRegistryUpdateVariable(t *testing.T) {
 		t.Errorf("expected 'updated' in output: %s", output)
 	}
 }
+
+// TestRegistryInfo verifies that info shows roles, clusters and variables.
+func TestRegistryInfo(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping e2e test in short mode")
+	}
+
+	repoDir, _, _, env := setupRegistryTest(t)
+
+	_, err := RunKubecmWithEnv(t, env,
+		"registry", "add", "--name", "acme", "--url", repoDir,
+		"--role", "devops", "--var", "Username=infouser")
+	if err != nil {
+		t.Fatalf("add failed: %v", err)
+	}
+
+	output, err := RunKubecmWithEnv(t, env, "registry", "info", "acme")
+	if err != nil {
+		t.Fatalf("info failed: %v\nOutput: %s", err, output)
+	}
+	for _, want := range []string{"E2E test registry", "infouser", "readonly", "cluster-a", "cluster-b", "Commit:"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected %q in info output: %s", want, output)
+		}
+	}
+}
+
+// TestRegistryRoles verifies that roles can be listed by URL and by registry name.
+func TestRegistryRoles(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping e2e test in short mode")
+	}
+
+	repoDir, _, _, env := setupRegistryTest(t)
+
+	output, err := RunKubecmWithEnv(t, env, "registry", "roles", repoDir)
+	if err != nil {
+		t.Fatalf("roles by URL failed: %v\nOutput: %s", err, output)
+	}
+	for _, want := range []string{"devops", "readonly"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected %q in roles output: %s", want, output)
+		}
+	}
+
+	_, err = RunKubecmWithEnv(t, env,
+		"registry", "add", "--name", "acme", "--url", repoDir,
+		"--role", "readonly", "--var", "Username=rolesuser")
+	if err != nil {
+		t.Fatalf("add failed: %v", err)
+	}
+
+	output, err = RunKubecmWithEnv(t, env, "registry", "roles", "acme")
+	if err != nil {
+		t.Fatalf("roles by name failed: %v\nOutput: %s", err, output)
+	}
+	if !strings.Contains(output, "devops") || !strings.Contains(output, "*") {
+		t.Errorf("expected roles with current marker, got: %s", output)
+	}
+}
+
+// TestRegistryDiff verifies that diff reports pending field changes.
+func TestRegistryDiff(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping e2e test in short mode")
+	}
+
+	repoDir, _, _, env := setupRegistryTest(t)
+
+	_, err := RunKubecmWithEnv(t, env,
+		"registry", "add", "--name", "acme", "--url", repoDir,
+		"--role", "devops", "--var", "Username=diffuser")
+	if err != nil {
+		t.Fatalf("add failed: %v", err)
+	}
+
+	output, err := RunKubecmWithEnv(t, env, "registry", "diff", "acme")
+	if err != nil {
+		t.Fatalf("diff failed: %v\nOutput: %s", err, output)
+	}
+	if !strings.Contains(output, "No differences") {
+		t.Errorf("expected no differences right after sync: %s", output)
+	}
+
+	// Move cluster-a to a new server upstream
+	path := filepath.Join(repoDir, "fragments", "cluster-a.yaml")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading fragment: %v", err)
+	}
+	updated := strings.Replace(string(data), "cluster-a.example.com", "cluster-a.internal", 1)
+	writeRegistryFile(t, path, updated)
+	runGitCommand(t, repoDir, "commit", "-am", "move cluster-a")
+
+	output, err = RunKubecmWithEnv(t, env, "registry", "diff", "acme")
+	if err != nil {
+		t.Fatalf("diff failed: %v\nOutput: %s", err, output)
+	}
+	if !strings.Contains(output, "~ e2e-cluster-a") || !strings.Contains(output, "https://cluster-a.internal:6443") {
+		t.Errorf("expected server change for e2e-cluster-a: %s", output)
+	}
+	if strings.Contains(output, "e2e-cluster-b") {
+		t.Errorf("unchanged context should not be listed: %s", output)
+	}
+}